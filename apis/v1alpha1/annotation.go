@@ -0,0 +1,88 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v1alpha1
+
+const (
+	// AnnotationPrefix is the prefix for all Firehose controller specific
+	// annotations.
+	AnnotationPrefix = "firehose.services.k8s.aws/"
+
+	// DriftPolicyAnnotation is an annotation whose value selects how the
+	// controller reacts when the live delivery stream no longer matches the
+	// last spec it applied. Valid values are the DriftPolicy constants. When
+	// the annotation is absent the controller-wide --drift-policy default is
+	// used.
+	DriftPolicyAnnotation = AnnotationPrefix + "drift-policy"
+
+	// LastAppliedSpecAnnotation is an annotation managed by the controller
+	// that records the JSON encoded spec last sent to Firehose. It is used to
+	// tell drift in the live delivery stream apart from changes made to the
+	// custom resource. Until it is recorded, every difference is drift.
+	LastAppliedSpecAnnotation = AnnotationPrefix + "last-applied-spec"
+
+	// AccessKeyHashAnnotation is an annotation managed by the controller that
+	// records an HMAC-SHA256 of the HTTP endpoint access key last sent to
+	// Firehose, keyed by the UID of the custom resource and the
+	// resourceVersion of the Secret holding the key. DescribeDeliveryStream
+	// never returns the access key, so the hash is used to detect rotations
+	// of the referenced Secret.
+	AccessKeyHashAnnotation = AnnotationPrefix + "access-key-hash"
+
+	// ReferenceOwnerAccountsAnnotation is an annotation whose value is a
+	// comma-separated list of the AWS account IDs, besides the account of the
+	// delivery stream, that the resources referenced from its spec may
+	// belong to. A referenced ACK resource belongs to another account when it
+	// carries the services.k8s.aws/owner-account-id annotation. The accounts
+	// of the references are only checked for the DeliveryStreams that carry
+	// the annotation, or for every DeliveryStream when the controller runs
+	// with --restrict-reference-accounts.
+	ReferenceOwnerAccountsAnnotation = AnnotationPrefix + "reference-owner-accounts"
+
+	// TagsHashAnnotation is an annotation managed by the controller that
+	// records a hash of the tags the delivery stream was known to carry after
+	// the last reconciliation. While both the tags of the spec and the tags
+	// read back with ListTagsForDeliveryStream match the hash, the tags are
+	// not compared.
+	TagsHashAnnotation = AnnotationPrefix + "tags-hash"
+
+	// TakeOwnershipAnnotation is an annotation that, when set to "true",
+	// allows the controller to modify a delivery stream whose owner tag
+	// names another cluster or custom resource, and to replace that tag
+	// with its own.
+	TakeOwnershipAnnotation = AnnotationPrefix + "take-ownership"
+)
+
+// DriftPolicy describes what the controller does when the live delivery
+// stream has drifted from the last applied spec.
+type DriftPolicy string
+
+const (
+	// DriftPolicyEnforce reverts drift by sending the desired spec back to
+	// Firehose.
+	DriftPolicyEnforce DriftPolicy = "Enforce"
+	// DriftPolicyReportOnly leaves the drifted fields of the live delivery
+	// stream untouched and reports them in the ACK.ResourceSynced condition.
+	// Changes made to the custom resource are still applied.
+	DriftPolicyReportOnly DriftPolicy = "ReportOnly"
+	// DriftPolicyAdoptLive copies the drifted live values into the spec of
+	// the custom resource.
+	DriftPolicyAdoptLive DriftPolicy = "AdoptLive"
+)
+
+// DriftPolicies lists all the supported DriftPolicy values.
+var DriftPolicies = []DriftPolicy{
+	DriftPolicyEnforce,
+	DriftPolicyReportOnly,
+	DriftPolicyAdoptLive,
+}
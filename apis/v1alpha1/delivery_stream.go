@@ -33,6 +33,7 @@ type DeliveryStreamSpec struct {
 	//
 	// Regex Pattern: `^[a-zA-Z0-9_.-]+$`
 	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="Value is immutable once set"
 	DeliveryStreamName *string `json:"deliveryStreamName"`
 	// The Firehose stream type. This parameter can be one of the following values:
 	//
@@ -110,6 +111,8 @@ type DeliveryStreamStatus struct {
 	// The date and time that the Firehose stream was last updated.
 	// +kubebuilder:validation:Optional
 	LastUpdateTimestamp *metav1.Time `json:"lastUpdateTimestamp,omitempty"`
+	// +kubebuilder:validation:Optional
+	References []*ReferenceStatus `json:"references,omitempty"`
 	// Each time the destination is updated for a Firehose stream, the version ID
 	// is changed, and the current version ID is required when updating the destination.
 	// This is so that the service knows it is applying the changes to the correct
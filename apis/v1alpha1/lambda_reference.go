@@ -0,0 +1,38 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v1alpha1
+
+import (
+	ackv1alpha1 "github.com/aws-controllers-k8s/runtime/apis/core/v1alpha1"
+)
+
+// LambdaReferenceKind is the kind of ACK Lambda resource a LambdaReference
+// points to.
+type LambdaReferenceKind string
+
+const (
+	LambdaReferenceKindFunction LambdaReferenceKind = "Function"
+	LambdaReferenceKindAlias    LambdaReferenceKind = "Alias"
+	LambdaReferenceKindVersion  LambdaReferenceKind = "Version"
+)
+
+// LambdaReference references an ACK Lambda Function, Alias or Version
+// resource whose ARN is used as the LambdaArn parameter of a processor. Only
+// valid for processors of type Lambda.
+type LambdaReference struct {
+	// Kind of the referenced Lambda resource. Defaults to Function.
+	// +kubebuilder:validation:Enum=Function;Alias;Version
+	Kind *string                           `json:"kind,omitempty"`
+	From *ackv1alpha1.AWSResourceReference `json:"from,omitempty"`
+}
@@ -0,0 +1,49 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v1alpha1
+
+// ReferenceState is the resolution state of a resource reference.
+type ReferenceState string
+
+const (
+	// ReferenceStateResolved means the referenced resource is synced and
+	// its value was copied into the spec.
+	ReferenceStateResolved ReferenceState = "Resolved"
+	// ReferenceStateNotSynced means the referenced resource exists but isn't
+	// synced with its AWS resource yet, or is in a terminal state.
+	ReferenceStateNotSynced ReferenceState = "NotSynced"
+	// ReferenceStateUnresolved means the referenced resource couldn't be
+	// read, usually because it doesn't exist.
+	ReferenceStateUnresolved ReferenceState = "Unresolved"
+)
+
+// ReferenceStatus reports the resolution of one resource reference of the
+// spec.
+type ReferenceStatus struct {
+	// Path of the reference field in the spec, e.g.
+	// HTTPEndpointDestinationConfiguration.RoleRef.
+	Path *string `json:"path,omitempty"`
+	// APIVersion of the referenced resource.
+	APIVersion *string `json:"apiVersion,omitempty"`
+	// Kind of the referenced resource.
+	Kind *string `json:"kind,omitempty"`
+	// Namespace of the referenced resource.
+	Namespace *string `json:"namespace,omitempty"`
+	// Name of the referenced resource.
+	Name *string `json:"name,omitempty"`
+	// +kubebuilder:validation:Enum=Resolved;NotSynced;Unresolved
+	State *string `json:"state,omitempty"`
+	// Message explains why the reference isn't resolved.
+	Message *string `json:"message,omitempty"`
+}
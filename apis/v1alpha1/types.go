@@ -225,9 +225,11 @@ type CatalogConfiguration struct {
 
 // Describes the Amazon CloudWatch logging options for your Firehose stream.
 type CloudWatchLoggingOptions struct {
-	Enabled       *bool   `json:"enabled,omitempty"`
-	LogGroupName  *string `json:"logGroupName,omitempty"`
-	LogStreamName *string `json:"logStreamName,omitempty"`
+	Enabled           *bool                                    `json:"enabled,omitempty"`
+	LogGroupName      *string                                  `json:"logGroupName,omitempty"`
+	LogGroupRef       *ackv1alpha1.AWSResourceReferenceWrapper `json:"logGroupRef,omitempty"`
+	LogGroupValueFrom *ValueFrom                               `json:"logGroupValueFrom,omitempty"`
+	LogStreamName     *string                                  `json:"logStreamName,omitempty"`
 }
 
 // Describes a COPY command for Amazon Redshift.
@@ -435,8 +437,9 @@ type DeliveryStreamEncryptionConfiguration struct {
 type DeliveryStreamEncryptionConfigurationInput struct {
 	KeyARN *string `json:"keyARN,omitempty"`
 	// Reference field for KeyARN
-	KeyRef  *ackv1alpha1.AWSResourceReferenceWrapper `json:"keyRef,omitempty"`
-	KeyType *string                                  `json:"keyType,omitempty"`
+	KeyRef       *ackv1alpha1.AWSResourceReferenceWrapper `json:"keyRef,omitempty"`
+	KeyType      *string                                  `json:"keyType,omitempty"`
+	KeyValueFrom *ValueFrom                               `json:"keyValueFrom,omitempty"`
 }
 
 // The deserializer you want Firehose to use for converting the input data from
@@ -806,8 +809,9 @@ type HTTPEndpointDestinationConfiguration struct {
 	RetryOptions *HTTPEndpointRetryOptions `json:"retryOptions,omitempty"`
 	RoleARN      *string                   `json:"roleARN,omitempty"`
 	// Reference field for RoleARN
-	RoleRef      *ackv1alpha1.AWSResourceReferenceWrapper `json:"roleRef,omitempty"`
-	S3BackupMode *string                                  `json:"s3BackupMode,omitempty"`
+	RoleRef       *ackv1alpha1.AWSResourceReferenceWrapper `json:"roleRef,omitempty"`
+	RoleValueFrom *ValueFrom                               `json:"roleValueFrom,omitempty"`
+	S3BackupMode  *string                                  `json:"s3BackupMode,omitempty"`
 	// Describes the configuration of a destination in Amazon S3.
 	S3Configuration *S3DestinationConfiguration `json:"s3Configuration,omitempty"`
 	// The structure that defines how Firehose accesses the secret.
@@ -1007,7 +1011,8 @@ type InputFormatConfiguration struct {
 type KMSEncryptionConfig struct {
 	AWSKMSKeyARN *string `json:"awsKMSKeyARN,omitempty"`
 	// Reference field for AWSKMSKeyARN
-	AWSKMSKeyRef *ackv1alpha1.AWSResourceReferenceWrapper `json:"awsKMSKeyRef,omitempty"`
+	AWSKMSKeyRef       *ackv1alpha1.AWSResourceReferenceWrapper `json:"awsKMSKeyRef,omitempty"`
+	AWSKMSKeyValueFrom *ValueFrom                               `json:"awsKMSKeyValueFrom,omitempty"`
 }
 
 // The stream and role Amazon Resource Names (ARNs) for a Kinesis data stream
@@ -1124,8 +1129,10 @@ type ProcessingConfiguration struct {
 // delivered to Amazon S3, choose AppendDelimiterToRecord as a processor type.
 // You don’t have to put a processor parameter when you select AppendDelimiterToRecord.
 type Processor struct {
-	Parameters []*ProcessorParameter `json:"parameters,omitempty"`
-	Type       *string               `json:"type,omitempty"`
+	LambdaRef       *LambdaReference      `json:"lambdaRef,omitempty"`
+	LambdaValueFrom *ValueFrom            `json:"lambdaValueFrom,omitempty"`
+	Parameters      []*ProcessorParameter `json:"parameters,omitempty"`
+	Type            *string               `json:"type,omitempty"`
 }
 
 // Describes the processor parameter.
@@ -1220,7 +1227,8 @@ type RetryOptions struct {
 type S3DestinationConfiguration struct {
 	BucketARN *string `json:"bucketARN,omitempty"`
 	// Reference field for BucketARN
-	BucketRef *ackv1alpha1.AWSResourceReferenceWrapper `json:"bucketRef,omitempty"`
+	BucketRef       *ackv1alpha1.AWSResourceReferenceWrapper `json:"bucketRef,omitempty"`
+	BucketValueFrom *ValueFrom                               `json:"bucketValueFrom,omitempty"`
 	// Describes hints for the buffering to perform before delivering data to the
 	// destination. These options are treated as hints, and therefore Firehose might
 	// choose to use different values when it is optimal. The SizeInMBs and IntervalInSeconds
@@ -1236,7 +1244,8 @@ type S3DestinationConfiguration struct {
 	Prefix                  *string                  `json:"prefix,omitempty"`
 	RoleARN                 *string                  `json:"roleARN,omitempty"`
 	// Reference field for RoleARN
-	RoleRef *ackv1alpha1.AWSResourceReferenceWrapper `json:"roleRef,omitempty"`
+	RoleRef       *ackv1alpha1.AWSResourceReferenceWrapper `json:"roleRef,omitempty"`
+	RoleValueFrom *ValueFrom                               `json:"roleValueFrom,omitempty"`
 }
 
 // Describes a destination in Amazon S3.
@@ -1301,10 +1310,12 @@ type SecretsManagerConfiguration struct {
 	Enabled *bool   `json:"enabled,omitempty"`
 	RoleARN *string `json:"roleARN,omitempty"`
 	// Reference field for RoleARN
-	RoleRef   *ackv1alpha1.AWSResourceReferenceWrapper `json:"roleRef,omitempty"`
-	SecretARN *string                                  `json:"secretARN,omitempty"`
+	RoleRef       *ackv1alpha1.AWSResourceReferenceWrapper `json:"roleRef,omitempty"`
+	RoleValueFrom *ValueFrom                               `json:"roleValueFrom,omitempty"`
+	SecretARN     *string                                  `json:"secretARN,omitempty"`
 	// Reference field for SecretARN
-	SecretRef *ackv1alpha1.AWSResourceReferenceWrapper `json:"secretRef,omitempty"`
+	SecretRef       *ackv1alpha1.AWSResourceReferenceWrapper `json:"secretRef,omitempty"`
+	SecretValueFrom *ValueFrom                               `json:"secretValueFrom,omitempty"`
 }
 
 // The serializer that you want Firehose to use to convert data to the target
@@ -0,0 +1,37 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v1alpha1
+
+import (
+	ackv1alpha1 "github.com/aws-controllers-k8s/runtime/apis/core/v1alpha1"
+)
+
+// ValueFrom reads the value of a field from a key of a ConfigMap or a
+// Secret. It is an alternative to the resource references of the spec for
+// identifiers published outside of ACK, e.g. Terraform outputs.
+// +kubebuilder:validation:XValidation:rule="has(self.configMapKeyRef) != has(self.secretKeyRef)",message="exactly one of configMapKeyRef and secretKeyRef must be set"
+type ValueFrom struct {
+	ConfigMapKeyRef *ConfigMapKeyReference          `json:"configMapKeyRef,omitempty"`
+	SecretKeyRef    *ackv1alpha1.SecretKeyReference `json:"secretKeyRef,omitempty"`
+}
+
+// ConfigMapKeyReference selects a key of a ConfigMap.
+type ConfigMapKeyReference struct {
+	// Name of the ConfigMap.
+	Name string `json:"name"`
+	// Namespace of the ConfigMap. Defaults to the namespace of the resource.
+	Namespace string `json:"namespace,omitempty"`
+	// Key of the value in the ConfigMap.
+	Key string `json:"key"`
+}
@@ -622,6 +622,16 @@ func (in *CloudWatchLoggingOptions) DeepCopyInto(out *CloudWatchLoggingOptions)
 		*out = new(string)
 		**out = **in
 	}
+	if in.LogGroupRef != nil {
+		in, out := &in.LogGroupRef, &out.LogGroupRef
+		*out = new(corev1alpha1.AWSResourceReferenceWrapper)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LogGroupValueFrom != nil {
+		in, out := &in.LogGroupValueFrom, &out.LogGroupValueFrom
+		*out = new(ValueFrom)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.LogStreamName != nil {
 		in, out := &in.LogStreamName, &out.LogStreamName
 		*out = new(string)
@@ -639,6 +649,21 @@ func (in *CloudWatchLoggingOptions) DeepCopy() *CloudWatchLoggingOptions {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigMapKeyReference) DeepCopyInto(out *ConfigMapKeyReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigMapKeyReference.
+func (in *ConfigMapKeyReference) DeepCopy() *ConfigMapKeyReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigMapKeyReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CopyCommand) DeepCopyInto(out *CopyCommand) {
 	*out = *in
@@ -1210,6 +1235,11 @@ func (in *DeliveryStreamEncryptionConfigurationInput) DeepCopyInto(out *Delivery
 		*out = new(string)
 		**out = **in
 	}
+	if in.KeyValueFrom != nil {
+		in, out := &in.KeyValueFrom, &out.KeyValueFrom
+		*out = new(ValueFrom)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeliveryStreamEncryptionConfigurationInput.
@@ -1347,6 +1377,17 @@ func (in *DeliveryStreamStatus) DeepCopyInto(out *DeliveryStreamStatus) {
 		in, out := &in.LastUpdateTimestamp, &out.LastUpdateTimestamp
 		*out = (*in).DeepCopy()
 	}
+	if in.References != nil {
+		in, out := &in.References, &out.References
+		*out = make([]*ReferenceStatus, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(ReferenceStatus)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
 	if in.VersionID != nil {
 		in, out := &in.VersionID, &out.VersionID
 		*out = new(string)
@@ -2343,6 +2384,11 @@ func (in *HTTPEndpointDestinationConfiguration) DeepCopyInto(out *HTTPEndpointDe
 		*out = new(corev1alpha1.AWSResourceReferenceWrapper)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.RoleValueFrom != nil {
+		in, out := &in.RoleValueFrom, &out.RoleValueFrom
+		*out = new(ValueFrom)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.S3BackupMode != nil {
 		in, out := &in.S3BackupMode, &out.S3BackupMode
 		*out = new(string)
@@ -2853,6 +2899,11 @@ func (in *KMSEncryptionConfig) DeepCopyInto(out *KMSEncryptionConfig) {
 		*out = new(corev1alpha1.AWSResourceReferenceWrapper)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.AWSKMSKeyValueFrom != nil {
+		in, out := &in.AWSKMSKeyValueFrom, &out.AWSKMSKeyValueFrom
+		*out = new(ValueFrom)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KMSEncryptionConfig.
@@ -2919,6 +2970,31 @@ func (in *KinesisStreamSourceDescription) DeepCopy() *KinesisStreamSourceDescrip
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LambdaReference) DeepCopyInto(out *LambdaReference) {
+	*out = *in
+	if in.Kind != nil {
+		in, out := &in.Kind, &out.Kind
+		*out = new(string)
+		**out = **in
+	}
+	if in.From != nil {
+		in, out := &in.From, &out.From
+		*out = new(corev1alpha1.AWSResourceReference)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LambdaReference.
+func (in *LambdaReference) DeepCopy() *LambdaReference {
+	if in == nil {
+		return nil
+	}
+	out := new(LambdaReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MSKSourceConfiguration) DeepCopyInto(out *MSKSourceConfiguration) {
 	*out = *in
@@ -3248,6 +3324,16 @@ func (in *ProcessingConfiguration) DeepCopy() *ProcessingConfiguration {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Processor) DeepCopyInto(out *Processor) {
 	*out = *in
+	if in.LambdaRef != nil {
+		in, out := &in.LambdaRef, &out.LambdaRef
+		*out = new(LambdaReference)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LambdaValueFrom != nil {
+		in, out := &in.LambdaValueFrom, &out.LambdaValueFrom
+		*out = new(ValueFrom)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Parameters != nil {
 		in, out := &in.Parameters, &out.Parameters
 		*out = make([]*ProcessorParameter, len(*in))
@@ -3541,6 +3627,56 @@ func (in *RedshiftRetryOptions) DeepCopy() *RedshiftRetryOptions {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReferenceStatus) DeepCopyInto(out *ReferenceStatus) {
+	*out = *in
+	if in.Path != nil {
+		in, out := &in.Path, &out.Path
+		*out = new(string)
+		**out = **in
+	}
+	if in.APIVersion != nil {
+		in, out := &in.APIVersion, &out.APIVersion
+		*out = new(string)
+		**out = **in
+	}
+	if in.Kind != nil {
+		in, out := &in.Kind, &out.Kind
+		*out = new(string)
+		**out = **in
+	}
+	if in.Namespace != nil {
+		in, out := &in.Namespace, &out.Namespace
+		*out = new(string)
+		**out = **in
+	}
+	if in.Name != nil {
+		in, out := &in.Name, &out.Name
+		*out = new(string)
+		**out = **in
+	}
+	if in.State != nil {
+		in, out := &in.State, &out.State
+		*out = new(string)
+		**out = **in
+	}
+	if in.Message != nil {
+		in, out := &in.Message, &out.Message
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReferenceStatus.
+func (in *ReferenceStatus) DeepCopy() *ReferenceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ReferenceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RetryOptions) DeepCopyInto(out *RetryOptions) {
 	*out = *in
@@ -3574,6 +3710,11 @@ func (in *S3DestinationConfiguration) DeepCopyInto(out *S3DestinationConfigurati
 		*out = new(corev1alpha1.AWSResourceReferenceWrapper)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.BucketValueFrom != nil {
+		in, out := &in.BucketValueFrom, &out.BucketValueFrom
+		*out = new(ValueFrom)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.BufferingHints != nil {
 		in, out := &in.BufferingHints, &out.BufferingHints
 		*out = new(BufferingHints)
@@ -3614,6 +3755,11 @@ func (in *S3DestinationConfiguration) DeepCopyInto(out *S3DestinationConfigurati
 		*out = new(corev1alpha1.AWSResourceReferenceWrapper)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.RoleValueFrom != nil {
+		in, out := &in.RoleValueFrom, &out.RoleValueFrom
+		*out = new(ValueFrom)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3DestinationConfiguration.
@@ -3819,6 +3965,11 @@ func (in *SecretsManagerConfiguration) DeepCopyInto(out *SecretsManagerConfigura
 		*out = new(corev1alpha1.AWSResourceReferenceWrapper)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.RoleValueFrom != nil {
+		in, out := &in.RoleValueFrom, &out.RoleValueFrom
+		*out = new(ValueFrom)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.SecretARN != nil {
 		in, out := &in.SecretARN, &out.SecretARN
 		*out = new(string)
@@ -3829,6 +3980,11 @@ func (in *SecretsManagerConfiguration) DeepCopyInto(out *SecretsManagerConfigura
 		*out = new(corev1alpha1.AWSResourceReferenceWrapper)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.SecretValueFrom != nil {
+		in, out := &in.SecretValueFrom, &out.SecretValueFrom
+		*out = new(ValueFrom)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretsManagerConfiguration.
@@ -4621,6 +4777,31 @@ func (in *Tag) DeepCopy() *Tag {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ValueFrom) DeepCopyInto(out *ValueFrom) {
+	*out = *in
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(ConfigMapKeyReference)
+		**out = **in
+	}
+	if in.SecretKeyRef != nil {
+		in, out := &in.SecretKeyRef, &out.SecretKeyRef
+		*out = new(corev1alpha1.SecretKeyReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ValueFrom.
+func (in *ValueFrom) DeepCopy() *ValueFrom {
+	if in == nil {
+		return nil
+	}
+	out := new(ValueFrom)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VPCConfiguration) DeepCopyInto(out *VPCConfiguration) {
 	*out = *in
@@ -30,8 +30,11 @@ import (
 	s3apitypes "github.com/aws-controllers-k8s/s3-controller/apis/v1alpha1"
 	secretsmanagerapitypes "github.com/aws-controllers-k8s/secretsmanager-controller/apis/v1alpha1"
 	flag "github.com/spf13/pflag"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrlrt "sigs.k8s.io/controller-runtime"
 	ctrlrtcache "sigs.k8s.io/controller-runtime/pkg/cache"
@@ -41,11 +44,15 @@ import (
 	ctrlrtwebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	svctypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+	svcconfig "github.com/aws-controllers-k8s/firehose-controller/pkg/config"
+	"github.com/aws-controllers-k8s/firehose-controller/pkg/refwatch"
 	svcresource "github.com/aws-controllers-k8s/firehose-controller/pkg/resource"
 
-	_ "github.com/aws-controllers-k8s/firehose-controller/pkg/resource/delivery_stream"
+	svcdeliverystream "github.com/aws-controllers-k8s/firehose-controller/pkg/resource/delivery_stream"
 
 	"github.com/aws-controllers-k8s/firehose-controller/pkg/version"
+	// Registers the admission webhooks with the ACK runtime's webhook registry.
+	_ "github.com/aws-controllers-k8s/firehose-controller/pkg/webhook"
 )
 
 var (
@@ -68,7 +75,9 @@ func init() {
 
 func main() {
 	var ackCfg ackcfg.Config
+	var svcCfg svcconfig.Config
 	ackCfg.BindFlags()
+	svcCfg.BindFlags()
 	flag.Parse()
 	ackCfg.SetupLogger()
 
@@ -86,6 +95,13 @@ func main() {
 		)
 		os.Exit(1)
 	}
+	if err := svcCfg.Validate(); err != nil {
+		setupLog.Error(
+			err, "Unable to create controller manager",
+			"aws.service", awsServiceAlias,
+		)
+		os.Exit(1)
+	}
 
 	host, port, err := ackrtutil.GetHostPort(ackCfg.WebhookServerAddr)
 	if err != nil {
@@ -146,6 +162,21 @@ func main() {
 		os.Exit(1)
 	}
 
+	if svcCfg.ClusterID == "" {
+		kubeSystem := &corev1.Namespace{}
+		err = mgr.GetAPIReader().Get(ctx, types.NamespacedName{Name: metav1.NamespaceSystem}, kubeSystem)
+		if err != nil {
+			setupLog.Error(
+				err, "unable to determine the cluster ID, set it with --cluster-id",
+				"aws.service", awsServiceAlias,
+			)
+			os.Exit(1)
+		}
+		svcCfg.ClusterID = string(kubeSystem.UID)
+	}
+	svcconfig.Set(svcCfg)
+	svcdeliverystream.SetAPIReader(mgr.GetAPIReader())
+
 	stopChan := ctrlrt.SetupSignalHandler()
 
 	setupLog.Info(
@@ -179,13 +210,30 @@ func main() {
 		}
 	}
 
-	if err = sc.BindControllerManager(mgr, ackCfg); err != nil {
+	// The DeliveryStream controller is registered through a manager that adds
+	// to it the watches of the Secrets and resources it references.
+	watchMgr, err := refwatch.NewManager(mgr, svcdeliverystream.ReferencedGroupVersionKinds)
+	if err != nil {
+		setupLog.Error(
+			err, "unable to set up reference watches",
+			"aws.service", awsServiceAlias,
+		)
+		os.Exit(1)
+	}
+	if err = sc.BindControllerManager(watchMgr, ackCfg); err != nil {
 		setupLog.Error(
 			err, "unable bind to controller manager to service controller",
 			"aws.service", awsServiceAlias,
 		)
 		os.Exit(1)
 	}
+	if err = watchMgr.Verify(ackCfg); err != nil {
+		setupLog.Error(
+			err, "unable to set up reference watches",
+			"aws.service", awsServiceAlias,
+		)
+		os.Exit(1)
+	}
 
 	if err = mgr.AddHealthzCheck("health", ctrlrthealthz.Ping); err != nil {
 		setupLog.Error(
@@ -0,0 +1,112 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Command fake-firehose serves an in-memory stand-in for the Firehose API,
+// so that the controller can run its full reconcile loop, for instance
+// against a kind cluster, without an AWS account or network access.
+//
+// It serves the Firehose JSON protocol for the delivery stream, encryption
+// and tag operations the controller uses, with the asynchronous state
+// transitions of the service, as well as the STS GetCallerIdentity call the
+// controller makes at startup. State is lost when it exits.
+//
+// Point the controller at it with its endpoint flags, or with the
+// aws.endpoint_url and aws.identity_endpoint_url values of the Helm chart:
+//
+//	fake-firehose --listen-address :4573
+//	controller --aws-region us-west-2 \
+//	    --aws-endpoint-url http://localhost:4573 \
+//	    --aws-identity-endpoint-url http://localhost:4573 \
+//	    --allow-unsafe-aws-endpoint-urls
+//
+// The SDK still needs credentials to sign requests, any value does, for
+// instance AWS_ACCESS_KEY_ID=fake and AWS_SECRET_ACCESS_KEY=fake.
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/aws-controllers-k8s/firehose-controller/pkg/fakefirehose"
+)
+
+func main() {
+	var (
+		addr  string
+		opts  fakefirehose.Options
+		quiet bool
+	)
+	flag.StringVar(&addr, "listen-address", ":4573", "The address the server listens on.")
+	flag.StringVar(&opts.AccountID, "account-id", "000000000000", "The AWS account ID of the delivery stream ARNs and of GetCallerIdentity.")
+	flag.StringVar(&opts.Region, "region", "us-west-2", "The AWS region of the delivery stream ARNs.")
+	flag.DurationVar(&opts.TransitionDelay, "transition-delay", fakefirehose.DefaultTransitionDelay,
+		"How long delivery streams stay CREATING or DELETING, and their encryption ENABLING or DISABLING.")
+	flag.BoolVar(&quiet, "quiet", false, "Don't log the requests.")
+	flag.Parse()
+
+	var handler http.Handler = fakefirehose.Handler(fakefirehose.New(opts))
+	if !quiet {
+		handler = logRequests(handler)
+	}
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("serving the Firehose API on %s", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+}
+
+// statusRecorder records the status code of a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// logRequests logs the operation and response status of every request.
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		op := r.Header.Get("X-Amz-Target")
+		if op == "" {
+			op = r.PostForm.Get("Action")
+		}
+		log.Printf("%s %d %s", op, rec.status, rec.Header().Get("X-Amzn-ErrorType"))
+	})
+}
@@ -0,0 +1,176 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Command firehose-export prints existing delivery streams as DeliveryStream
+// manifests, so that streams created outside of Kubernetes can be brought
+// under the management of the controller.
+//
+// The delivery streams named on the command line, or all the delivery
+// streams of the account and region if none is, are read the way the
+// controller reads them and printed as a YAML stream on the standard output:
+//
+//	firehose-export --aws-region us-west-2 --namespace streams \
+//	    --adoption-policy adopt > streams.yaml
+//
+// DescribeDeliveryStream never returns the access key of an HTTP endpoint,
+// so each manifest refers to a <name>-access-key Secret holding it under the
+// accessKey key, which has to be created before the manifests are applied.
+//
+// With --resolve-references, the ARNs of the IAM Roles, S3 Buckets, KMS Keys,
+// Secrets Manager Secrets and Lambda functions, and the names of the log
+// groups, that are managed by ACK resources of the current kubeconfig
+// context are replaced with references to those resources.
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	ackrt "github.com/aws-controllers-k8s/runtime/pkg/runtime"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	svcsdk "github.com/aws/aws-sdk-go-v2/service/firehose"
+	flag "github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlconfig "sigs.k8s.io/controller-runtime/pkg/client/config"
+	"sigs.k8s.io/yaml"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+	svcresource "github.com/aws-controllers-k8s/firehose-controller/pkg/resource/delivery_stream"
+)
+
+func main() {
+	var (
+		region            string
+		endpointURL       string
+		opts              svcresource.ExportOptions
+		adoptionPolicy    string
+		resolveReferences bool
+		referenceNS       string
+	)
+	flag.StringVar(&region, "aws-region", "", "The AWS region of the delivery streams. Defaults to the region of the AWS configuration.")
+	flag.StringVar(&endpointURL, "aws-endpoint-url", "", "The Firehose endpoint URL, to use instead of the endpoint of the region.")
+	flag.StringVar(&opts.Namespace, "namespace", "default", "The namespace of the DeliveryStream manifests.")
+	flag.StringVar(&adoptionPolicy, "adoption-policy", "",
+		fmt.Sprintf("The adoption policy the manifests are annotated with, %q or %q. No adoption annotations are set when empty.",
+			ackrt.AdoptionPolicy_Adopt, ackrt.AdoptionPolicy_AdoptOrCreate))
+	flag.BoolVar(&resolveReferences, "resolve-references", false,
+		"Replace the ARNs and log group names of the resources managed by ACK in the current kubeconfig context with references to them.")
+	flag.StringVar(&referenceNS, "reference-namespace", "",
+		"The namespace the referenced ACK resources are looked up in. Defaults to all namespaces.")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] [delivery stream name...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	switch ackrt.AdoptionPolicy(adoptionPolicy) {
+	case "", ackrt.AdoptionPolicy_Adopt, ackrt.AdoptionPolicy_AdoptOrCreate:
+		opts.AdoptionPolicy = ackrt.AdoptionPolicy(adoptionPolicy)
+	default:
+		fatalf("unsupported adoption policy %q", adoptionPolicy)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
+	if err != nil {
+		fatalf("loading the AWS configuration: %v", err)
+	}
+	client := svcsdk.NewFromConfig(cfg, func(o *svcsdk.Options) {
+		if endpointURL != "" {
+			o.BaseEndpoint = aws.String(endpointURL)
+		}
+	})
+
+	if resolveReferences {
+		restConfig, err := ctrlconfig.GetConfig()
+		if err != nil {
+			fatalf("loading the kubeconfig: %v", err)
+		}
+		kc, err := ctrlclient.New(restConfig, ctrlclient.Options{})
+		if err != nil {
+			fatalf("creating the Kubernetes client: %v", err)
+		}
+		opts.References, err = svcresource.NewReferenceIndex(ctx, kc, referenceNS)
+		if err != nil {
+			fatalf("indexing the ACK resources: %v", err)
+		}
+	}
+
+	names := flag.Args()
+	if len(names) == 0 {
+		if names, err = listDeliveryStreams(ctx, client); err != nil {
+			fatalf("listing the delivery streams: %v", err)
+		}
+	}
+	for i, name := range names {
+		ko, err := svcresource.Export(ctx, client, name, opts)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		if i > 0 {
+			fmt.Fprintln(os.Stdout, "---")
+		}
+		if err := writeManifest(os.Stdout, ko); err != nil {
+			fatalf("writing the manifest of delivery stream %q: %v", name, err)
+		}
+	}
+}
+
+// listDeliveryStreams returns the names of all the delivery streams.
+func listDeliveryStreams(ctx context.Context, client *svcsdk.Client) ([]string, error) {
+	var names []string
+	input := &svcsdk.ListDeliveryStreamsInput{}
+	for {
+		out, err := client.ListDeliveryStreams(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, out.DeliveryStreamNames...)
+		if !aws.ToBool(out.HasMoreDeliveryStreams) || len(out.DeliveryStreamNames) == 0 {
+			return names, nil
+		}
+		input.ExclusiveStartDeliveryStreamName = aws.String(names[len(names)-1])
+	}
+}
+
+// writeManifest writes ko as YAML, without its empty status and creation
+// timestamp.
+func writeManifest(w io.Writer, ko *svcapitypes.DeliveryStream) error {
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(ko)
+	if err != nil {
+		return err
+	}
+	delete(obj, "status")
+	if metadata, ok := obj["metadata"].(map[string]interface{}); ok {
+		delete(metadata, "creationTimestamp")
+	}
+	b, err := yaml.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "firehose-export: "+format+"\n", args...)
+	os.Exit(1)
+}
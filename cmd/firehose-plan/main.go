@@ -0,0 +1,334 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Command firehose-plan previews the Firehose API calls the controller would
+// make to reconcile DeliveryStream manifests, without making any of them.
+//
+// The DeliveryStreams of the manifest files named on the command line, or of
+// the standard input if none is, are reconciled the way the controller does:
+// references are resolved, the live delivery stream is read and compared to
+// the manifest, then the calls that create or update it are recorded instead
+// of being made. The plan of each DeliveryStream is printed as a YAML
+// document on the standard output:
+//
+//	firehose-plan --aws-region us-west-2 --cluster-id prod streams.yaml
+//
+// The controller flags, such as --resource-tags, --drift-policy or
+// --propagate-label-keys, are accepted and must match those the controller
+// runs with for the plan to be accurate.
+//
+// References, access key Secrets, namespace labels and the DeliveryStreams
+// stored in the cluster are read from the current kubeconfig context. With
+// --kubernetes=false, no cluster is contacted and manifests with references
+// or an access key can't be planned.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	iamapitypes "github.com/aws-controllers-k8s/iam-controller/apis/v1alpha1"
+	kmsapitypes "github.com/aws-controllers-k8s/kms-controller/apis/v1alpha1"
+	ackcfg "github.com/aws-controllers-k8s/runtime/pkg/config"
+	acktypes "github.com/aws-controllers-k8s/runtime/pkg/types"
+	s3apitypes "github.com/aws-controllers-k8s/s3-controller/apis/v1alpha1"
+	secretsmanagerapitypes "github.com/aws-controllers-k8s/secretsmanager-controller/apis/v1alpha1"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	flag "github.com/spf13/pflag"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlconfig "sigs.k8s.io/controller-runtime/pkg/client/config"
+	"sigs.k8s.io/yaml"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+	svcconfig "github.com/aws-controllers-k8s/firehose-controller/pkg/config"
+	svcresource "github.com/aws-controllers-k8s/firehose-controller/pkg/resource/delivery_stream"
+	"github.com/aws-controllers-k8s/firehose-controller/pkg/version"
+)
+
+const (
+	awsServiceAPIGroup = "firehose.services.k8s.aws"
+	awsServiceAlias    = "firehose"
+)
+
+// manifest is a DeliveryStream read from a manifest file.
+type manifest struct {
+	source string
+	ko     *svcapitypes.DeliveryStream
+}
+
+// planOutput is the YAML document printed for each DeliveryStream.
+type planOutput struct {
+	DeliveryStream     string       `json:"deliveryStream"`
+	DeliveryStreamName string       `json:"deliveryStreamName"`
+	Action             string       `json:"action"`
+	Differences        []string     `json:"differences,omitempty"`
+	Calls              []callOutput `json:"calls,omitempty"`
+}
+
+type callOutput struct {
+	Operation string      `json:"operation"`
+	Input     interface{} `json:"input,omitempty"`
+}
+
+func main() {
+	var (
+		ackCfg            ackcfg.Config
+		svcCfg            svcconfig.Config
+		namespace         string
+		useKubernetes     bool
+		controllerVersion string
+	)
+	ackCfg.BindFlags()
+	svcCfg.BindFlags()
+	flag.StringVar(&namespace, "namespace", "default", "The namespace of the DeliveryStreams of the manifests that don't set one.")
+	flag.BoolVar(&useKubernetes, "kubernetes", true,
+		"Read references, Secrets, namespace labels and the stored DeliveryStreams from the cluster of the current kubeconfig context.")
+	flag.StringVar(&controllerVersion, "controller-version", version.GitVersion,
+		"The version of the controller, recorded in its default resource tags.")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] [manifest file...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := ackCfg.Validate(ctx); err != nil {
+		fatalf("%v", err)
+	}
+	if err := svcCfg.Validate(); err != nil {
+		fatalf("%v", err)
+	}
+
+	clientcfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(ackCfg.Region))
+	if err != nil {
+		fatalf("loading the AWS configuration: %v", err)
+	}
+	if ackCfg.EndpointURL != "" {
+		clientcfg.BaseEndpoint = aws.String(ackCfg.EndpointURL)
+	}
+
+	opts := svcresource.PlanOptions{
+		Metadata: acktypes.ServiceControllerMetadata{
+			VersionInfo: acktypes.VersionInfo{
+				GitCommit:  version.GitCommit,
+				GitVersion: controllerVersion,
+				BuildDate:  version.BuildDate,
+			},
+			ServiceAlias:    awsServiceAlias,
+			ServiceAPIGroup: awsServiceAPIGroup,
+		},
+	}
+	if useKubernetes {
+		kc, err := newKubernetesClient()
+		if err != nil {
+			fatalf("%v", err)
+		}
+		if svcCfg.ClusterID == "" {
+			kubeSystem := &corev1.Namespace{}
+			if err := kc.Get(ctx, types.NamespacedName{Name: metav1.NamespaceSystem}, kubeSystem); err != nil {
+				fatalf("unable to determine the cluster ID, set it with --cluster-id: %v", err)
+			}
+			svcCfg.ClusterID = string(kubeSystem.UID)
+		}
+		opts.APIReader = kc
+		svcresource.SetAPIReader(kc)
+	}
+	svcconfig.Set(svcCfg)
+
+	manifests, err := readManifests(flag.Args(), namespace)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	for i, m := range manifests {
+		p, err := svcresource.Plan(ctx, ackCfg, clientcfg, m.ko, opts)
+		if err != nil {
+			fatalf("planning DeliveryStream %s/%s of %s: %v", m.ko.Namespace, m.ko.Name, m.source, err)
+		}
+		if i > 0 {
+			fmt.Fprintln(os.Stdout, "---")
+		}
+		if err := writePlan(os.Stdout, m.ko, p); err != nil {
+			fatalf("writing the plan of DeliveryStream %s/%s: %v", m.ko.Namespace, m.ko.Name, err)
+		}
+	}
+}
+
+// newKubernetesClient returns a client of the cluster of the current
+// kubeconfig context, which knows DeliveryStreams and the ACK resources they
+// refer to.
+func newKubernetesClient() (ctrlclient.Client, error) {
+	restConfig, err := ctrlconfig.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("loading the kubeconfig: %w", err)
+	}
+	scheme := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{
+		clientgoscheme.AddToScheme,
+		svcapitypes.AddToScheme,
+		iamapitypes.AddToScheme,
+		kmsapitypes.AddToScheme,
+		s3apitypes.AddToScheme,
+		secretsmanagerapitypes.AddToScheme,
+	} {
+		if err := add(scheme); err != nil {
+			return nil, err
+		}
+	}
+	kc, err := ctrlclient.New(restConfig, ctrlclient.Options{Scheme: scheme})
+	if err != nil {
+		return nil, fmt.Errorf("creating the Kubernetes client: %w", err)
+	}
+	return kc, nil
+}
+
+// readManifests returns the DeliveryStreams of the supplied files, or of the
+// standard input if there are none. Other objects are skipped.
+func readManifests(files []string, namespace string) ([]manifest, error) {
+	if len(files) == 0 {
+		return decodeManifests(os.Stdin, "<stdin>", namespace)
+	}
+	var manifests []manifest
+	for _, name := range files {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		m, err := decodeManifests(f, name, namespace)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, m...)
+	}
+	return manifests, nil
+}
+
+func decodeManifests(r io.Reader, source string, namespace string) ([]manifest, error) {
+	var manifests []manifest
+	decoder := utilyaml.NewYAMLOrJSONDecoder(r, 4096)
+	for {
+		obj := &unstructured.Unstructured{}
+		err := decoder.Decode(&obj.Object)
+		if errors.Is(err, io.EOF) {
+			return manifests, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decoding %s: %w", source, err)
+		}
+		gvk := obj.GroupVersionKind()
+		if gvk != svcapitypes.GroupVersion.WithKind("DeliveryStream") {
+			continue
+		}
+		ko := &svcapitypes.DeliveryStream{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, ko); err != nil {
+			return nil, fmt.Errorf("decoding DeliveryStream %s of %s: %w", obj.GetName(), source, err)
+		}
+		if ko.Namespace == "" {
+			ko.Namespace = namespace
+		}
+		manifests = append(manifests, manifest{source: source, ko: ko})
+	}
+}
+
+// writePlan writes the plan of ko as YAML.
+func writePlan(w io.Writer, ko *svcapitypes.DeliveryStream, p *svcresource.DeliveryStreamPlan) error {
+	out := planOutput{
+		DeliveryStream:     ko.Namespace + "/" + ko.Name,
+		DeliveryStreamName: aws.ToString(ko.Spec.DeliveryStreamName),
+		Differences:        p.Differences,
+	}
+	switch {
+	case p.Create:
+		out.Action = "create"
+	case len(p.Calls) > 0:
+		out.Action = "update"
+	default:
+		out.Action = "none"
+	}
+	for _, c := range p.Calls {
+		input, err := pruneInput(c.Input)
+		if err != nil {
+			return err
+		}
+		out.Calls = append(out.Calls, callOutput{Operation: c.Operation, Input: input})
+	}
+	b, err := yaml.Marshal(out)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// pruneInput returns the JSON representation of an API call input without
+// its unset fields.
+func pruneInput(input interface{}) (interface{}, error) {
+	b, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	return prune(v), nil
+}
+
+func prune(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			if e = prune(e); e == nil {
+				delete(v, k)
+			} else {
+				v[k] = e
+			}
+		}
+		if len(v) == 0 {
+			return nil
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			return nil
+		}
+		for i, e := range v {
+			v[i] = prune(e)
+		}
+	case string:
+		if v == "" {
+			return nil
+		}
+	}
+	return v
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "firehose-plan: "+format+"\n", args...)
+	os.Exit(1)
+}
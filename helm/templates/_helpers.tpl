@@ -69,6 +69,21 @@ rules:
   - get
   - list
   - watch
+- apiGroups:
+  - cloudwatchlogs.services.k8s.aws
+  resources:
+  - loggroups
+  verbs:
+  - get
+  - list
+  - watch
+- apiGroups:
+  - cloudwatchlogs.services.k8s.aws
+  resources:
+  - loggroups/status
+  verbs:
+  - get
+  - list
 - apiGroups:
   - firehose.services.k8s.aws
   resources:
@@ -93,6 +108,13 @@ rules:
   - iam.services.k8s.aws
   resources:
   - roles
+  verbs:
+  - get
+  - list
+  - watch
+- apiGroups:
+  - iam.services.k8s.aws
+  resources:
   - roles/status
   verbs:
   - get
@@ -101,14 +123,47 @@ rules:
   - kms.services.k8s.aws
   resources:
   - keys
+  verbs:
+  - get
+  - list
+  - watch
+- apiGroups:
+  - kms.services.k8s.aws
+  resources:
   - keys/status
   verbs:
   - get
   - list
+- apiGroups:
+  - lambda.services.k8s.aws
+  resources:
+  - aliases
+  - functions
+  - versions
+  verbs:
+  - get
+  - list
+  - watch
+- apiGroups:
+  - lambda.services.k8s.aws
+  resources:
+  - aliases/status
+  - functions/status
+  - versions/status
+  verbs:
+  - get
+  - list
 - apiGroups:
   - s3.services.k8s.aws
   resources:
   - buckets
+  verbs:
+  - get
+  - list
+  - watch
+- apiGroups:
+  - s3.services.k8s.aws
+  resources:
   - buckets/status
   verbs:
   - get
@@ -117,6 +172,13 @@ rules:
   - secretsmanager.services.k8s.aws
   resources:
   - secrets
+  verbs:
+  - get
+  - list
+  - watch
+- apiGroups:
+  - secretsmanager.services.k8s.aws
+  resources:
   - secrets/status
   verbs:
   - get
@@ -153,3 +215,22 @@ rules:
 {{- end -}}
 {{ join "," $list }}
 {{- end -}}
+
+{{/* The name of the Service of the admission webhooks */}}
+{{- define "ack-firehose-controller.webhook.service-name" -}}
+{{ .Chart.Name | trimSuffix "-chart" | trunc 44 }}-controller-webhook
+{{- end -}}
+
+{{/* The name of the Secret holding the serving certificate of the admission webhooks */}}
+{{- define "ack-firehose-controller.webhook.secret-name" -}}
+{{- if .Values.webhook.certManager.enabled -}}
+{{ include "ack-firehose-controller.app.fullname" . | trunc 52 }}-webhook-tls
+{{- else -}}
+{{ required "webhook.tls.secretName is required when webhook.certManager.enabled is false" .Values.webhook.tls.secretName }}
+{{- end -}}
+{{- end -}}
+
+{{/* The directory the webhook server of the controller reads its serving certificate from */}}
+{{- define "ack-firehose-controller.webhook.cert-dir" -}}
+{{- "/tmp/k8s-webhook-server/serving-certs" -}}
+{{- end -}}
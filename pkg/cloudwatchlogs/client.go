@@ -0,0 +1,277 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package cloudwatchlogs is a minimal Amazon CloudWatch Logs client, limited
+// to the calls the Firehose controller needs to validate the log groups a
+// delivery stream logs to. It spares the controller a dependency on the full
+// CloudWatch Logs SDK. Like the SDK clients, a Client uses the endpoint of the
+// AWS configuration of the controller when it has one, so that a custom
+// --aws-endpoint-url or CARM endpoint applies to CloudWatch Logs too, and
+// retries the failed requests with the retryer of the configuration.
+package cloudwatchlogs
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	svcsdk "github.com/aws/aws-sdk-go-v2/service/firehose"
+)
+
+const (
+	signingName   = "logs"
+	targetPrefix  = "Logs_20140328."
+	contentType   = "application/x-amz-json-1.1"
+	maxPageLength = 50
+)
+
+// Options contains the settings of a Client.
+type Options struct {
+	// Endpoint is the URL requests are sent to. Defaults to the base endpoint
+	// of the AWS configuration, or the CloudWatch Logs endpoint of its region
+	// if it has none.
+	Endpoint string
+	// HTTPClient sends the requests. Defaults to the HTTP client of the AWS
+	// configuration, or http.DefaultClient if it has none.
+	HTTPClient aws.HTTPClient
+	// Retryer decides whether and when failed requests are retried. Defaults
+	// to the retryer of the AWS configuration, or the standard retryer of the
+	// SDK if it has none.
+	Retryer aws.Retryer
+}
+
+// Client sends signed requests to the CloudWatch Logs JSON API.
+type Client struct {
+	cfg     aws.Config
+	options Options
+	signer  *v4.Signer
+}
+
+// New returns a Client using the region and credentials of cfg.
+func New(cfg aws.Config, optFns ...func(*Options)) *Client {
+	options := Options{
+		Endpoint:   aws.ToString(cfg.BaseEndpoint),
+		HTTPClient: cfg.HTTPClient,
+	}
+	if options.Endpoint == "" {
+		options.Endpoint = endpointForRegion(cfg.Region)
+	}
+	if cfg.Retryer != nil {
+		options.Retryer = cfg.Retryer()
+	}
+	for _, fn := range optFns {
+		fn(&options)
+	}
+	if options.HTTPClient == nil {
+		options.HTTPClient = http.DefaultClient
+	}
+	if options.Retryer == nil {
+		options.Retryer = retry.NewStandard()
+	}
+	return &Client{
+		cfg:     cfg,
+		options: options,
+		signer:  v4.NewSigner(),
+	}
+}
+
+// endpointForRegion returns the CloudWatch Logs endpoint of the region. The
+// Firehose endpoint of the region is resolved with the endpoint rules of the
+// Firehose SDK, which know the DNS suffix of every partition, and its service
+// label replaced, both services following the {service}.{region}.{suffix}
+// naming. It returns an empty string if the region can't be resolved, which
+// fails the requests of the client.
+func endpointForRegion(region string) string {
+	if region == "" {
+		return ""
+	}
+	resolved, err := svcsdk.NewDefaultEndpointResolverV2().ResolveEndpoint(
+		context.Background(), svcsdk.EndpointParameters{Region: aws.String(region)},
+	)
+	if err != nil {
+		return ""
+	}
+	host, found := strings.CutPrefix(resolved.URI.Host, "firehose.")
+	if !found {
+		return ""
+	}
+	resolved.URI.Host = signingName + "." + host
+	return resolved.URI.String()
+}
+
+// APIError is an error returned by the CloudWatch Logs API.
+type APIError struct {
+	// Code is the error code, e.g. AccessDeniedException.
+	Code string
+	// Message is the error message returned with the code.
+	Message string
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("cloudwatchlogs: %s (HTTP %d): %s", e.Code, e.StatusCode, e.Message)
+}
+
+// ErrorCode returns the error code, which the retryers of the SDK use to
+// detect throttling errors.
+func (e *APIError) ErrorCode() string {
+	return e.Code
+}
+
+// HTTPStatusCode returns the HTTP status code, which the retryers of the SDK
+// use to detect the errors of the server.
+func (e *APIError) HTTPStatusCode() int {
+	return e.StatusCode
+}
+
+type describeLogGroupsInput struct {
+	LogGroupNamePrefix string  `json:"logGroupNamePrefix,omitempty"`
+	Limit              int     `json:"limit,omitempty"`
+	NextToken          *string `json:"nextToken,omitempty"`
+}
+
+type describeLogGroupsOutput struct {
+	LogGroups []struct {
+		LogGroupName string `json:"logGroupName"`
+	} `json:"logGroups"`
+	NextToken *string `json:"nextToken"`
+}
+
+// LogGroupExists returns true if a log group with the supplied name exists in
+// the region of the client.
+func (c *Client) LogGroupExists(ctx context.Context, name string) (bool, error) {
+	input := describeLogGroupsInput{
+		LogGroupNamePrefix: name,
+		Limit:              maxPageLength,
+	}
+	for {
+		var output describeLogGroupsOutput
+		if err := c.call(ctx, "DescribeLogGroups", &input, &output); err != nil {
+			return false, err
+		}
+		for _, group := range output.LogGroups {
+			if group.LogGroupName == name {
+				return true, nil
+			}
+		}
+		if output.NextToken == nil || *output.NextToken == "" {
+			return false, nil
+		}
+		input.NextToken = output.NextToken
+	}
+}
+
+// call sends the operation with the JSON encoded input, retrying it as long
+// as the retryer of the client allows, and decodes the response into output.
+func (c *Client) call(ctx context.Context, operation string, input, output any) error {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	if c.options.Endpoint == "" {
+		return fmt.Errorf("cloudwatchlogs: no endpoint for region %q", c.cfg.Region)
+	}
+	retryer := c.options.Retryer
+	for attempt := 1; ; attempt++ {
+		err = c.send(ctx, operation, body, output)
+		if err == nil || attempt >= retryer.MaxAttempts() || !retryer.IsErrorRetryable(err) {
+			return err
+		}
+		delay, delayErr := retryer.RetryDelay(attempt, err)
+		if delayErr != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// send makes a single attempt to send the operation with the JSON encoded
+// body and decodes the response into output.
+func (c *Client) send(ctx context.Context, operation string, body []byte, output any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(c.options.Endpoint, "/")+"/", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Amz-Target", targetPrefix+operation)
+
+	if c.cfg.Credentials == nil {
+		return fmt.Errorf("cloudwatchlogs: no credentials provider configured")
+	}
+	creds, err := c.cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256(body)
+	err = c.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), signingName, c.cfg.Region, time.Now())
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.options.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return newAPIError(resp.StatusCode, respBody)
+	}
+	return json.Unmarshal(respBody, output)
+}
+
+// newAPIError decodes the error returned in the body of a response.
+func newAPIError(statusCode int, body []byte) *APIError {
+	var payload struct {
+		Type         string `json:"__type"`
+		Message      string `json:"message"`
+		MessageUpper string `json:"Message"`
+	}
+	_ = json.Unmarshal(body, &payload)
+	code := payload.Type
+	// The error type may be prefixed with its namespace, e.g.
+	// com.amazonaws.logs#ResourceNotFoundException.
+	if i := strings.LastIndex(code, "#"); i >= 0 {
+		code = code[i+1:]
+	}
+	if code == "" {
+		code = http.StatusText(statusCode)
+	}
+	message := payload.Message
+	if message == "" {
+		message = payload.MessageUpper
+	}
+	return &APIError{
+		Code:       code,
+		Message:    message,
+		StatusCode: statusCode,
+	}
+}
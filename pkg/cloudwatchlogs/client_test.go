@@ -0,0 +1,254 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cloudwatchlogs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+)
+
+// newTestClient returns a Client sending its requests to a server that
+// serves the supplied pages of log group names, in order.
+func newTestClient(t *testing.T, pages ...[]string) *Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Amz-Target"); got != "Logs_20140328.DescribeLogGroups" {
+			t.Errorf("unexpected X-Amz-Target %q", got)
+		}
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+			t.Errorf("expected a signed request")
+		}
+		var input describeLogGroupsInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			t.Fatalf("decoding request: %v", err)
+		}
+		if input.LogGroupNamePrefix == "forbidden" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"com.amazonaws.logs#AccessDeniedException","message":"denied"}`))
+			return
+		}
+		page := 0
+		if input.NextToken != nil {
+			page = len(*input.NextToken)
+		}
+		output := map[string]any{}
+		groups := []map[string]string{}
+		if page < len(pages) {
+			for _, name := range pages[page] {
+				groups = append(groups, map[string]string{"logGroupName": name})
+			}
+		}
+		output["logGroups"] = groups
+		if page+1 < len(pages) {
+			output["nextToken"] = strings.Repeat("x", page+1)
+		}
+		_ = json.NewEncoder(w).Encode(output)
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := aws.Config{
+		Region: "us-west-2",
+		Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET"}, nil
+		}),
+	}
+	return New(cfg, func(o *Options) {
+		o.Endpoint = server.URL
+	})
+}
+
+func TestLogGroupExists(t *testing.T) {
+	tests := []struct {
+		name      string
+		pages     [][]string
+		logGroup  string
+		expect    bool
+		expectErr string
+	}{
+		{
+			name:     "exact match",
+			pages:    [][]string{{"/aws/firehose/stream"}},
+			logGroup: "/aws/firehose/stream",
+			expect:   true,
+		},
+		{
+			name:     "prefix match only",
+			pages:    [][]string{{"/aws/firehose/stream-2"}},
+			logGroup: "/aws/firehose/stream",
+		},
+		{
+			name:     "match on a later page",
+			pages:    [][]string{{"/aws/firehose/stream-1"}, {"/aws/firehose/stream-2", "/aws/firehose/stream"}},
+			logGroup: "/aws/firehose/stream",
+			expect:   true,
+		},
+		{
+			name:     "no log groups",
+			logGroup: "/aws/firehose/stream",
+		},
+		{
+			name:      "API error",
+			logGroup:  "forbidden",
+			expectErr: "AccessDeniedException",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, tt.pages...)
+			exists, err := c.LogGroupExists(context.TODO(), tt.logGroup)
+			if tt.expectErr != "" {
+				var apiErr *APIError
+				if !errors.As(err, &apiErr) || apiErr.Code != tt.expectErr {
+					t.Fatalf("expected %s error, got %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if exists != tt.expect {
+				t.Errorf("expected %v, got %v", tt.expect, exists)
+			}
+		})
+	}
+}
+
+func TestLogGroupExistsRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int
+		status       int
+		code         string
+		expect       bool
+		expectErr    string
+		expectCalled int
+	}{
+		{
+			name:         "throttled then found",
+			failures:     2,
+			status:       http.StatusBadRequest,
+			code:         "ThrottlingException",
+			expect:       true,
+			expectCalled: 3,
+		},
+		{
+			name:         "server errors until the attempts run out",
+			failures:     3,
+			status:       http.StatusServiceUnavailable,
+			code:         "ServiceUnavailableException",
+			expectErr:    "ServiceUnavailableException",
+			expectCalled: 3,
+		},
+		{
+			name:         "client errors are not retried",
+			failures:     1,
+			status:       http.StatusBadRequest,
+			code:         "AccessDeniedException",
+			expectErr:    "AccessDeniedException",
+			expectCalled: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called++
+				if called <= tt.failures {
+					w.WriteHeader(tt.status)
+					_, _ = w.Write([]byte(`{"__type":"` + tt.code + `","message":"failed"}`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"logGroups":[{"logGroupName":"/aws/firehose/stream"}]}`))
+			}))
+			t.Cleanup(server.Close)
+
+			cfg := aws.Config{
+				Region: "us-west-2",
+				Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+					return aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET"}, nil
+				}),
+				Retryer: func() aws.Retryer {
+					return retry.NewStandard(func(o *retry.StandardOptions) {
+						o.Backoff = retry.BackoffDelayerFunc(func(int, error) (time.Duration, error) {
+							return 0, nil
+						})
+					})
+				},
+			}
+			c := New(cfg, func(o *Options) {
+				o.Endpoint = server.URL
+			})
+			exists, err := c.LogGroupExists(context.TODO(), "/aws/firehose/stream")
+			if called != tt.expectCalled {
+				t.Errorf("expected %d calls, got %d", tt.expectCalled, called)
+			}
+			if tt.expectErr != "" {
+				var apiErr *APIError
+				if !errors.As(err, &apiErr) || apiErr.Code != tt.expectErr {
+					t.Fatalf("expected %s error, got %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if exists != tt.expect {
+				t.Errorf("expected %v, got %v", tt.expect, exists)
+			}
+		})
+	}
+}
+
+func TestNewEndpoint(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      aws.Config
+		expected string
+	}{
+		{
+			name:     "regional endpoint",
+			cfg:      aws.Config{Region: "us-west-2"},
+			expected: "https://logs.us-west-2.amazonaws.com",
+		},
+		{
+			name:     "regional endpoint of another partition",
+			cfg:      aws.Config{Region: "cn-north-1"},
+			expected: "https://logs.cn-north-1.amazonaws.com.cn",
+		},
+		{
+			name:     "base endpoint of the configuration",
+			cfg:      aws.Config{Region: "us-west-2", BaseEndpoint: aws.String("http://localhost:4566")},
+			expected: "http://localhost:4566",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := New(tt.cfg).options.Endpoint; got != tt.expected {
+				t.Errorf("expected endpoint %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package config contains the configuration options that are specific to the
+// Firehose service controller, as opposed to the options shared by every ACK
+// controller in the runtime's config package.
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	flag "github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+)
+
+const (
+	flagDriftPolicy       = "drift-policy"
+	flagValidateLogGroups = "validate-log-groups"
+	flagClusterID         = "cluster-id"
+	flagPropagateLabels   = "propagate-label-keys"
+	flagRestrictAccounts  = "restrict-reference-accounts"
+)
+
+// Config contains configuration options for the Firehose service controller.
+type Config struct {
+	// DriftPolicy is the default drift policy for DeliveryStream resources
+	// that do not carry the drift-policy annotation.
+	DriftPolicy string
+	// ValidateLogGroups makes the controller check, before creating or
+	// updating a delivery stream, that the CloudWatch Logs log groups it
+	// logs delivery errors to exist.
+	ValidateLogGroups bool
+	// ClusterID identifies the Kubernetes cluster in the ownership tag the
+	// controller stamps on the delivery streams it creates. When empty, the
+	// UID of the kube-system namespace is used.
+	ClusterID string
+	// PropagateLabelKeys lists the label keys that are copied from a
+	// DeliveryStream and from its namespace to the tags of the delivery
+	// stream. A label set on the DeliveryStream takes precedence over the
+	// same label set on its namespace, and both take precedence over the
+	// tags set with --resource-tags. Tags set in Spec.Tags under one of
+	// these keys are ignored.
+	PropagateLabelKeys []string
+	// RestrictReferenceAccounts makes the controller reject the references
+	// of every DeliveryStream that resolve to a resource of another AWS
+	// account not listed in its reference-owner-accounts annotation. When
+	// false, only the DeliveryStreams carrying the annotation are checked.
+	RestrictReferenceAccounts bool
+}
+
+// BindFlags defines CLI/runtime configuration options
+func (cfg *Config) BindFlags() {
+	flag.StringVar(
+		&cfg.DriftPolicy, flagDriftPolicy,
+		string(svcapitypes.DriftPolicyEnforce),
+		"The default policy applied when a delivery stream drifts from its last applied spec. "+
+			"Valid values are 'Enforce', 'ReportOnly' and 'AdoptLive'.",
+	)
+	flag.BoolVar(
+		&cfg.ValidateLogGroups, flagValidateLogGroups,
+		false,
+		"Check that the CloudWatch Logs log groups of a delivery stream exist before creating or updating it.",
+	)
+	flag.StringVar(
+		&cfg.ClusterID, flagClusterID,
+		"",
+		"The ID of the cluster recorded in the ownership tag of the delivery streams the controller creates. "+
+			"Defaults to the UID of the kube-system namespace.",
+	)
+	flag.StringSliceVar(
+		&cfg.PropagateLabelKeys, flagPropagateLabels,
+		nil,
+		"The label keys copied from a delivery stream custom resource and from its namespace to the tags of the delivery stream. "+
+			"Labels of the custom resource take precedence over those of its namespace.",
+	)
+	flag.BoolVar(
+		&cfg.RestrictReferenceAccounts, flagRestrictAccounts,
+		false,
+		"Reject the references of a delivery stream that resolve to a resource of another AWS account, "+
+			"unless the account is listed in its reference-owner-accounts annotation. "+
+			"Delivery streams carrying the annotation are checked regardless.",
+	)
+}
+
+// Validate ensures the options are valid
+func (cfg *Config) Validate() error {
+	if !slices.Contains(svcapitypes.DriftPolicies, svcapitypes.DriftPolicy(cfg.DriftPolicy)) {
+		return fmt.Errorf("invalid value for flag '%s': %q, must be one of %v",
+			flagDriftPolicy, cfg.DriftPolicy, svcapitypes.DriftPolicies)
+	}
+	for _, key := range cfg.PropagateLabelKeys {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("invalid value for flag '%s': %q is not a valid label key: %s",
+				flagPropagateLabels, key, strings.Join(errs, "; "))
+		}
+	}
+	return nil
+}
+
+var (
+	mu      sync.RWMutex
+	current = Config{
+		DriftPolicy: string(svcapitypes.DriftPolicyEnforce),
+	}
+)
+
+// Set replaces the controller configuration returned by Get. It is called
+// once by the controller's main function after flags have been parsed.
+func Set(cfg Config) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = cfg
+}
+
+// Get returns the controller configuration.
+func Get() Config {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
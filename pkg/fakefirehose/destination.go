@@ -0,0 +1,191 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fakefirehose
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	svcsdktypes "github.com/aws/aws-sdk-go-v2/service/firehose/types"
+)
+
+// destinationID is the ID of the only destination of a delivery stream, as
+// the service assigns it.
+const destinationID = "destinationId-000000000001"
+
+// validateHTTPEndpointDestination checks the fields the service requires
+// in an HTTP endpoint destination.
+func validateHTTPEndpointDestination(in *svcsdktypes.HttpEndpointDestinationConfiguration) error {
+	if in.EndpointConfiguration == nil || in.EndpointConfiguration.Url == nil {
+		return invalidArgument("HttpEndpointDestinationConfiguration.EndpointConfiguration.Url is required")
+	}
+	if u, err := url.Parse(*in.EndpointConfiguration.Url); err != nil || u.Scheme != "https" {
+		return invalidArgument(fmt.Sprintf("endpoint URL %s must use https", *in.EndpointConfiguration.Url))
+	}
+	if in.S3Configuration == nil || in.S3Configuration.BucketARN == nil || in.S3Configuration.RoleARN == nil {
+		return invalidArgument("HttpEndpointDestinationConfiguration.S3Configuration requires BucketARN and RoleARN")
+	}
+	return nil
+}
+
+// describeHTTPEndpointDestination returns the description of a new HTTP
+// endpoint destination, with the defaults the service fills in. The access
+// key of the endpoint is never described.
+func describeHTTPEndpointDestination(in *svcsdktypes.HttpEndpointDestinationConfiguration) svcsdktypes.HttpEndpointDestinationDescription {
+	in = clone(in)
+	out := svcsdktypes.HttpEndpointDestinationDescription{
+		BufferingHints:           in.BufferingHints,
+		CloudWatchLoggingOptions: in.CloudWatchLoggingOptions,
+		EndpointConfiguration: &svcsdktypes.HttpEndpointDescription{
+			Name: in.EndpointConfiguration.Name,
+			Url:  in.EndpointConfiguration.Url,
+		},
+		ProcessingConfiguration:     in.ProcessingConfiguration,
+		RequestConfiguration:        in.RequestConfiguration,
+		RetryOptions:                in.RetryOptions,
+		RoleARN:                     in.RoleARN,
+		S3BackupMode:                in.S3BackupMode,
+		SecretsManagerConfiguration: in.SecretsManagerConfiguration,
+		S3DestinationDescription: &svcsdktypes.S3DestinationDescription{
+			BucketARN:                in.S3Configuration.BucketARN,
+			BufferingHints:           in.S3Configuration.BufferingHints,
+			CloudWatchLoggingOptions: in.S3Configuration.CloudWatchLoggingOptions,
+			CompressionFormat:        in.S3Configuration.CompressionFormat,
+			EncryptionConfiguration:  in.S3Configuration.EncryptionConfiguration,
+			ErrorOutputPrefix:        in.S3Configuration.ErrorOutputPrefix,
+			Prefix:                   in.S3Configuration.Prefix,
+			RoleARN:                  in.S3Configuration.RoleARN,
+		},
+	}
+	setHTTPEndpointDestinationDefaults(&out)
+	return out
+}
+
+// setHTTPEndpointDestinationDefaults fills the fields of a destination
+// description that the service defaults.
+func setHTTPEndpointDestinationDefaults(d *svcsdktypes.HttpEndpointDestinationDescription) {
+	if d.BufferingHints == nil {
+		d.BufferingHints = &svcsdktypes.HttpEndpointBufferingHints{}
+	}
+	if d.BufferingHints.IntervalInSeconds == nil {
+		d.BufferingHints.IntervalInSeconds = aws.Int32(300)
+	}
+	if d.BufferingHints.SizeInMBs == nil {
+		d.BufferingHints.SizeInMBs = aws.Int32(5)
+	}
+	if d.CloudWatchLoggingOptions == nil {
+		d.CloudWatchLoggingOptions = &svcsdktypes.CloudWatchLoggingOptions{Enabled: aws.Bool(false)}
+	}
+	if d.ProcessingConfiguration == nil {
+		d.ProcessingConfiguration = &svcsdktypes.ProcessingConfiguration{Enabled: aws.Bool(false)}
+	}
+	if d.RequestConfiguration == nil {
+		d.RequestConfiguration = &svcsdktypes.HttpEndpointRequestConfiguration{}
+	}
+	if d.RequestConfiguration.ContentEncoding == "" {
+		d.RequestConfiguration.ContentEncoding = svcsdktypes.ContentEncodingNone
+	}
+	if d.RetryOptions == nil {
+		d.RetryOptions = &svcsdktypes.HttpEndpointRetryOptions{DurationInSeconds: aws.Int32(300)}
+	}
+	if d.S3BackupMode == "" {
+		d.S3BackupMode = svcsdktypes.HttpEndpointS3BackupModeFailedDataOnly
+	}
+
+	s3 := d.S3DestinationDescription
+	if s3.BufferingHints == nil {
+		s3.BufferingHints = &svcsdktypes.BufferingHints{}
+	}
+	if s3.BufferingHints.IntervalInSeconds == nil {
+		s3.BufferingHints.IntervalInSeconds = aws.Int32(300)
+	}
+	if s3.BufferingHints.SizeInMBs == nil {
+		s3.BufferingHints.SizeInMBs = aws.Int32(5)
+	}
+	if s3.CloudWatchLoggingOptions == nil {
+		s3.CloudWatchLoggingOptions = &svcsdktypes.CloudWatchLoggingOptions{Enabled: aws.Bool(false)}
+	}
+	if s3.CompressionFormat == "" {
+		s3.CompressionFormat = svcsdktypes.CompressionFormatUncompressed
+	}
+	if s3.EncryptionConfiguration == nil {
+		s3.EncryptionConfiguration = &svcsdktypes.EncryptionConfiguration{
+			NoEncryptionConfig: svcsdktypes.NoEncryptionConfigNoEncryption,
+		}
+	}
+}
+
+// updateHTTPEndpointDestination applies the fields set in an update to a
+// destination description.
+func updateHTTPEndpointDestination(d *svcsdktypes.HttpEndpointDestinationDescription, u *svcsdktypes.HttpEndpointDestinationUpdate) {
+	if u.BufferingHints != nil {
+		d.BufferingHints = u.BufferingHints
+	}
+	if u.CloudWatchLoggingOptions != nil {
+		d.CloudWatchLoggingOptions = u.CloudWatchLoggingOptions
+	}
+	if u.EndpointConfiguration != nil {
+		d.EndpointConfiguration = &svcsdktypes.HttpEndpointDescription{
+			Name: u.EndpointConfiguration.Name,
+			Url:  u.EndpointConfiguration.Url,
+		}
+	}
+	if u.ProcessingConfiguration != nil {
+		d.ProcessingConfiguration = u.ProcessingConfiguration
+	}
+	if u.RequestConfiguration != nil {
+		d.RequestConfiguration = u.RequestConfiguration
+	}
+	if u.RetryOptions != nil {
+		d.RetryOptions = u.RetryOptions
+	}
+	if u.RoleARN != nil {
+		d.RoleARN = u.RoleARN
+	}
+	if u.S3BackupMode != "" {
+		d.S3BackupMode = u.S3BackupMode
+	}
+	if u.SecretsManagerConfiguration != nil {
+		d.SecretsManagerConfiguration = u.SecretsManagerConfiguration
+	}
+	if s3 := u.S3Update; s3 != nil {
+		desc := d.S3DestinationDescription
+		if s3.BucketARN != nil {
+			desc.BucketARN = s3.BucketARN
+		}
+		if s3.BufferingHints != nil {
+			desc.BufferingHints = s3.BufferingHints
+		}
+		if s3.CloudWatchLoggingOptions != nil {
+			desc.CloudWatchLoggingOptions = s3.CloudWatchLoggingOptions
+		}
+		if s3.CompressionFormat != "" {
+			desc.CompressionFormat = s3.CompressionFormat
+		}
+		if s3.EncryptionConfiguration != nil {
+			desc.EncryptionConfiguration = s3.EncryptionConfiguration
+		}
+		if s3.ErrorOutputPrefix != nil {
+			desc.ErrorOutputPrefix = s3.ErrorOutputPrefix
+		}
+		if s3.Prefix != nil {
+			desc.Prefix = s3.Prefix
+		}
+		if s3.RoleARN != nil {
+			desc.RoleARN = s3.RoleARN
+		}
+	}
+	setHTTPEndpointDestinationDefaults(d)
+}
@@ -0,0 +1,574 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package fakefirehose implements an in-memory stand-in for the part of the
+// Firehose API the controller uses. It keeps delivery streams, their
+// destinations, encryption settings and tags in memory and simulates the
+// asynchronous state transitions of the service: a delivery stream is
+// CREATING, then ACTIVE, its encryption ENABLING or DISABLING, then ENABLED
+// or DISABLED, and it is DELETING before it is gone. Errors are reported with
+// the error types of the Firehose SDK, so that callers see the same error
+// codes as with the service. Handler serves a Firehose over the Firehose
+// JSON protocol, for clients that can only be pointed at an endpoint URL.
+package fakefirehose
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	svcsdk "github.com/aws/aws-sdk-go-v2/service/firehose"
+	svcsdktypes "github.com/aws/aws-sdk-go-v2/service/firehose/types"
+)
+
+const (
+	// DefaultTransitionDelay is how long a pending state lasts when Options
+	// doesn't set a delay.
+	DefaultTransitionDelay = 5 * time.Second
+	// maxTags is the number of tags a delivery stream can have.
+	maxTags = 50
+)
+
+// Options configures a Firehose.
+type Options struct {
+	// AccountID and Region are used to build the ARNs of the delivery
+	// streams. They default to 000000000000 and us-west-2.
+	AccountID string
+	Region    string
+	// TransitionDelay is how long a delivery stream stays CREATING or
+	// DELETING, and its encryption ENABLING or DISABLING. Defaults to
+	// DefaultTransitionDelay.
+	TransitionDelay time.Duration
+	// Now returns the current time. Defaults to time.Now. Tests replace it
+	// to control when state transitions happen.
+	Now func() time.Time
+}
+
+// Firehose is an in-memory Firehose API. Its methods have the signatures of
+// the methods of the SDK client, and it is safe for concurrent use.
+type Firehose struct {
+	opts Options
+
+	mu      sync.Mutex
+	streams map[string]*deliveryStream
+	// failures holds the errors to return from the next calls of an
+	// operation, keyed by operation name.
+	failures map[string][]error
+}
+
+// deliveryStream is the state of a delivery stream.
+type deliveryStream struct {
+	description svcsdktypes.DeliveryStreamDescription
+	destination svcsdktypes.HttpEndpointDestinationDescription
+	tags        map[string]string
+	version     int
+	// transitions are applied, in order, once their time has come.
+	transitions []transition
+	deleted     bool
+}
+
+type transition struct {
+	at    time.Time
+	apply func(*deliveryStream)
+}
+
+// New returns an empty Firehose.
+func New(opts Options) *Firehose {
+	if opts.AccountID == "" {
+		opts.AccountID = "000000000000"
+	}
+	if opts.Region == "" {
+		opts.Region = "us-west-2"
+	}
+	if opts.TransitionDelay == 0 {
+		opts.TransitionDelay = DefaultTransitionDelay
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Firehose{
+		opts:     opts,
+		streams:  map[string]*deliveryStream{},
+		failures: map[string][]error{},
+	}
+}
+
+// FailNext makes the next call of the supplied operation, for instance
+// "UpdateDestination", return err without any other effect. Successive calls
+// queue errors for successive calls of the operation.
+func (f *Firehose) FailNext(operation string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures[operation] = append(f.failures[operation], err)
+}
+
+// injectedFailure returns the next error queued for operation, if any.
+func (f *Firehose) injectedFailure(operation string) error {
+	errs := f.failures[operation]
+	if len(errs) == 0 {
+		return nil
+	}
+	f.failures[operation] = errs[1:]
+	return errs[0]
+}
+
+// stream returns the delivery stream with the supplied name, after applying
+// the transitions that are due, or a ResourceNotFoundException.
+func (f *Firehose) stream(name *string) (*deliveryStream, error) {
+	if name == nil {
+		return nil, invalidArgument("DeliveryStreamName is required")
+	}
+	s, ok := f.streams[*name]
+	if ok {
+		now := f.opts.Now()
+		for len(s.transitions) > 0 && !s.transitions[0].at.After(now) {
+			s.transitions[0].apply(s)
+			s.transitions = s.transitions[1:]
+		}
+		if s.deleted {
+			delete(f.streams, *name)
+			ok = false
+		}
+	}
+	if !ok {
+		return nil, &svcsdktypes.ResourceNotFoundException{
+			Message: aws.String(fmt.Sprintf("Firehose %s under account %s not found.", *name, f.opts.AccountID)),
+		}
+	}
+	return s, nil
+}
+
+// activeStream returns the delivery stream with the supplied name, or a
+// ResourceInUseException if it is not ACTIVE.
+func (f *Firehose) activeStream(name *string) (*deliveryStream, error) {
+	s, err := f.stream(name)
+	if err != nil {
+		return nil, err
+	}
+	if status := s.description.DeliveryStreamStatus; status != svcsdktypes.DeliveryStreamStatusActive {
+		return nil, &svcsdktypes.ResourceInUseException{
+			Message: aws.String(fmt.Sprintf("Firehose %s is not in the ACTIVE state, it is %s.", *name, status)),
+		}
+	}
+	return s, nil
+}
+
+// after schedules apply to happen once the transition delay has elapsed.
+func (f *Firehose) after(s *deliveryStream, apply func(*deliveryStream)) {
+	s.transitions = append(s.transitions, transition{
+		at:    f.opts.Now().Add(f.opts.TransitionDelay),
+		apply: apply,
+	})
+}
+
+// CreateDeliveryStream creates a CREATING delivery stream, which becomes
+// ACTIVE after the transition delay. Only HTTP endpoint destinations are
+// supported.
+func (f *Firehose) CreateDeliveryStream(
+	_ context.Context,
+	input *svcsdk.CreateDeliveryStreamInput,
+	_ ...func(*svcsdk.Options),
+) (*svcsdk.CreateDeliveryStreamOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injectedFailure("CreateDeliveryStream"); err != nil {
+		return nil, err
+	}
+	if input.DeliveryStreamName == nil || *input.DeliveryStreamName == "" {
+		return nil, invalidArgument("DeliveryStreamName is required")
+	}
+	name := *input.DeliveryStreamName
+	if _, err := f.stream(input.DeliveryStreamName); err == nil {
+		return nil, &svcsdktypes.ResourceInUseException{
+			Message: aws.String(fmt.Sprintf("Firehose %s under accountId %s already exists", name, f.opts.AccountID)),
+		}
+	}
+	if input.HttpEndpointDestinationConfiguration == nil {
+		return nil, invalidArgument("exactly one destination configuration must be specified, only HttpEndpointDestinationConfiguration is supported")
+	}
+	if err := validateHTTPEndpointDestination(input.HttpEndpointDestinationConfiguration); err != nil {
+		return nil, err
+	}
+	if len(input.Tags) > maxTags {
+		return nil, &svcsdktypes.LimitExceededException{
+			Message: aws.String(fmt.Sprintf("a delivery stream can have at most %d tags", maxTags)),
+		}
+	}
+	streamType := input.DeliveryStreamType
+	if streamType == "" {
+		streamType = svcsdktypes.DeliveryStreamTypeDirectPut
+	}
+	if streamType != svcsdktypes.DeliveryStreamTypeDirectPut {
+		return nil, invalidArgument(fmt.Sprintf("delivery stream type %s is not supported", streamType))
+	}
+
+	now := f.opts.Now()
+	s := &deliveryStream{
+		description: svcsdktypes.DeliveryStreamDescription{
+			DeliveryStreamARN: aws.String(fmt.Sprintf(
+				"arn:aws:firehose:%s:%s:deliverystream/%s", f.opts.Region, f.opts.AccountID, name,
+			)),
+			DeliveryStreamName:   aws.String(name),
+			DeliveryStreamStatus: svcsdktypes.DeliveryStreamStatusCreating,
+			DeliveryStreamType:   streamType,
+			CreateTimestamp:      aws.Time(now),
+			DeliveryStreamEncryptionConfiguration: &svcsdktypes.DeliveryStreamEncryptionConfiguration{
+				Status: svcsdktypes.DeliveryStreamEncryptionStatusDisabled,
+			},
+			HasMoreDestinations: aws.Bool(false),
+		},
+		destination: describeHTTPEndpointDestination(input.HttpEndpointDestinationConfiguration),
+		tags:        map[string]string{},
+		version:     1,
+	}
+	for _, tag := range input.Tags {
+		s.tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	if enc := input.DeliveryStreamEncryptionConfigurationInput; enc != nil {
+		s.description.DeliveryStreamEncryptionConfiguration = &svcsdktypes.DeliveryStreamEncryptionConfiguration{
+			KeyARN:  enc.KeyARN,
+			KeyType: enc.KeyType,
+			Status:  svcsdktypes.DeliveryStreamEncryptionStatusEnabling,
+		}
+	}
+	f.after(s, func(s *deliveryStream) {
+		s.description.DeliveryStreamStatus = svcsdktypes.DeliveryStreamStatusActive
+		if enc := s.description.DeliveryStreamEncryptionConfiguration; enc.Status == svcsdktypes.DeliveryStreamEncryptionStatusEnabling {
+			enc.Status = svcsdktypes.DeliveryStreamEncryptionStatusEnabled
+		}
+	})
+	f.streams[name] = s
+	return &svcsdk.CreateDeliveryStreamOutput{DeliveryStreamARN: s.description.DeliveryStreamARN}, nil
+}
+
+// DescribeDeliveryStream returns the description of a delivery stream.
+func (f *Firehose) DescribeDeliveryStream(
+	_ context.Context,
+	input *svcsdk.DescribeDeliveryStreamInput,
+	_ ...func(*svcsdk.Options),
+) (*svcsdk.DescribeDeliveryStreamOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injectedFailure("DescribeDeliveryStream"); err != nil {
+		return nil, err
+	}
+	s, err := f.stream(input.DeliveryStreamName)
+	if err != nil {
+		return nil, err
+	}
+	description := s.description
+	description.VersionId = aws.String(strconv.Itoa(s.version))
+	description.Destinations = []svcsdktypes.DestinationDescription{{
+		DestinationId:                      aws.String(destinationID),
+		HttpEndpointDestinationDescription: &s.destination,
+	}}
+	return &svcsdk.DescribeDeliveryStreamOutput{
+		DeliveryStreamDescription: clone(&description),
+	}, nil
+}
+
+// ListDeliveryStreams lists the names of the delivery streams in name order,
+// starting after ExclusiveStartDeliveryStreamName and returning at most
+// Limit names.
+func (f *Firehose) ListDeliveryStreams(
+	_ context.Context,
+	input *svcsdk.ListDeliveryStreamsInput,
+	_ ...func(*svcsdk.Options),
+) (*svcsdk.ListDeliveryStreamsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injectedFailure("ListDeliveryStreams"); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(f.streams))
+	for name := range f.streams {
+		if input.ExclusiveStartDeliveryStreamName != nil && name <= *input.ExclusiveStartDeliveryStreamName {
+			continue
+		}
+		s, err := f.stream(aws.String(name))
+		if err != nil {
+			continue
+		}
+		if input.DeliveryStreamType != "" && s.description.DeliveryStreamType != input.DeliveryStreamType {
+			continue
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	hasMore := false
+	if input.Limit != nil && int(*input.Limit) < len(names) {
+		names, hasMore = names[:*input.Limit], true
+	}
+	return &svcsdk.ListDeliveryStreamsOutput{DeliveryStreamNames: names, HasMoreDeliveryStreams: aws.Bool(hasMore)}, nil
+}
+
+// UpdateDestination updates the HTTP endpoint destination of an ACTIVE
+// delivery stream and increments its version ID. Only the fields set in
+// the update are changed.
+func (f *Firehose) UpdateDestination(
+	_ context.Context,
+	input *svcsdk.UpdateDestinationInput,
+	_ ...func(*svcsdk.Options),
+) (*svcsdk.UpdateDestinationOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injectedFailure("UpdateDestination"); err != nil {
+		return nil, err
+	}
+	s, err := f.activeStream(input.DeliveryStreamName)
+	if err != nil {
+		return nil, err
+	}
+	if aws.ToString(input.DestinationId) != destinationID {
+		return nil, invalidArgument(fmt.Sprintf("destination %s not found", aws.ToString(input.DestinationId)))
+	}
+	if current := strconv.Itoa(s.version); aws.ToString(input.CurrentDeliveryStreamVersionId) != current {
+		return nil, &svcsdktypes.ConcurrentModificationException{
+			Message: aws.String(fmt.Sprintf(
+				"CurrentDeliveryStreamVersionId %s does not match the current version %s",
+				aws.ToString(input.CurrentDeliveryStreamVersionId), current,
+			)),
+		}
+	}
+	if input.HttpEndpointDestinationUpdate == nil {
+		return nil, invalidArgument("only HttpEndpointDestinationUpdate is supported")
+	}
+	updateHTTPEndpointDestination(&s.destination, clone(input.HttpEndpointDestinationUpdate))
+	s.version++
+	s.description.LastUpdateTimestamp = aws.Time(f.opts.Now())
+	return &svcsdk.UpdateDestinationOutput{}, nil
+}
+
+// DeleteDeliveryStream marks a delivery stream DELETING. It is removed after
+// the transition delay.
+func (f *Firehose) DeleteDeliveryStream(
+	_ context.Context,
+	input *svcsdk.DeleteDeliveryStreamInput,
+	_ ...func(*svcsdk.Options),
+) (*svcsdk.DeleteDeliveryStreamOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injectedFailure("DeleteDeliveryStream"); err != nil {
+		return nil, err
+	}
+	s, err := f.stream(input.DeliveryStreamName)
+	if err != nil {
+		return nil, err
+	}
+	if s.description.DeliveryStreamStatus != svcsdktypes.DeliveryStreamStatusDeleting {
+		s.description.DeliveryStreamStatus = svcsdktypes.DeliveryStreamStatusDeleting
+		f.after(s, func(s *deliveryStream) { s.deleted = true })
+	}
+	return &svcsdk.DeleteDeliveryStreamOutput{}, nil
+}
+
+// StartDeliveryStreamEncryption enables server-side encryption on an ACTIVE
+// delivery stream. Its encryption is ENABLING, then ENABLED after the
+// transition delay.
+func (f *Firehose) StartDeliveryStreamEncryption(
+	_ context.Context,
+	input *svcsdk.StartDeliveryStreamEncryptionInput,
+	_ ...func(*svcsdk.Options),
+) (*svcsdk.StartDeliveryStreamEncryptionOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injectedFailure("StartDeliveryStreamEncryption"); err != nil {
+		return nil, err
+	}
+	s, err := f.activeStream(input.DeliveryStreamName)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkEncryptionSettled(s); err != nil {
+		return nil, err
+	}
+	in := input.DeliveryStreamEncryptionConfigurationInput
+	if in == nil || in.KeyType == "" {
+		return nil, invalidArgument("DeliveryStreamEncryptionConfigurationInput.KeyType is required")
+	}
+	if in.KeyType == svcsdktypes.KeyTypeCustomerManagedCmk && in.KeyARN == nil {
+		return nil, invalidArgument("KeyARN is required for CUSTOMER_MANAGED_CMK keys")
+	}
+	s.description.DeliveryStreamEncryptionConfiguration = &svcsdktypes.DeliveryStreamEncryptionConfiguration{
+		KeyARN:  in.KeyARN,
+		KeyType: in.KeyType,
+		Status:  svcsdktypes.DeliveryStreamEncryptionStatusEnabling,
+	}
+	f.after(s, func(s *deliveryStream) {
+		s.description.DeliveryStreamEncryptionConfiguration.Status = svcsdktypes.DeliveryStreamEncryptionStatusEnabled
+	})
+	return &svcsdk.StartDeliveryStreamEncryptionOutput{}, nil
+}
+
+// StopDeliveryStreamEncryption disables server-side encryption on an ACTIVE
+// delivery stream. Its encryption is DISABLING, then DISABLED after the
+// transition delay.
+func (f *Firehose) StopDeliveryStreamEncryption(
+	_ context.Context,
+	input *svcsdk.StopDeliveryStreamEncryptionInput,
+	_ ...func(*svcsdk.Options),
+) (*svcsdk.StopDeliveryStreamEncryptionOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injectedFailure("StopDeliveryStreamEncryption"); err != nil {
+		return nil, err
+	}
+	s, err := f.activeStream(input.DeliveryStreamName)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkEncryptionSettled(s); err != nil {
+		return nil, err
+	}
+	enc := s.description.DeliveryStreamEncryptionConfiguration
+	if enc.Status == svcsdktypes.DeliveryStreamEncryptionStatusDisabled {
+		return &svcsdk.StopDeliveryStreamEncryptionOutput{}, nil
+	}
+	enc.Status = svcsdktypes.DeliveryStreamEncryptionStatusDisabling
+	f.after(s, func(s *deliveryStream) {
+		s.description.DeliveryStreamEncryptionConfiguration = &svcsdktypes.DeliveryStreamEncryptionConfiguration{
+			Status: svcsdktypes.DeliveryStreamEncryptionStatusDisabled,
+		}
+	})
+	return &svcsdk.StopDeliveryStreamEncryptionOutput{}, nil
+}
+
+// checkEncryptionSettled returns a ResourceInUseException if the encryption
+// of the delivery stream is being enabled or disabled.
+func checkEncryptionSettled(s *deliveryStream) error {
+	switch status := s.description.DeliveryStreamEncryptionConfiguration.Status; status {
+	case svcsdktypes.DeliveryStreamEncryptionStatusEnabling, svcsdktypes.DeliveryStreamEncryptionStatusDisabling:
+		return &svcsdktypes.ResourceInUseException{
+			Message: aws.String(fmt.Sprintf("the encryption of Firehose %s is %s", aws.ToString(s.description.DeliveryStreamName), status)),
+		}
+	}
+	return nil
+}
+
+// TagDeliveryStream adds or updates tags of a delivery stream.
+func (f *Firehose) TagDeliveryStream(
+	_ context.Context,
+	input *svcsdk.TagDeliveryStreamInput,
+	_ ...func(*svcsdk.Options),
+) (*svcsdk.TagDeliveryStreamOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injectedFailure("TagDeliveryStream"); err != nil {
+		return nil, err
+	}
+	s, err := f.stream(input.DeliveryStreamName)
+	if err != nil {
+		return nil, err
+	}
+	if len(input.Tags) == 0 || len(input.Tags) > maxTags {
+		return nil, invalidArgument(fmt.Sprintf("between 1 and %d tags must be specified", maxTags))
+	}
+	count := len(s.tags)
+	for _, tag := range input.Tags {
+		if _, ok := s.tags[aws.ToString(tag.Key)]; !ok {
+			count++
+		}
+	}
+	if count > maxTags {
+		return nil, &svcsdktypes.LimitExceededException{
+			Message: aws.String(fmt.Sprintf("a delivery stream can have at most %d tags", maxTags)),
+		}
+	}
+	for _, tag := range input.Tags {
+		s.tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return &svcsdk.TagDeliveryStreamOutput{}, nil
+}
+
+// UntagDeliveryStream removes tags from a delivery stream. Keys that are not
+// set are ignored.
+func (f *Firehose) UntagDeliveryStream(
+	_ context.Context,
+	input *svcsdk.UntagDeliveryStreamInput,
+	_ ...func(*svcsdk.Options),
+) (*svcsdk.UntagDeliveryStreamOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injectedFailure("UntagDeliveryStream"); err != nil {
+		return nil, err
+	}
+	s, err := f.stream(input.DeliveryStreamName)
+	if err != nil {
+		return nil, err
+	}
+	if len(input.TagKeys) == 0 || len(input.TagKeys) > maxTags {
+		return nil, invalidArgument(fmt.Sprintf("between 1 and %d tag keys must be specified", maxTags))
+	}
+	for _, key := range input.TagKeys {
+		delete(s.tags, key)
+	}
+	return &svcsdk.UntagDeliveryStreamOutput{}, nil
+}
+
+// ListTagsForDeliveryStream lists the tags of a delivery stream in key
+// order, starting after ExclusiveStartTagKey and returning at most Limit
+// tags.
+func (f *Firehose) ListTagsForDeliveryStream(
+	_ context.Context,
+	input *svcsdk.ListTagsForDeliveryStreamInput,
+	_ ...func(*svcsdk.Options),
+) (*svcsdk.ListTagsForDeliveryStreamOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.injectedFailure("ListTagsForDeliveryStream"); err != nil {
+		return nil, err
+	}
+	s, err := f.stream(input.DeliveryStreamName)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(s.tags))
+	for key := range s.tags {
+		if input.ExclusiveStartTagKey == nil || key > *input.ExclusiveStartTagKey {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	hasMore := false
+	if input.Limit != nil && int(*input.Limit) < len(keys) {
+		keys, hasMore = keys[:*input.Limit], true
+	}
+	tags := make([]svcsdktypes.Tag, 0, len(keys))
+	for _, key := range keys {
+		tags = append(tags, svcsdktypes.Tag{Key: aws.String(key), Value: aws.String(s.tags[key])})
+	}
+	return &svcsdk.ListTagsForDeliveryStreamOutput{Tags: tags, HasMoreTags: aws.Bool(hasMore)}, nil
+}
+
+func invalidArgument(message string) error {
+	return &svcsdktypes.InvalidArgumentException{Message: aws.String(message)}
+}
+
+// clone returns a deep copy of v, so that callers never share memory with
+// the state of the fake. The SDK types only hold exported data fields.
+func clone[T any](v *T) *T {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	out := new(T)
+	if err := json.Unmarshal(b, out); err != nil {
+		panic(err)
+	}
+	return out
+}
@@ -0,0 +1,181 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fakefirehose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	svcsdk "github.com/aws/aws-sdk-go-v2/service/firehose"
+	svcsdktypes "github.com/aws/aws-sdk-go-v2/service/firehose/types"
+	"github.com/aws/smithy-go"
+)
+
+func newStream(t *testing.T, f *Firehose, name string) {
+	t.Helper()
+	_, err := f.CreateDeliveryStream(context.Background(), &svcsdk.CreateDeliveryStreamInput{
+		DeliveryStreamName: aws.String(name),
+		HttpEndpointDestinationConfiguration: &svcsdktypes.HttpEndpointDestinationConfiguration{
+			EndpointConfiguration: &svcsdktypes.HttpEndpointConfiguration{Url: aws.String("https://example.com")},
+			S3Configuration: &svcsdktypes.S3DestinationConfiguration{
+				BucketARN: aws.String("arn:aws:s3:::bucket"),
+				RoleARN:   aws.String("arn:aws:iam::123456789012:role/firehose"),
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateDeliveryStream() error = %v", err)
+	}
+}
+
+func errorCode(err error) string {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode()
+	}
+	return ""
+}
+
+func TestTags(t *testing.T) {
+	ctx := context.Background()
+	f := New(Options{})
+	newStream(t, f, "stream")
+
+	var tags []svcsdktypes.Tag
+	for i := 0; i < maxTags; i++ {
+		tags = append(tags, svcsdktypes.Tag{Key: aws.String(fmt.Sprintf("key-%02d", i)), Value: aws.String("v")})
+	}
+	if _, err := f.TagDeliveryStream(ctx, &svcsdk.TagDeliveryStreamInput{DeliveryStreamName: aws.String("stream"), Tags: tags}); err != nil {
+		t.Fatalf("TagDeliveryStream() error = %v", err)
+	}
+	_, err := f.TagDeliveryStream(ctx, &svcsdk.TagDeliveryStreamInput{
+		DeliveryStreamName: aws.String("stream"),
+		Tags:               []svcsdktypes.Tag{{Key: aws.String("extra"), Value: aws.String("v")}},
+	})
+	if got := errorCode(err); got != "LimitExceededException" {
+		t.Errorf("TagDeliveryStream() beyond the limit error = %v, want LimitExceededException", err)
+	}
+
+	out, err := f.ListTagsForDeliveryStream(ctx, &svcsdk.ListTagsForDeliveryStreamInput{
+		DeliveryStreamName:   aws.String("stream"),
+		ExclusiveStartTagKey: aws.String("key-09"),
+		Limit:                aws.Int32(2),
+	})
+	if err != nil {
+		t.Fatalf("ListTagsForDeliveryStream() error = %v", err)
+	}
+	if len(out.Tags) != 2 || aws.ToString(out.Tags[0].Key) != "key-10" || !aws.ToBool(out.HasMoreTags) {
+		t.Errorf("ListTagsForDeliveryStream() = %v, has more %v, want key-10 and key-11 with more tags", out.Tags, aws.ToBool(out.HasMoreTags))
+	}
+
+	if _, err := f.UntagDeliveryStream(ctx, &svcsdk.UntagDeliveryStreamInput{
+		DeliveryStreamName: aws.String("stream"),
+		TagKeys:            []string{"key-00", "missing"},
+	}); err != nil {
+		t.Fatalf("UntagDeliveryStream() error = %v", err)
+	}
+	out, _ = f.ListTagsForDeliveryStream(ctx, &svcsdk.ListTagsForDeliveryStreamInput{DeliveryStreamName: aws.String("stream")})
+	if len(out.Tags) != maxTags-1 || aws.ToBool(out.HasMoreTags) {
+		t.Errorf("ListTagsForDeliveryStream() returned %d tags, want %d", len(out.Tags), maxTags-1)
+	}
+}
+
+func TestListDeliveryStreams(t *testing.T) {
+	ctx := context.Background()
+	f := New(Options{})
+	for _, name := range []string{"c", "a", "b"} {
+		newStream(t, f, name)
+	}
+
+	out, err := f.ListDeliveryStreams(ctx, &svcsdk.ListDeliveryStreamsInput{Limit: aws.Int32(2)})
+	if err != nil {
+		t.Fatalf("ListDeliveryStreams() error = %v", err)
+	}
+	if !slices.Equal(out.DeliveryStreamNames, []string{"a", "b"}) || !aws.ToBool(out.HasMoreDeliveryStreams) {
+		t.Errorf("ListDeliveryStreams() = %v, has more %v, want [a b] with more streams", out.DeliveryStreamNames, aws.ToBool(out.HasMoreDeliveryStreams))
+	}
+	out, err = f.ListDeliveryStreams(ctx, &svcsdk.ListDeliveryStreamsInput{ExclusiveStartDeliveryStreamName: aws.String("b")})
+	if err != nil {
+		t.Fatalf("ListDeliveryStreams() error = %v", err)
+	}
+	if !slices.Equal(out.DeliveryStreamNames, []string{"c"}) || aws.ToBool(out.HasMoreDeliveryStreams) {
+		t.Errorf("ListDeliveryStreams() after b = %v, has more %v, want [c]", out.DeliveryStreamNames, aws.ToBool(out.HasMoreDeliveryStreams))
+	}
+}
+
+func TestErrors(t *testing.T) {
+	ctx := context.Background()
+	f := New(Options{})
+	newStream(t, f, "stream")
+
+	tests := []struct {
+		name string
+		call func() error
+		want string
+	}{
+		{
+			name: "describe a missing stream",
+			call: func() error {
+				_, err := f.DescribeDeliveryStream(ctx, &svcsdk.DescribeDeliveryStreamInput{DeliveryStreamName: aws.String("missing")})
+				return err
+			},
+			want: "ResourceNotFoundException",
+		},
+		{
+			name: "update a CREATING stream",
+			call: func() error {
+				_, err := f.UpdateDestination(ctx, &svcsdk.UpdateDestinationInput{
+					DeliveryStreamName:             aws.String("stream"),
+					CurrentDeliveryStreamVersionId: aws.String("1"),
+					DestinationId:                  aws.String(destinationID),
+				})
+				return err
+			},
+			want: "ResourceInUseException",
+		},
+		{
+			name: "encrypt a CREATING stream",
+			call: func() error {
+				_, err := f.StartDeliveryStreamEncryption(ctx, &svcsdk.StartDeliveryStreamEncryptionInput{
+					DeliveryStreamName: aws.String("stream"),
+					DeliveryStreamEncryptionConfigurationInput: &svcsdktypes.DeliveryStreamEncryptionConfigurationInput{
+						KeyType: svcsdktypes.KeyTypeAwsOwnedCmk,
+					},
+				})
+				return err
+			},
+			want: "ResourceInUseException",
+		},
+		{
+			name: "injected failure",
+			call: func() error {
+				f.FailNext("DescribeDeliveryStream", &svcsdktypes.ServiceUnavailableException{Message: aws.String("unavailable")})
+				_, err := f.DescribeDeliveryStream(ctx, &svcsdk.DescribeDeliveryStreamInput{DeliveryStreamName: aws.String("stream")})
+				return err
+			},
+			want: "ServiceUnavailableException",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errorCode(tt.call()); got != tt.want {
+				t.Errorf("error code = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fakefirehose
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	svcsdk "github.com/aws/aws-sdk-go-v2/service/firehose"
+	"github.com/aws/smithy-go"
+)
+
+const (
+	// targetPrefix prefixes the X-Amz-Target header of the Firehose
+	// operations, which is followed by the operation name.
+	targetPrefix = "Firehose_20150804."
+	// contentType is the content type of the Firehose JSON protocol.
+	contentType = "application/x-amz-json-1.1"
+)
+
+// operation decodes the request of a Firehose operation, calls it and
+// returns its output.
+type operation func(ctx context.Context, body []byte) (any, error)
+
+// newOperation adapts a method of Firehose to an operation.
+func newOperation[In, Out any](call func(context.Context, *In, ...func(*svcsdk.Options)) (*Out, error)) operation {
+	return func(ctx context.Context, body []byte) (any, error) {
+		input := new(In)
+		if len(body) > 0 {
+			if err := json.Unmarshal(body, input); err != nil {
+				return nil, &smithy.GenericAPIError{Code: "SerializationException", Message: err.Error()}
+			}
+		}
+		return call(ctx, input)
+	}
+}
+
+// Handler returns an http.Handler that serves the Firehose JSON protocol,
+// so that the Firehose SDK and the controller can use f through their
+// endpoint URL. It also answers the STS GetCallerIdentity call the
+// controller makes at startup with the account ID of f, when it is used as
+// the identity endpoint URL.
+func Handler(f *Firehose) http.Handler {
+	operations := map[string]operation{
+		"CreateDeliveryStream":          newOperation(f.CreateDeliveryStream),
+		"DescribeDeliveryStream":        newOperation(f.DescribeDeliveryStream),
+		"ListDeliveryStreams":           newOperation(f.ListDeliveryStreams),
+		"UpdateDestination":             newOperation(f.UpdateDestination),
+		"DeleteDeliveryStream":          newOperation(f.DeleteDeliveryStream),
+		"StartDeliveryStreamEncryption": newOperation(f.StartDeliveryStreamEncryption),
+		"StopDeliveryStreamEncryption":  newOperation(f.StopDeliveryStreamEncryption),
+		"TagDeliveryStream":             newOperation(f.TagDeliveryStream),
+		"UntagDeliveryStream":           newOperation(f.UntagDeliveryStream),
+		"ListTagsForDeliveryStream":     newOperation(f.ListTagsForDeliveryStream),
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		target := r.Header.Get("X-Amz-Target")
+		if target == "" {
+			f.serveIdentity(w, r)
+			return
+		}
+		op, ok := operations[strings.TrimPrefix(target, targetPrefix)]
+		if !ok || !strings.HasPrefix(target, targetPrefix) {
+			writeError(w, &smithy.GenericAPIError{Code: "UnknownOperationException", Message: fmt.Sprintf("unknown operation %s", target)})
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, &smithy.GenericAPIError{Code: "SerializationException", Message: err.Error()})
+			return
+		}
+		out, err := op(r.Context(), body)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		b, err := marshalOutput(out)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Write(b)
+	})
+}
+
+// marshalOutput encodes the output of an operation. The SDK output types
+// are encoded with their field names, which are the member names of the
+// protocol, except that timestamps are epoch seconds and the result
+// metadata of the SDK is not part of the protocol.
+func marshalOutput(out any) ([]byte, error) {
+	b, err := json.Marshal(out)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	delete(doc, "ResultMetadata")
+	return json.Marshal(protocolValue(doc))
+}
+
+// protocolValue removes the unset members of a decoded JSON document and
+// replaces its RFC 3339 timestamps, in members whose name ends with
+// Timestamp, with epoch seconds.
+func protocolValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if value == nil {
+				delete(v, key)
+				continue
+			}
+			if s, ok := value.(string); ok && strings.HasSuffix(key, "Timestamp") {
+				if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+					v[key] = float64(t.UnixNano()) / float64(time.Second)
+					continue
+				}
+			}
+			v[key] = protocolValue(value)
+		}
+	case []any:
+		for i := range v {
+			v[i] = protocolValue(v[i])
+		}
+	}
+	return v
+}
+
+// writeError writes err the way the service reports errors. Errors that
+// aren't API errors are internal failures.
+func writeError(w http.ResponseWriter, err error) {
+	code, status := "InternalFailure", http.StatusInternalServerError
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		code, status = apiErr.ErrorCode(), http.StatusBadRequest
+		if apiErr.ErrorFault() == smithy.FaultServer {
+			status = http.StatusServiceUnavailable
+		}
+	}
+	message := err.Error()
+	if apiErr != nil {
+		message = apiErr.ErrorMessage()
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Amzn-ErrorType", code)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"__type": code, "message": message})
+}
+
+// getCallerIdentityResponse is the STS GetCallerIdentity response.
+type getCallerIdentityResponse struct {
+	XMLName xml.Name `xml:"https://sts.amazonaws.com/doc/2011-06-15/ GetCallerIdentityResponse"`
+	Result  struct {
+		Arn     string `xml:"Arn"`
+		UserID  string `xml:"UserId"`
+		Account string `xml:"Account"`
+	} `xml:"GetCallerIdentityResult"`
+	RequestID string `xml:"ResponseMetadata>RequestId"`
+}
+
+// serveIdentity answers the STS GetCallerIdentity call of the STS query
+// protocol.
+func (f *Firehose) serveIdentity(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("Action") != "GetCallerIdentity" {
+		http.Error(w, "only the Firehose operations and STS GetCallerIdentity are supported", http.StatusBadRequest)
+		return
+	}
+	var resp getCallerIdentityResponse
+	resp.Result.Account = f.opts.AccountID
+	resp.Result.UserID = "AIDAFAKEFIREHOSE"
+	resp.Result.Arn = fmt.Sprintf("arn:aws:iam::%s:user/fake-firehose", f.opts.AccountID)
+	resp.RequestID = "00000000-0000-0000-0000-000000000000"
+	w.Header().Set("Content-Type", "text/xml")
+	xml.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,107 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fakefirehose
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	svcsdk "github.com/aws/aws-sdk-go-v2/service/firehose"
+	svcsdktypes "github.com/aws/aws-sdk-go-v2/service/firehose/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// TestHandler drives the handler through the SDK clients the controller
+// uses.
+func TestHandler(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	f := New(Options{AccountID: "123456789012", Now: func() time.Time { return now }})
+	server := httptest.NewServer(Handler(f))
+	defer server.Close()
+	cfg := aws.Config{
+		Region:       "us-west-2",
+		Credentials:  aws.AnonymousCredentials{},
+		BaseEndpoint: aws.String(server.URL),
+	}
+	client := svcsdk.NewFromConfig(cfg)
+
+	_, err := client.CreateDeliveryStream(ctx, &svcsdk.CreateDeliveryStreamInput{
+		DeliveryStreamName: aws.String("stream"),
+		HttpEndpointDestinationConfiguration: &svcsdktypes.HttpEndpointDestinationConfiguration{
+			EndpointConfiguration: &svcsdktypes.HttpEndpointConfiguration{
+				Url:       aws.String("https://example.com"),
+				AccessKey: aws.String("secret"),
+			},
+			BufferingHints: &svcsdktypes.HttpEndpointBufferingHints{SizeInMBs: aws.Int32(1)},
+			S3Configuration: &svcsdktypes.S3DestinationConfiguration{
+				BucketARN: aws.String("arn:aws:s3:::bucket"),
+				RoleARN:   aws.String("arn:aws:iam::123456789012:role/firehose"),
+			},
+		},
+		Tags: []svcsdktypes.Tag{{Key: aws.String("team"), Value: aws.String("data")}},
+	})
+	if err != nil {
+		t.Fatalf("CreateDeliveryStream() error = %v", err)
+	}
+
+	now = now.Add(time.Hour)
+	out, err := client.DescribeDeliveryStream(ctx, &svcsdk.DescribeDeliveryStreamInput{DeliveryStreamName: aws.String("stream")})
+	if err != nil {
+		t.Fatalf("DescribeDeliveryStream() error = %v", err)
+	}
+	description := out.DeliveryStreamDescription
+	if description.DeliveryStreamStatus != svcsdktypes.DeliveryStreamStatusActive {
+		t.Errorf("DeliveryStreamStatus = %s, want ACTIVE", description.DeliveryStreamStatus)
+	}
+	if got, want := aws.ToTime(description.CreateTimestamp), now.Add(-time.Hour); !got.Equal(want) {
+		t.Errorf("CreateTimestamp = %v, want %v", got, want)
+	}
+	destination := description.Destinations[0].HttpEndpointDestinationDescription
+	if got := aws.ToInt32(destination.BufferingHints.SizeInMBs); got != 1 {
+		t.Errorf("BufferingHints.SizeInMBs = %d, want 1", got)
+	}
+	if got := aws.ToInt32(destination.BufferingHints.IntervalInSeconds); got != 300 {
+		t.Errorf("BufferingHints.IntervalInSeconds = %d, want the default 300", got)
+	}
+	if got := aws.ToString(destination.EndpointConfiguration.Url); got != "https://example.com" {
+		t.Errorf("EndpointConfiguration.Url = %q, want https://example.com", got)
+	}
+
+	tags, err := client.ListTagsForDeliveryStream(ctx, &svcsdk.ListTagsForDeliveryStreamInput{DeliveryStreamName: aws.String("stream")})
+	if err != nil {
+		t.Fatalf("ListTagsForDeliveryStream() error = %v", err)
+	}
+	if len(tags.Tags) != 1 || aws.ToString(tags.Tags[0].Value) != "data" {
+		t.Errorf("Tags = %v, want team=data", tags.Tags)
+	}
+
+	_, err = client.DescribeDeliveryStream(ctx, &svcsdk.DescribeDeliveryStreamInput{DeliveryStreamName: aws.String("missing")})
+	var notFound *svcsdktypes.ResourceNotFoundException
+	if !errors.As(err, &notFound) {
+		t.Errorf("DescribeDeliveryStream() of a missing stream error = %v, want a ResourceNotFoundException", err)
+	}
+
+	identity, err := sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		t.Fatalf("GetCallerIdentity() error = %v", err)
+	}
+	if got := aws.ToString(identity.Account); got != "123456789012" {
+		t.Errorf("Account = %q, want 123456789012", got)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package refwatch triggers the reconciliation of DeliveryStream resources
+// when Kubernetes objects they reference change. The ACK runtime only
+// watches the DeliveryStream resources themselves, so without it such
+// changes are only picked up on the next periodic resync.
+package refwatch
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+
+	ackcfg "github.com/aws-controllers-k8s/runtime/pkg/config"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrlrt "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// deliveryStreamControllerName is the name the ACK runtime gives to the
+	// DeliveryStream controller, the lowercased kind.
+	deliveryStreamControllerName = "deliverystream"
+	// deliveryStreamKind is the kind listed in the reconcile-resources option
+	// of the ACK runtime to reconcile DeliveryStreams.
+	deliveryStreamKind = "DeliveryStream"
+)
+
+// Manager is a manager that adds watches to the DeliveryStream controller
+// registered through it.
+type Manager struct {
+	ctrlrt.Manager
+	sources []source.Source
+	// watching is true once the watches were added to the DeliveryStream
+	// controller.
+	watching bool
+}
+
+// NewManager returns a manager that forwards every call to mgr and adds, to
+// the DeliveryStream controller the ACK runtime registers through it, a
+// watch on the Secrets holding HTTP endpoint access keys and on the
+// resources of the supplied kinds referenced by DeliveryStreams. The ACK
+// runtime doesn't let a service controller add watches to the controllers it
+// builds, the returned manager must therefore be passed to the
+// BindControllerManager method of the service controller, and Verify called
+// once it returns. The events of the added watches go through the workqueue
+// of the DeliveryStream controller, so a DeliveryStream is never reconciled
+// concurrently.
+func NewManager(
+	mgr ctrlrt.Manager,
+	referencedKinds []schema.GroupVersionKind,
+) (*Manager, error) {
+	secretSrc, err := secretSource(mgr)
+	if err != nil {
+		return nil, err
+	}
+	referenceSrcs, err := referenceSources(mgr, referencedKinds)
+	if err != nil {
+		return nil, err
+	}
+	return &Manager{
+		Manager: mgr,
+		sources: append([]source.Source{secretSrc}, referenceSrcs...),
+	}, nil
+}
+
+// Add adds the watches to r if it is the DeliveryStream controller, then
+// adds r to the manager.
+func (m *Manager) Add(r manager.Runnable) error {
+	if c, ok := r.(controller.Controller); ok && controllerName(c) == deliveryStreamControllerName {
+		for _, src := range m.sources {
+			if err := c.Watch(src); err != nil {
+				return err
+			}
+		}
+		m.watching = true
+	}
+	return m.Manager.Add(r)
+}
+
+// Verify returns an error if the ACK runtime was configured with cfg to
+// reconcile DeliveryStreams but no DeliveryStream controller was registered
+// through m, so that the controller doesn't start without the watches.
+func (m *Manager) Verify(cfg ackcfg.Config) error {
+	if m.watching {
+		return nil
+	}
+	kinds, err := cfg.GetReconcileResources()
+	if err != nil {
+		return err
+	}
+	if len(kinds) > 0 && !slices.Contains(kinds, deliveryStreamKind) {
+		return nil
+	}
+	return fmt.Errorf("no controller named %q was registered, "+
+		"the watches of the objects DeliveryStreams reference could not be added", deliveryStreamControllerName)
+}
+
+// controllerName returns the name of a controller built by controller-runtime,
+// or an empty string if it has none. The Controller interface doesn't expose
+// the name, it is read from the Name field of the implementation.
+func controllerName(c controller.Controller) string {
+	v := reflect.Indirect(reflect.ValueOf(c))
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	name := v.FieldByName("Name")
+	if !name.IsValid() || name.Kind() != reflect.String {
+		return ""
+	}
+	return name.String()
+}
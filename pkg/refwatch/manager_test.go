@@ -0,0 +1,130 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package refwatch
+
+import (
+	"context"
+	"testing"
+
+	ackcfg "github.com/aws-controllers-k8s/runtime/pkg/config"
+	"k8s.io/client-go/util/workqueue"
+	ctrlrt "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// recordingManager records the runnables added to it.
+type recordingManager struct {
+	ctrlrt.Manager
+	added []manager.Runnable
+}
+
+func (m *recordingManager) Add(r manager.Runnable) error {
+	m.added = append(m.added, r)
+	return nil
+}
+
+// recordingController records the sources it is asked to watch. Like the
+// controllers of controller-runtime, it has a Name field.
+type recordingController struct {
+	controller.Controller
+	Name    string
+	watched []source.Source
+}
+
+func (c *recordingController) Watch(src source.Source) error {
+	c.watched = append(c.watched, src)
+	return nil
+}
+
+func newController(t *testing.T, name string) controller.Controller {
+	t.Helper()
+	c, err := controller.NewUnmanaged(name, controller.Options{
+		Reconciler: reconcile.Func(func(context.Context, reconcile.Request) (reconcile.Result, error) {
+			return reconcile.Result{}, nil
+		}),
+		SkipNameValidation: ptrTo(true),
+	})
+	if err != nil {
+		t.Fatalf("NewUnmanaged() error = %v", err)
+	}
+	return c
+}
+
+func ptrTo[T any](v T) *T {
+	return &v
+}
+
+func TestControllerName(t *testing.T) {
+	for _, name := range []string{deliveryStreamControllerName, "fieldexport"} {
+		if got := controllerName(newController(t, name)); got != name {
+			t.Errorf("controllerName() = %q, want %q", got, name)
+		}
+	}
+}
+
+func TestManagerAdd(t *testing.T) {
+	src := source.Func(func(context.Context, workqueue.TypedRateLimitingInterface[reconcile.Request]) error {
+		return nil
+	})
+	tests := []struct {
+		name        string
+		controller  string
+		wantWatched int
+	}{
+		{name: "DeliveryStream controller gets the watches", controller: deliveryStreamControllerName, wantWatched: 1},
+		{name: "other controllers are left untouched", controller: "fieldexport", wantWatched: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rm := &recordingManager{}
+			m := &Manager{Manager: rm, sources: []source.Source{src}}
+			c := &recordingController{Name: tt.controller}
+			if err := m.Add(c); err != nil {
+				t.Fatalf("Add() error = %v", err)
+			}
+			if len(c.watched) != tt.wantWatched {
+				t.Errorf("watched %d sources, want %d", len(c.watched), tt.wantWatched)
+			}
+			if len(rm.added) != 1 || rm.added[0] != c {
+				t.Errorf("added runnables = %v, want the controller", rm.added)
+			}
+		})
+	}
+}
+
+func TestManagerVerify(t *testing.T) {
+	tests := []struct {
+		name               string
+		watching           bool
+		reconcileResources string
+		wantErr            bool
+	}{
+		{name: "watches added", watching: true},
+		{name: "controller not found", wantErr: true},
+		{name: "controller not found among reconciled resources", reconcileResources: "DeliveryStream", wantErr: true},
+		{name: "DeliveryStreams not reconciled", reconcileResources: "FieldExport"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Manager{Manager: &recordingManager{}, watching: tt.watching}
+			err := m.Verify(ackcfg.Config{ReconcileResources: tt.reconcileResources})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package refwatch
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlrt "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+)
+
+// The generated references.go only grants get and list on the resources the
+// generated reference fields point to; watching them needs watch as well.
+
+// +kubebuilder:rbac:groups=kms.services.k8s.aws,resources=keys,verbs=get;list;watch
+// +kubebuilder:rbac:groups=iam.services.k8s.aws,resources=roles,verbs=get;list;watch
+// +kubebuilder:rbac:groups=s3.services.k8s.aws,resources=buckets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=secretsmanager.services.k8s.aws,resources=secrets,verbs=get;list;watch
+
+const (
+	// referenceIndex indexes DeliveryStream resources by the resources
+	// listed in their Status.References.
+	referenceIndex = ".status.references"
+)
+
+// referenceKey returns the index key of the resource of the supplied kind,
+// namespace and name.
+func referenceKey(gk schema.GroupKind, namespace, name string) string {
+	return gk.String() + "/" + types.NamespacedName{Namespace: namespace, Name: name}.String()
+}
+
+// referenceKeys returns the index keys of the resources referenced by the
+// supplied DeliveryStream, as recorded in its status by the last
+// reconciliation.
+func referenceKeys(ds *svcapitypes.DeliveryStream) []string {
+	var keys []string
+	for _, ref := range ds.Status.References {
+		if ref == nil || ref.APIVersion == nil || ref.Kind == nil ||
+			ref.Namespace == nil || ref.Name == nil {
+			continue
+		}
+		gv, err := schema.ParseGroupVersion(*ref.APIVersion)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, referenceKey(gv.WithKind(*ref.Kind).GroupKind(), *ref.Namespace, *ref.Name))
+	}
+	return keys
+}
+
+// referenceSources returns a source of requests, for each of the supplied
+// kinds, for every DeliveryStream that references a resource of that kind
+// that changed, so that references are resolved as soon as the referenced
+// resources are synced. Kinds whose CRD isn't installed are skipped. Only the
+// metadata of the referenced resources is cached.
+func referenceSources(
+	mgr ctrlrt.Manager,
+	kinds []schema.GroupVersionKind,
+) ([]source.Source, error) {
+	log := mgr.GetLogger().WithName("refwatch")
+	var watched []schema.GroupVersionKind
+	for _, gvk := range kinds {
+		_, err := mgr.GetRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+		if meta.IsNoMatchError(err) {
+			log.Info("not watching referenced kind, its CRD is not installed", "kind", gvk.String())
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		watched = append(watched, gvk)
+	}
+	if len(watched) == 0 {
+		return nil, nil
+	}
+
+	err := mgr.GetFieldIndexer().IndexField(
+		context.Background(),
+		&svcapitypes.DeliveryStream{},
+		referenceIndex,
+		func(obj client.Object) []string {
+			return referenceKeys(obj.(*svcapitypes.DeliveryStream))
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	kc := mgr.GetClient()
+	sources := make([]source.Source, 0, len(watched))
+	for _, gvk := range watched {
+		gk := gvk.GroupKind()
+		obj := &metav1.PartialObjectMetadata{}
+		obj.SetGroupVersionKind(gvk)
+		sources = append(sources, source.Kind(
+			mgr.GetCache(),
+			client.Object(obj),
+			handler.EnqueueRequestsFromMapFunc(
+				func(ctx context.Context, obj client.Object) []reconcile.Request {
+					key := referenceKey(gk, obj.GetNamespace(), obj.GetName())
+					list := &svcapitypes.DeliveryStreamList{}
+					err := kc.List(ctx, list, client.MatchingFields{
+						referenceIndex: key,
+					})
+					if err != nil {
+						ctrlrt.LoggerFrom(ctx).Error(
+							err, "unable to list delivery streams referencing resource",
+							"resource", key,
+						)
+						return nil
+					}
+					requests := make([]reconcile.Request, 0, len(list.Items))
+					for _, ds := range list.Items {
+						requests = append(requests, reconcile.Request{
+							NamespacedName: types.NamespacedName{
+								Namespace: ds.Namespace,
+								Name:      ds.Name,
+							},
+						})
+					}
+					return requests
+				},
+			),
+		))
+	}
+	return sources, nil
+}
@@ -0,0 +1,110 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package refwatch
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlrt "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+)
+
+const (
+	// accessKeySecretIndex indexes DeliveryStream resources by the
+	// namespace/name of the Secret holding their HTTP endpoint access key.
+	accessKeySecretIndex = ".spec.httpEndpointDestinationConfiguration.endpointConfiguration.accessKey"
+)
+
+// accessKeySecret returns the namespace/name of the Secret holding the HTTP
+// endpoint access key of the supplied DeliveryStream, or an empty string if
+// it doesn't reference one. A Secret reference without a namespace points to
+// the namespace of the DeliveryStream.
+func accessKeySecret(ds *svcapitypes.DeliveryStream) string {
+	if ds.Spec.HTTPEndpointDestinationConfiguration == nil ||
+		ds.Spec.HTTPEndpointDestinationConfiguration.EndpointConfiguration == nil ||
+		ds.Spec.HTTPEndpointDestinationConfiguration.EndpointConfiguration.AccessKey == nil {
+		return ""
+	}
+	ref := ds.Spec.HTTPEndpointDestinationConfiguration.EndpointConfiguration.AccessKey
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = ds.Namespace
+	}
+	return types.NamespacedName{Namespace: namespace, Name: ref.Name}.String()
+}
+
+// secretSource returns a source of requests for every DeliveryStream whose
+// HTTP endpoint access key is held by a Secret that changed. Only the
+// metadata of Secrets is cached.
+func secretSource(mgr ctrlrt.Manager) (source.Source, error) {
+	err := mgr.GetFieldIndexer().IndexField(
+		context.Background(),
+		&svcapitypes.DeliveryStream{},
+		accessKeySecretIndex,
+		func(obj client.Object) []string {
+			if secret := accessKeySecret(obj.(*svcapitypes.DeliveryStream)); secret != "" {
+				return []string{secret}
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	kc := mgr.GetClient()
+	secret := &metav1.PartialObjectMetadata{}
+	secret.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Secret"))
+	return source.Kind(
+		mgr.GetCache(),
+		client.Object(secret),
+		handler.EnqueueRequestsFromMapFunc(
+			func(ctx context.Context, obj client.Object) []reconcile.Request {
+				secret := types.NamespacedName{
+					Namespace: obj.GetNamespace(),
+					Name:      obj.GetName(),
+				}
+				list := &svcapitypes.DeliveryStreamList{}
+				err := kc.List(ctx, list, client.MatchingFields{
+					accessKeySecretIndex: secret.String(),
+				})
+				if err != nil {
+					ctrlrt.LoggerFrom(ctx).Error(
+						err, "unable to list delivery streams referencing secret",
+						"secret", secret.String(),
+					)
+					return nil
+				}
+				requests := make([]reconcile.Request, 0, len(list.Items))
+				for _, ds := range list.Items {
+					requests = append(requests, reconcile.Request{
+						NamespacedName: types.NamespacedName{
+							Namespace: ds.Namespace,
+							Name:      ds.Name,
+						},
+					})
+				}
+				return requests
+			},
+		),
+	), nil
+}
@@ -0,0 +1,112 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delivery_stream
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	ackv1alpha1 "github.com/aws-controllers-k8s/runtime/apis/core/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+)
+
+const (
+	accessKeyPath = "Spec.HTTPEndpointDestinationConfiguration.EndpointConfiguration.AccessKey"
+)
+
+// accessKeyRef returns the Secret reference holding the HTTP endpoint access
+// key or nil if it isn't set.
+func accessKeyRef(ko *svcapitypes.DeliveryStream) *ackv1alpha1.SecretKeyReference {
+	if ko.Spec.HTTPEndpointDestinationConfiguration == nil ||
+		ko.Spec.HTTPEndpointDestinationConfiguration.EndpointConfiguration == nil {
+		return nil
+	}
+	return ko.Spec.HTTPEndpointDestinationConfiguration.EndpointConfiguration.AccessKey
+}
+
+// accessKeyHash returns the access key hash recorded on the resource.
+func accessKeyHash(r *resource) string {
+	return r.ko.GetAnnotations()[svcapitypes.AccessKeyHashAnnotation]
+}
+
+// hashAccessKey returns the hex encoded HMAC-SHA256 of the access key value,
+// keyed by the UID of the custom resource and the resourceVersion of the
+// Secret holding the key. The recorded hash can thus neither be looked up in
+// precomputed tables nor compared across resources, and any change to the
+// Secret is treated as a rotation.
+func hashAccessKey(value string, uid types.UID, secretVersion string) string {
+	mac := hmac.New(sha256.New, []byte(string(uid)+"/"+secretVersion))
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// accessKeySecretVersion returns the resourceVersion of the Secret referenced
+// by ref, or an empty string if there is no reader to get it with.
+func accessKeySecretVersion(
+	ctx context.Context,
+	ko *svcapitypes.DeliveryStream,
+	ref *ackv1alpha1.SecretKeyReference,
+) (string, error) {
+	reader := getAPIReader()
+	if reader == nil {
+		return "", nil
+	}
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = ko.GetNamespace()
+	}
+	secret := &corev1.Secret{}
+	if err := reader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: ref.Name}, secret); err != nil {
+		return "", fmt.Errorf("getting Secret %s/%s: %w", namespace, ref.Name, err)
+	}
+	return secret.GetResourceVersion(), nil
+}
+
+// setAccessKeyHash resolves the access key referenced by the supplied custom
+// resource and records its hash in the access-key-hash annotation. The
+// annotation is removed when no access key is referenced.
+func (rm *resourceManager) setAccessKeyHash(
+	ctx context.Context,
+	ko *svcapitypes.DeliveryStream,
+) error {
+	annotations := ko.GetAnnotations()
+	ref := accessKeyRef(ko)
+	if ref == nil {
+		if _, ok := annotations[svcapitypes.AccessKeyHashAnnotation]; ok {
+			delete(annotations, svcapitypes.AccessKeyHashAnnotation)
+			ko.SetAnnotations(annotations)
+		}
+		return nil
+	}
+	value, err := rm.rr.SecretValueFromReference(ctx, ref)
+	if err != nil {
+		return err
+	}
+	secretVersion, err := accessKeySecretVersion(ctx, ko, ref)
+	if err != nil {
+		return err
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[svcapitypes.AccessKeyHashAnnotation] = hashAccessKey(value, ko.GetUID(), secretVersion)
+	ko.SetAnnotations(annotations)
+	return nil
+}
@@ -0,0 +1,94 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delivery_stream
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSetAccessKeyHash(t *testing.T) {
+	ctx := context.TODO()
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "data", Name: "endpoint"},
+		Type:       corev1.SecretTypeOpaque,
+		Data:       map[string][]byte{"accessKey": []byte("secret")},
+	}
+	kc := fake.NewClientBuilder().WithObjects(secret).Build()
+	defer SetAPIReader(getAPIReader())
+	SetAPIReader(kc)
+	rm := &resourceManager{rr: &planReconciler{apiReader: kc, namespace: "data"}}
+
+	hash := func(uid types.UID) string {
+		r := newAccessKeyResource("")
+		r.ko.Namespace = "data"
+		r.ko.UID = uid
+		if err := rm.setAccessKeyHash(ctx, r.ko); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return accessKeyHash(r)
+	}
+
+	first := hash("uid-1")
+	if first != hash("uid-1") {
+		t.Errorf("expected the hash to be stable")
+	}
+	sum := sha256.Sum256([]byte("secret"))
+	if first == hex.EncodeToString(sum[:]) {
+		t.Errorf("expected the hash not to be the plain SHA-256 of the access key")
+	}
+	if first == hash("uid-2") {
+		t.Errorf("expected the hash to differ across resources")
+	}
+
+	if err := kc.Get(ctx, client.ObjectKeyFromObject(secret), secret); err != nil {
+		t.Fatal(err)
+	}
+	secret.Labels = map[string]string{"rotated": "true"}
+	if err := kc.Update(ctx, secret); err != nil {
+		t.Fatal(err)
+	}
+	if first == hash("uid-1") {
+		t.Errorf("expected the hash to change with the Secret")
+	}
+}
+
+func TestSetAccessKeyHashWithoutReader(t *testing.T) {
+	kc := fake.NewClientBuilder().WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "data", Name: "endpoint"},
+		Type:       corev1.SecretTypeOpaque,
+		Data:       map[string][]byte{"accessKey": []byte("secret")},
+	}).Build()
+	defer SetAPIReader(getAPIReader())
+	SetAPIReader(nil)
+	rm := &resourceManager{rr: &planReconciler{apiReader: kc, namespace: "data"}}
+
+	r := newAccessKeyResource("")
+	r.ko.Namespace = "data"
+	r.ko.UID = "uid-1"
+	if err := rm.setAccessKeyHash(context.TODO(), r.ko); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := hashAccessKey("secret", "uid-1", ""); accessKeyHash(r) != expected {
+		t.Errorf("expected hash %s, got %s", expected, accessKeyHash(r))
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delivery_stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	ackerr "github.com/aws-controllers-k8s/runtime/pkg/errors"
+	ackrt "github.com/aws-controllers-k8s/runtime/pkg/runtime"
+	acktags "github.com/aws-controllers-k8s/runtime/pkg/tags"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+	svcconfig "github.com/aws-controllers-k8s/firehose-controller/pkg/config"
+)
+
+// ownerTagKey is the key of the tag recording the cluster and the custom
+// resource that own a delivery stream, as "<cluster ID>/<CR UID>".
+const ownerTagKey = svcapitypes.AnnotationPrefix + "owner"
+
+// ErrDeliveryStreamOwnedElsewhere is returned when a delivery stream carries
+// the owner tag of another cluster or custom resource.
+var ErrDeliveryStreamOwnedElsewhere = errors.New("delivery stream is owned by another cluster or resource")
+
+// ownerTagValue returns the owner tag value identifying the supplied custom
+// resource, or an empty string if the cluster ID or the UID of the resource
+// is unknown.
+func ownerTagValue(ko *svcapitypes.DeliveryStream) string {
+	clusterID := svcconfig.Get().ClusterID
+	if clusterID == "" || ko.GetUID() == "" {
+		return ""
+	}
+	return clusterID + "/" + string(ko.GetUID())
+}
+
+// setControllerTags adds to the supplied tags those the controller manages
+// for the supplied custom resource: the owner tag and the propagated labels.
+//
+// The labels whose keys are listed in the propagate-label-keys option are
+// copied from the namespace of the custom resource, then from the custom
+// resource itself, so that a label of the custom resource takes precedence
+// over the same label of its namespace. Propagated labels take precedence
+// over the supplied tags, whatever their origin, and the owner tag can't be
+// overridden.
+func setControllerTags(
+	ctx context.Context,
+	tags acktags.Tags,
+	ko *svcapitypes.DeliveryStream,
+) error {
+	if keys := svcconfig.Get().PropagateLabelKeys; len(keys) > 0 {
+		nsLabels, err := namespaceLabels(ctx, ko.GetNamespace())
+		if err != nil {
+			return err
+		}
+		propagateLabels(tags, keys, nsLabels)
+		propagateLabels(tags, keys, ko.GetLabels())
+	}
+	if owner := ownerTagValue(ko); owner != "" {
+		tags[ownerTagKey] = owner
+	}
+	return nil
+}
+
+// ignoreControllerTags removes the tags managed by the controller from the
+// supplied tags, so that they don't show in the spec of the custom resource.
+func ignoreControllerTags(tags acktags.Tags) {
+	delete(tags, ownerTagKey)
+	for _, key := range svcconfig.Get().PropagateLabelKeys {
+		delete(tags, key)
+	}
+}
+
+// propagateLabels sets in the supplied tags the labels whose keys are listed
+// in keys.
+func propagateLabels(tags acktags.Tags, keys []string, labels map[string]string) {
+	for _, key := range keys {
+		if value, ok := labels[key]; ok {
+			tags[key] = value
+		}
+	}
+}
+
+// namespaceLabels returns the labels of the supplied namespace, or nil if no
+// namespace reader is set.
+func namespaceLabels(ctx context.Context, name string) (map[string]string, error) {
+	reader := getAPIReader()
+	if reader == nil || name == "" {
+		return nil, nil
+	}
+	ns := &corev1.Namespace{}
+	if err := reader.Get(ctx, types.NamespacedName{Name: name}, ns); err != nil {
+		return nil, fmt.Errorf("getting the labels of namespace %q: %w", name, err)
+	}
+	return ns.GetLabels(), nil
+}
+
+// tagValue returns the value of the tag with the supplied key, or an empty
+// string if there is none.
+func tagValue(tags []*svcapitypes.Tag, key string) string {
+	for _, tag := range tags {
+		if tag != nil && tag.Key != nil && *tag.Key == key && tag.Value != nil {
+			return *tag.Value
+		}
+	}
+	return ""
+}
+
+// foreignOwner returns the owner tag of the latest delivery stream if it
+// names another cluster or custom resource than desired, or an empty string.
+// Delivery streams without an owner tag, like those created before the tag
+// was introduced, are owned by whichever custom resource manages them.
+func foreignOwner(desired *resource, latest *resource) string {
+	owner := tagValue(latest.ko.Spec.Tags, ownerTagKey)
+	want := ownerTagValue(desired.ko)
+	if owner == "" || want == "" || owner == want {
+		return ""
+	}
+	return owner
+}
+
+// checkOwnership returns a terminal error if the latest delivery stream
+// carries the owner tag of another cluster or custom resource, unless the
+// desired resource carries the take-ownership annotation. In that case it
+// returns true, the owner tag needing to be replaced.
+func checkOwnership(desired *resource, latest *resource) (takeOver bool, err error) {
+	owner := foreignOwner(desired, latest)
+	if owner == "" {
+		return false, nil
+	}
+	if desired.ko.GetAnnotations()[svcapitypes.TakeOwnershipAnnotation] == "true" {
+		return true, nil
+	}
+	return false, ackerr.NewTerminalError(fmt.Errorf(
+		"%w: its %s tag is %q. Set the %s annotation to \"true\" to take ownership of it",
+		ErrDeliveryStreamOwnedElsewhere, ownerTagKey, owner, svcapitypes.TakeOwnershipAnnotation,
+	))
+}
+
+// checkReadOwnership returns the terminal error of checkOwnership if the
+// delivery stream read for desired is owned by another cluster or custom
+// resource, so that it is neither adopted nor reported in the status of
+// desired. Read-only resources only observe the delivery stream, they may
+// read one owned elsewhere. Resources being deleted may read one too, so
+// that sdkDelete can release them without deleting the delivery stream.
+func checkReadOwnership(desired *resource, latest *resource) error {
+	if ackrt.IsReadOnly(desired) || desired.ko.GetDeletionTimestamp() != nil {
+		return nil
+	}
+	_, err := checkOwnership(desired, latest)
+	return err
+}
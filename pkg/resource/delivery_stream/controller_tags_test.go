@@ -0,0 +1,185 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delivery_stream
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	ackv1alpha1 "github.com/aws-controllers-k8s/runtime/apis/core/v1alpha1"
+	ackerr "github.com/aws-controllers-k8s/runtime/pkg/errors"
+	acktags "github.com/aws-controllers-k8s/runtime/pkg/tags"
+	"github.com/aws/aws-sdk-go/aws"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+	svcconfig "github.com/aws-controllers-k8s/firehose-controller/pkg/config"
+)
+
+func newOwnedResource(uid types.UID, owner string, annotations map[string]string) *resource {
+	r := newHTTPEndpointDestinationResource(&svcapitypes.HTTPEndpointDestinationConfiguration{})
+	r.ko.ObjectMeta = metav1.ObjectMeta{UID: uid, Annotations: annotations}
+	if owner != "" {
+		r.ko.Spec.Tags = []*svcapitypes.Tag{{Key: aws.String(ownerTagKey), Value: aws.String(owner)}}
+	}
+	return r
+}
+
+func TestCheckOwnership(t *testing.T) {
+	defer svcconfig.Set(svcconfig.Get())
+	svcconfig.Set(svcconfig.Config{ClusterID: "cluster"})
+
+	takeOwnership := map[string]string{svcapitypes.TakeOwnershipAnnotation: "true"}
+	tests := []struct {
+		name           string
+		desired        *resource
+		latest         *resource
+		expectTakeOver bool
+		expectErr      bool
+	}{
+		{
+			name:    "owned by the resource",
+			desired: newOwnedResource("uid", "", nil),
+			latest:  newOwnedResource("uid", "cluster/uid", nil),
+		},
+		{
+			name:    "no owner tag",
+			desired: newOwnedResource("uid", "", nil),
+			latest:  newOwnedResource("uid", "", nil),
+		},
+		{
+			name:      "owned by another resource",
+			desired:   newOwnedResource("uid", "", nil),
+			latest:    newOwnedResource("uid", "cluster/other", nil),
+			expectErr: true,
+		},
+		{
+			name:      "owned by another cluster",
+			desired:   newOwnedResource("uid", "", nil),
+			latest:    newOwnedResource("uid", "other/uid", nil),
+			expectErr: true,
+		},
+		{
+			name:           "ownership taken over",
+			desired:        newOwnedResource("uid", "", takeOwnership),
+			latest:         newOwnedResource("uid", "other/uid", nil),
+			expectTakeOver: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			takeOver, err := checkOwnership(tt.desired, tt.latest)
+			if takeOver != tt.expectTakeOver {
+				t.Errorf("expected takeOver %v, got %v", tt.expectTakeOver, takeOver)
+			}
+			if tt.expectErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.expectErr, err)
+			}
+			var terminal *ackerr.TerminalError
+			if err != nil && (!errors.As(err, &terminal) || !errors.Is(err, ErrDeliveryStreamOwnedElsewhere)) {
+				t.Errorf("expected a terminal ownership error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCheckReadOwnership(t *testing.T) {
+	defer svcconfig.Set(svcconfig.Get())
+	svcconfig.Set(svcconfig.Config{ClusterID: "cluster"})
+
+	latest := newOwnedResource("uid", "other/uid", nil)
+	if err := checkReadOwnership(newOwnedResource("uid", "", nil), latest); !errors.Is(err, ErrDeliveryStreamOwnedElsewhere) {
+		t.Errorf("expected an ownership error, got %v", err)
+	}
+	takeOwnership := map[string]string{svcapitypes.TakeOwnershipAnnotation: "true"}
+	if err := checkReadOwnership(newOwnedResource("uid", "", takeOwnership), latest); err != nil {
+		t.Errorf("expected a delivery stream being taken over to be read, got %v", err)
+	}
+	readOnly := map[string]string{ackv1alpha1.AnnotationReadOnly: "true"}
+	if err := checkReadOwnership(newOwnedResource("uid", "", readOnly), latest); err != nil {
+		t.Errorf("expected a read-only resource to read the delivery stream, got %v", err)
+	}
+	deleted := newOwnedResource("uid", "", nil)
+	now := metav1.Now()
+	deleted.ko.DeletionTimestamp = &now
+	if err := checkReadOwnership(deleted, latest); err != nil {
+		t.Errorf("expected a resource being deleted to read the delivery stream, got %v", err)
+	}
+}
+
+func TestControllerTags(t *testing.T) {
+	defer svcconfig.Set(svcconfig.Get())
+	svcconfig.Set(svcconfig.Config{ClusterID: "cluster"})
+
+	r := newOwnedResource("uid", "", nil)
+	tags := acktags.Tags{"team": "data"}
+	if err := setControllerTags(context.TODO(), tags, r.ko); err != nil {
+		t.Fatal(err)
+	}
+	if tags[ownerTagKey] != "cluster/uid" {
+		t.Errorf("expected owner tag %q, got %q", "cluster/uid", tags[ownerTagKey])
+	}
+	ignoreControllerTags(tags)
+	if _, ok := tags[ownerTagKey]; ok || tags["team"] != "data" {
+		t.Errorf("expected only the owner tag to be ignored, got %v", tags)
+	}
+
+	tags = acktags.Tags{}
+	if err := setControllerTags(context.TODO(), tags, newOwnedResource("", "", nil).ko); err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 0 {
+		t.Errorf("expected no owner tag for a resource without UID, got %v", tags)
+	}
+}
+
+func TestPropagateLabels(t *testing.T) {
+	defer svcconfig.Set(svcconfig.Get())
+	svcconfig.Set(svcconfig.Config{PropagateLabelKeys: []string{"team", "env", "cost-center"}})
+	defer SetAPIReader(getAPIReader())
+	SetAPIReader(fake.NewClientBuilder().WithObjects(&corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "data",
+			Labels: map[string]string{"team": "data", "env": "prod", "tier": "backend"},
+		},
+	}).Build())
+
+	r := newOwnedResource("", "", nil)
+	r.ko.Namespace = "data"
+	r.ko.Labels = map[string]string{"env": "dev", "app": "ingest"}
+	tags := acktags.Tags{"team": "spec", "cost-center": "default", "owner": "spec"}
+	if err := setControllerTags(context.TODO(), tags, r.ko); err != nil {
+		t.Fatal(err)
+	}
+	expected := acktags.Tags{"team": "data", "env": "dev", "cost-center": "default", "owner": "spec"}
+	if !reflect.DeepEqual(tags, expected) {
+		t.Errorf("expected tags %v, got %v", expected, tags)
+	}
+
+	ignoreControllerTags(tags)
+	if expected := (acktags.Tags{"owner": "spec"}); !reflect.DeepEqual(tags, expected) {
+		t.Errorf("expected propagated tags to be ignored, got %v", tags)
+	}
+
+	r.ko.Namespace = "missing"
+	if err := setControllerTags(context.TODO(), acktags.Tags{}, r.ko); err == nil {
+		t.Error("expected an error for a missing namespace")
+	}
+}
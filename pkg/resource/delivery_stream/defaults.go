@@ -0,0 +1,181 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delivery_stream
+
+import (
+	"reflect"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"k8s.io/apimachinery/pkg/api/equality"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+	svcsdktypes "github.com/aws/aws-sdk-go-v2/service/firehose/types"
+)
+
+// s3DestinationDefaults contains the values Firehose uses for an S3
+// destination configuration when they are not specified.
+var s3DestinationDefaults = &svcapitypes.S3DestinationConfiguration{
+	BufferingHints: &svcapitypes.BufferingHints{
+		IntervalInSeconds: aws.Int64(300),
+		SizeInMBs:         aws.Int64(5),
+	},
+	CloudWatchLoggingOptions: &svcapitypes.CloudWatchLoggingOptions{
+		Enabled: aws.Bool(false),
+	},
+	CompressionFormat: aws.String(string(svcsdktypes.CompressionFormatUncompressed)),
+	EncryptionConfiguration: &svcapitypes.EncryptionConfiguration{
+		NoEncryptionConfig: aws.String(string(svcsdktypes.NoEncryptionConfigNoEncryption)),
+	},
+}
+
+// destinationDefaults contains, keyed by the name of the destination
+// configuration field in DeliveryStreamSpec, the values Firehose uses for
+// that destination when they are not specified. Only non-nil fields have a
+// service default.
+var destinationDefaults = map[string]any{
+	"HTTPEndpointDestinationConfiguration": &svcapitypes.HTTPEndpointDestinationConfiguration{
+		BufferingHints: &svcapitypes.HTTPEndpointBufferingHints{
+			IntervalInSeconds: aws.Int64(300),
+			SizeInMBs:         aws.Int64(5),
+		},
+		CloudWatchLoggingOptions: &svcapitypes.CloudWatchLoggingOptions{
+			Enabled: aws.Bool(false),
+		},
+		ProcessingConfiguration: &svcapitypes.ProcessingConfiguration{
+			Enabled: aws.Bool(false),
+		},
+		RequestConfiguration: &svcapitypes.HTTPEndpointRequestConfiguration{
+			ContentEncoding: aws.String(string(svcsdktypes.ContentEncodingNone)),
+		},
+		RetryOptions: &svcapitypes.HTTPEndpointRetryOptions{
+			DurationInSeconds: aws.Int64(300),
+		},
+		S3BackupMode:    aws.String(string(svcsdktypes.HttpEndpointS3BackupModeFailedDataOnly)),
+		S3Configuration: s3DestinationDefaults,
+	},
+}
+
+// equalWithDefaults returns true if a and b are equal once the unset fields
+// of both are replaced by the service defaults in d. d may be an invalid
+// value, in which case no field has a default.
+func equalWithDefaults(a, b, d reflect.Value) bool {
+	if a.Kind() == reflect.Ptr {
+		if d.IsValid() && d.IsNil() {
+			d = reflect.Value{}
+		}
+		switch {
+		case a.IsNil() && b.IsNil():
+			return true
+		case a.IsNil():
+			return isDefault(b, d)
+		case b.IsNil():
+			return isDefault(a, d)
+		}
+		a, b = a.Elem(), b.Elem()
+		if d.IsValid() {
+			d = d.Elem()
+		}
+	}
+	if a.Kind() != reflect.Struct {
+		return equality.Semantic.DeepEqual(a.Interface(), b.Interface())
+	}
+	for i := 0; i < a.NumField(); i++ {
+		var df reflect.Value
+		if d.IsValid() {
+			df = d.Field(i)
+		}
+		if !equalWithDefaults(a.Field(i), b.Field(i), df) {
+			return false
+		}
+	}
+	return true
+}
+
+// isDefault returns true if every field set in v holds the service default
+// found in d.
+func isDefault(v, d reflect.Value) bool {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return true
+		}
+		if !d.IsValid() || d.IsNil() {
+			return false
+		}
+		v, d = v.Elem(), d.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		if v.IsZero() {
+			return true
+		}
+		return d.IsValid() && equality.Semantic.DeepEqual(v.Interface(), d.Interface())
+	}
+	for i := 0; i < v.NumField(); i++ {
+		var df reflect.Value
+		if d.IsValid() {
+			df = d.Field(i)
+		}
+		if !isDefault(v.Field(i), df) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeDefaults walks the struct pointers a and b and, for every field
+// whose values only differ by being unset on one side and set to the service
+// default on the other, copies the set value into the unset side. Fields are
+// normalized at every nesting level so that the remaining differences point
+// at the innermost field that actually differs.
+func normalizeDefaults(a, b, d reflect.Value) {
+	if a.IsNil() || b.IsNil() {
+		return
+	}
+	a, b, d = a.Elem(), b.Elem(), d.Elem()
+	for i := 0; i < a.NumField(); i++ {
+		af, bf, df := a.Field(i), b.Field(i), d.Field(i)
+		if af.Kind() != reflect.Ptr || df.IsNil() {
+			continue
+		}
+		if !equalWithDefaults(af, bf, df) {
+			if df.Elem().Kind() == reflect.Struct {
+				normalizeDefaults(af, bf, df)
+			}
+			continue
+		}
+		switch {
+		case af.IsNil():
+			af.Set(bf)
+		case bf.IsNil():
+			bf.Set(af)
+		case df.Elem().Kind() == reflect.Struct:
+			normalizeDefaults(af, bf, df)
+		}
+	}
+}
+
+// normalizeDestinationDefaults makes the destination configurations of a and
+// b match wherever one of them leaves a field unset and the other sets it to
+// the value Firehose uses by default. Both resources are modified and may
+// share values afterwards, they must be copies made for the comparison.
+func normalizeDestinationDefaults(a, b *resource) {
+	specA := reflect.ValueOf(&a.ko.Spec).Elem()
+	specB := reflect.ValueOf(&b.ko.Spec).Elem()
+	for field, defaults := range destinationDefaults {
+		normalizeDefaults(
+			specA.FieldByName(field),
+			specB.FieldByName(field),
+			reflect.ValueOf(defaults),
+		)
+	}
+}
@@ -16,8 +16,13 @@ package delivery_stream
 import (
 	"testing"
 
-	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+	ackv1alpha1 "github.com/aws-controllers-k8s/runtime/apis/core/v1alpha1"
 	"github.com/aws/aws-sdk-go/aws"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+	"github.com/aws-controllers-k8s/firehose-controller/pkg/resource/tags"
 )
 
 func TestDeliveryStreamEncryptionConfigurationComparison(t *testing.T) {
@@ -200,3 +205,520 @@ func TestDeliveryStreamEncryptionConfigurationComparison(t *testing.T) {
 		})
 	}
 }
+
+func newProcessingConfigurationResource(processors ...*svcapitypes.Processor) *resource {
+	return &resource{
+		ko: &svcapitypes.DeliveryStream{
+			Spec: svcapitypes.DeliveryStreamSpec{
+				HTTPEndpointDestinationConfiguration: &svcapitypes.HTTPEndpointDestinationConfiguration{
+					ProcessingConfiguration: &svcapitypes.ProcessingConfiguration{
+						Enabled:    aws.Bool(true),
+						Processors: processors,
+					},
+				},
+			},
+		},
+	}
+}
+
+func newProcessor(processorType string, params ...string) *svcapitypes.Processor {
+	p := &svcapitypes.Processor{Type: aws.String(processorType)}
+	for i := 0; i+1 < len(params); i += 2 {
+		p.Parameters = append(p.Parameters, &svcapitypes.ProcessorParameter{
+			ParameterName:  aws.String(params[i]),
+			ParameterValue: aws.String(params[i+1]),
+		})
+	}
+	return p
+}
+
+func TestProcessingConfigurationProcessorsComparison(t *testing.T) {
+	lambdaARN := "arn:aws:lambda:us-east-1:123456789012:function:transform"
+	tests := []struct {
+		name     string
+		a        *resource
+		b        *resource
+		expected bool // true if difference expected
+	}{
+		{
+			name: "same parameters in a different order expects no difference",
+			a: newProcessingConfigurationResource(
+				newProcessor("Lambda", "LambdaArn", lambdaARN, "NumberOfRetries", "5"),
+			),
+			b: newProcessingConfigurationResource(
+				newProcessor("Lambda", "NumberOfRetries", "5", "LambdaArn", lambdaARN),
+			),
+			expected: false,
+		},
+		{
+			name: "same processors in a different order has difference",
+			a: newProcessingConfigurationResource(
+				newProcessor("RecordDeAggregation", "SubRecordType", "JSON"),
+				newProcessor("AppendDelimiterToRecord"),
+			),
+			b: newProcessingConfigurationResource(
+				newProcessor("AppendDelimiterToRecord"),
+				newProcessor("RecordDeAggregation", "SubRecordType", "JSON"),
+			),
+			expected: true,
+		},
+		{
+			name: "service injected Lambda defaults expects no difference",
+			a: newProcessingConfigurationResource(
+				newProcessor("Lambda", "LambdaArn", lambdaARN),
+			),
+			b: newProcessingConfigurationResource(
+				newProcessor("Lambda", "BufferSizeInMBs", "1", "LambdaArn", lambdaARN, "NumberOfRetries", "3", "BufferIntervalInSeconds", "60"),
+			),
+			expected: false,
+		},
+		{
+			name: "injected parameter with a non-default value has difference",
+			a: newProcessingConfigurationResource(
+				newProcessor("Lambda", "LambdaArn", lambdaARN),
+			),
+			b: newProcessingConfigurationResource(
+				newProcessor("Lambda", "LambdaArn", lambdaARN, "NumberOfRetries", "1"),
+			),
+			expected: true,
+		},
+		{
+			name: "user specified parameter differing from the live value has difference",
+			a: newProcessingConfigurationResource(
+				newProcessor("Lambda", "LambdaArn", lambdaARN, "NumberOfRetries", "5"),
+			),
+			b: newProcessingConfigurationResource(
+				newProcessor("Lambda", "LambdaArn", lambdaARN, "NumberOfRetries", "3"),
+			),
+			expected: true,
+		},
+		{
+			name: "different processor types has difference",
+			a: newProcessingConfigurationResource(
+				newProcessor("Lambda", "LambdaArn", lambdaARN),
+			),
+			b: newProcessingConfigurationResource(
+				newProcessor("AppendDelimiterToRecord"),
+			),
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delta := newResourceDelta(tt.a, tt.b)
+			hasDifference := delta.DifferentAt("Spec.HTTPEndpointDestinationConfiguration.ProcessingConfiguration")
+
+			if hasDifference != tt.expected {
+				t.Errorf("Expected difference: %v, got: %v", tt.expected, hasDifference)
+			}
+		})
+	}
+}
+
+func newCommonAttributesResource(attributes ...string) *resource {
+	requestConfiguration := &svcapitypes.HTTPEndpointRequestConfiguration{}
+	for i := 0; i+1 < len(attributes); i += 2 {
+		requestConfiguration.CommonAttributes = append(requestConfiguration.CommonAttributes, &svcapitypes.HTTPEndpointCommonAttribute{
+			AttributeName:  aws.String(attributes[i]),
+			AttributeValue: aws.String(attributes[i+1]),
+		})
+	}
+	return &resource{
+		ko: &svcapitypes.DeliveryStream{
+			Spec: svcapitypes.DeliveryStreamSpec{
+				HTTPEndpointDestinationConfiguration: &svcapitypes.HTTPEndpointDestinationConfiguration{
+					RequestConfiguration: requestConfiguration,
+				},
+			},
+		},
+	}
+}
+
+func TestHTTPEndpointCommonAttributesComparison(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        *resource
+		b        *resource
+		expected bool // true if difference expected
+	}{
+		{
+			name:     "same attributes in the same order expects no difference",
+			a:        newCommonAttributesResource("env", "prod", "team", "data"),
+			b:        newCommonAttributesResource("env", "prod", "team", "data"),
+			expected: false,
+		},
+		{
+			name:     "same attributes in a different order expects no difference",
+			a:        newCommonAttributesResource("env", "prod", "team", "data"),
+			b:        newCommonAttributesResource("team", "data", "env", "prod"),
+			expected: false,
+		},
+		{
+			name:     "different attribute value has difference",
+			a:        newCommonAttributesResource("env", "prod", "team", "data"),
+			b:        newCommonAttributesResource("team", "data", "env", "dev"),
+			expected: true,
+		},
+		{
+			name:     "missing attribute has difference",
+			a:        newCommonAttributesResource("env", "prod", "team", "data"),
+			b:        newCommonAttributesResource("env", "prod"),
+			expected: true,
+		},
+		{
+			name:     "duplicate attribute names are compared in order",
+			a:        newCommonAttributesResource("env", "prod", "env", "prod"),
+			b:        newCommonAttributesResource("env", "prod", "team", "data"),
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delta := newResourceDelta(tt.a, tt.b)
+			hasDifference := delta.DifferentAt("Spec.HTTPEndpointDestinationConfiguration.RequestConfiguration.CommonAttributes")
+
+			if hasDifference != tt.expected {
+				t.Errorf("Expected difference: %v, got: %v", tt.expected, hasDifference)
+			}
+		})
+	}
+}
+
+func newHTTPEndpointDestinationResource(config *svcapitypes.HTTPEndpointDestinationConfiguration) *resource {
+	return &resource{
+		ko: &svcapitypes.DeliveryStream{
+			Spec: svcapitypes.DeliveryStreamSpec{
+				HTTPEndpointDestinationConfiguration: config,
+			},
+		},
+	}
+}
+
+// describedHTTPEndpointDestination returns the destination configuration
+// DescribeDeliveryStream reports when only the endpoint URL, role and bucket
+// were specified.
+func describedHTTPEndpointDestination() *svcapitypes.HTTPEndpointDestinationConfiguration {
+	return &svcapitypes.HTTPEndpointDestinationConfiguration{
+		BufferingHints: &svcapitypes.HTTPEndpointBufferingHints{
+			IntervalInSeconds: aws.Int64(300),
+			SizeInMBs:         aws.Int64(5),
+		},
+		CloudWatchLoggingOptions: &svcapitypes.CloudWatchLoggingOptions{
+			Enabled: aws.Bool(false),
+		},
+		EndpointConfiguration: &svcapitypes.HTTPEndpointConfiguration{
+			URL: aws.String("https://example.com"),
+		},
+		ProcessingConfiguration: &svcapitypes.ProcessingConfiguration{
+			Enabled: aws.Bool(false),
+		},
+		RequestConfiguration: &svcapitypes.HTTPEndpointRequestConfiguration{
+			ContentEncoding: aws.String("NONE"),
+		},
+		RetryOptions: &svcapitypes.HTTPEndpointRetryOptions{
+			DurationInSeconds: aws.Int64(300),
+		},
+		RoleARN:      aws.String("arn:aws:iam::123456789012:role/firehose"),
+		S3BackupMode: aws.String("FailedDataOnly"),
+		S3Configuration: &svcapitypes.S3DestinationConfiguration{
+			BucketARN: aws.String("arn:aws:s3:::bucket"),
+			BufferingHints: &svcapitypes.BufferingHints{
+				IntervalInSeconds: aws.Int64(300),
+				SizeInMBs:         aws.Int64(5),
+			},
+			CloudWatchLoggingOptions: &svcapitypes.CloudWatchLoggingOptions{
+				Enabled: aws.Bool(false),
+			},
+			CompressionFormat: aws.String("UNCOMPRESSED"),
+			EncryptionConfiguration: &svcapitypes.EncryptionConfiguration{
+				NoEncryptionConfig: aws.String("NoEncryption"),
+			},
+			RoleARN: aws.String("arn:aws:iam::123456789012:role/firehose"),
+		},
+	}
+}
+
+// minimalHTTPEndpointDestination returns a destination configuration that
+// leaves every field with a service default unset.
+func minimalHTTPEndpointDestination() *svcapitypes.HTTPEndpointDestinationConfiguration {
+	return &svcapitypes.HTTPEndpointDestinationConfiguration{
+		EndpointConfiguration: &svcapitypes.HTTPEndpointConfiguration{
+			URL: aws.String("https://example.com"),
+		},
+		RoleARN: aws.String("arn:aws:iam::123456789012:role/firehose"),
+		S3Configuration: &svcapitypes.S3DestinationConfiguration{
+			BucketARN: aws.String("arn:aws:s3:::bucket"),
+			RoleARN:   aws.String("arn:aws:iam::123456789012:role/firehose"),
+		},
+	}
+}
+
+func TestDestinationDefaultsComparison(t *testing.T) {
+	tests := []struct {
+		name        string
+		a           func() *svcapitypes.HTTPEndpointDestinationConfiguration
+		b           func() *svcapitypes.HTTPEndpointDestinationConfiguration
+		expectPaths []string // paths expected to differ, none if empty
+	}{
+		{
+			name: "unset fields and service defaults expects no difference",
+			a:    minimalHTTPEndpointDestination,
+			b:    describedHTTPEndpointDestination,
+		},
+		{
+			name: "service defaults set on both sides expects no difference",
+			a:    describedHTTPEndpointDestination,
+			b:    describedHTTPEndpointDestination,
+		},
+		{
+			name: "default set in spec and missing from latest expects no difference",
+			a:    describedHTTPEndpointDestination,
+			b:    minimalHTTPEndpointDestination,
+		},
+		{
+			name: "partially set nested struct expects no difference",
+			a: func() *svcapitypes.HTTPEndpointDestinationConfiguration {
+				c := minimalHTTPEndpointDestination()
+				c.BufferingHints = &svcapitypes.HTTPEndpointBufferingHints{
+					SizeInMBs: aws.Int64(5),
+				}
+				c.S3Configuration.BufferingHints = &svcapitypes.BufferingHints{
+					IntervalInSeconds: aws.Int64(300),
+				}
+				return c
+			},
+			b: describedHTTPEndpointDestination,
+		},
+		{
+			name: "non default value has difference at the nested field",
+			a: func() *svcapitypes.HTTPEndpointDestinationConfiguration {
+				c := minimalHTTPEndpointDestination()
+				c.BufferingHints = &svcapitypes.HTTPEndpointBufferingHints{
+					SizeInMBs: aws.Int64(10),
+				}
+				return c
+			},
+			b: describedHTTPEndpointDestination,
+			expectPaths: []string{
+				"Spec.HTTPEndpointDestinationConfiguration.BufferingHints.SizeInMBs",
+			},
+		},
+		{
+			name: "non default nested S3 value has difference",
+			a: func() *svcapitypes.HTTPEndpointDestinationConfiguration {
+				c := minimalHTTPEndpointDestination()
+				c.S3Configuration.CompressionFormat = aws.String("GZIP")
+				return c
+			},
+			b: describedHTTPEndpointDestination,
+			expectPaths: []string{
+				"Spec.HTTPEndpointDestinationConfiguration.S3Configuration.CompressionFormat",
+			},
+		},
+		{
+			name: "live value drifted from the default has difference",
+			a:    minimalHTTPEndpointDestination,
+			b: func() *svcapitypes.HTTPEndpointDestinationConfiguration {
+				c := describedHTTPEndpointDestination()
+				c.RetryOptions.DurationInSeconds = aws.Int64(60)
+				return c
+			},
+			expectPaths: []string{
+				"Spec.HTTPEndpointDestinationConfiguration.RetryOptions",
+			},
+		},
+		{
+			name: "enabled CloudWatch logging has difference",
+			a: func() *svcapitypes.HTTPEndpointDestinationConfiguration {
+				c := minimalHTTPEndpointDestination()
+				c.CloudWatchLoggingOptions = &svcapitypes.CloudWatchLoggingOptions{
+					Enabled:      aws.Bool(true),
+					LogGroupName: aws.String("firehose"),
+				}
+				return c
+			},
+			b: describedHTTPEndpointDestination,
+			expectPaths: []string{
+				"Spec.HTTPEndpointDestinationConfiguration.CloudWatchLoggingOptions.Enabled",
+				"Spec.HTTPEndpointDestinationConfiguration.CloudWatchLoggingOptions.LogGroupName",
+			},
+		},
+		{
+			name: "field without a service default has difference",
+			a:    minimalHTTPEndpointDestination,
+			b: func() *svcapitypes.HTTPEndpointDestinationConfiguration {
+				c := describedHTTPEndpointDestination()
+				c.S3Configuration.Prefix = aws.String("logs/")
+				return c
+			},
+			expectPaths: []string{
+				"Spec.HTTPEndpointDestinationConfiguration.S3Configuration.Prefix",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delta := newResourceDelta(
+				newHTTPEndpointDestinationResource(tt.a()),
+				newHTTPEndpointDestinationResource(tt.b()),
+			)
+			if len(delta.Differences) != len(tt.expectPaths) {
+				for _, diff := range delta.Differences {
+					t.Logf("difference at %s", differencePath(diff))
+				}
+				t.Fatalf("Expected %d differences, got: %d", len(tt.expectPaths), len(delta.Differences))
+			}
+			for _, path := range tt.expectPaths {
+				if !delta.DifferentAt(path) {
+					t.Errorf("Expected difference at %s", path)
+				}
+			}
+		})
+	}
+}
+
+func TestResourceDeltaLeavesResourcesUnchanged(t *testing.T) {
+	lambdaARN := "arn:aws:lambda:us-east-1:123456789012:function:transform"
+	a := newHTTPEndpointDestinationResource(minimalHTTPEndpointDestination())
+	a.ko.Spec.HTTPEndpointDestinationConfiguration.ProcessingConfiguration = &svcapitypes.ProcessingConfiguration{
+		Enabled:    aws.Bool(true),
+		Processors: []*svcapitypes.Processor{newProcessor("Lambda", "LambdaArn", lambdaARN)},
+	}
+	a.ko.Spec.HTTPEndpointDestinationConfiguration.RequestConfiguration = &svcapitypes.HTTPEndpointRequestConfiguration{
+		CommonAttributes: []*svcapitypes.HTTPEndpointCommonAttribute{
+			{AttributeName: aws.String("env"), AttributeValue: aws.String("prod")},
+			{AttributeName: aws.String("team"), AttributeValue: aws.String("data")},
+		},
+	}
+	b := newHTTPEndpointDestinationResource(describedHTTPEndpointDestination())
+	b.ko.Spec.HTTPEndpointDestinationConfiguration.ProcessingConfiguration = &svcapitypes.ProcessingConfiguration{
+		Enabled:    aws.Bool(true),
+		Processors: []*svcapitypes.Processor{newProcessor("Lambda", "NumberOfRetries", "3", "LambdaArn", lambdaARN)},
+	}
+	b.ko.Spec.HTTPEndpointDestinationConfiguration.RequestConfiguration = &svcapitypes.HTTPEndpointRequestConfiguration{
+		CommonAttributes: []*svcapitypes.HTTPEndpointCommonAttribute{
+			{AttributeName: aws.String("team"), AttributeValue: aws.String("data")},
+			{AttributeName: aws.String("env"), AttributeValue: aws.String("prod")},
+		},
+		ContentEncoding: aws.String("NONE"),
+	}
+	wantA, wantB := a.ko.DeepCopy(), b.ko.DeepCopy()
+
+	if delta := newResourceDelta(a, b); len(delta.Differences) != 0 {
+		for _, diff := range delta.Differences {
+			t.Errorf("difference at %s", differencePath(diff))
+		}
+	}
+	if !equality.Semantic.DeepEqual(a.ko, wantA) {
+		t.Error("desired resource was modified")
+	}
+	if !equality.Semantic.DeepEqual(b.ko, wantB) {
+		t.Error("latest resource was modified")
+	}
+	if a.ko.Spec.HTTPEndpointDestinationConfiguration.BufferingHints != nil {
+		t.Error("BufferingHints of the desired resource was set from the latest resource")
+	}
+}
+
+func newAccessKeyResource(hash string) *resource {
+	r := newHTTPEndpointDestinationResource(minimalHTTPEndpointDestination())
+	r.ko.Spec.HTTPEndpointDestinationConfiguration.EndpointConfiguration.AccessKey = &ackv1alpha1.SecretKeyReference{
+		SecretReference: corev1.SecretReference{Name: "endpoint"},
+		Key:             "accessKey",
+	}
+	if hash != "" {
+		r.ko.Annotations = map[string]string{svcapitypes.AccessKeyHashAnnotation: hash}
+	}
+	return r
+}
+
+func TestAccessKeyHashComparison(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        *resource
+		b        *resource
+		expected bool // true if difference expected
+	}{
+		{
+			name:     "same hash expects no difference",
+			a:        newAccessKeyResource("abc"),
+			b:        newAccessKeyResource("abc"),
+			expected: false,
+		},
+		{
+			name:     "rotated secret has difference",
+			a:        newAccessKeyResource("abc"),
+			b:        newAccessKeyResource("def"),
+			expected: true,
+		},
+		{
+			name:     "hash never recorded has difference",
+			a:        newAccessKeyResource(""),
+			b:        newAccessKeyResource("def"),
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delta := newResourceDelta(tt.a, tt.b)
+			hasDifference := delta.DifferentAt(accessKeyPath)
+
+			if hasDifference != tt.expected {
+				t.Errorf("Expected difference: %v, got: %v", tt.expected, hasDifference)
+			}
+		})
+	}
+}
+
+func newTaggedResource(hash string, tags ...*svcapitypes.Tag) *resource {
+	r := newHTTPEndpointDestinationResource(minimalHTTPEndpointDestination())
+	r.ko.Spec.Tags = tags
+	if hash != "" {
+		r.ko.Annotations = map[string]string{svcapitypes.TagsHashAnnotation: hash}
+	}
+	return r
+}
+
+func TestTagsComparison(t *testing.T) {
+	team := &svcapitypes.Tag{Key: aws.String("team"), Value: aws.String("data")}
+	stack := &svcapitypes.Tag{Key: aws.String("aws:cloudformation:stack-name"), Value: aws.String("stack")}
+	synced := tags.Hash([]*svcapitypes.Tag{team})
+	tests := []struct {
+		name     string
+		a        *resource
+		b        *resource
+		expected bool // true if difference expected
+	}{
+		{
+			name:     "tags unchanged since synced expect no difference",
+			a:        newTaggedResource(synced, team),
+			b:        newTaggedResource(synced, team, stack),
+			expected: false,
+		},
+		{
+			name:     "tags never synced are compared",
+			a:        newTaggedResource("", team),
+			b:        newTaggedResource(synced, team, stack),
+			expected: true,
+		},
+		{
+			name:     "tags changed on the delivery stream have difference",
+			a:        newTaggedResource(synced, team),
+			b:        newTaggedResource(synced),
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delta := newResourceDelta(tt.a, tt.b)
+			if hasDifference := delta.DifferentAt("Spec.Tags"); hasDifference != tt.expected {
+				t.Errorf("Expected difference: %v, got: %v", tt.expected, hasDifference)
+			}
+		})
+	}
+}
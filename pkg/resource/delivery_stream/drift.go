@@ -0,0 +1,264 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delivery_stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	ackcompare "github.com/aws-controllers-k8s/runtime/pkg/compare"
+	ackcondition "github.com/aws-controllers-k8s/runtime/pkg/condition"
+	ackerr "github.com/aws-controllers-k8s/runtime/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+	svcconfig "github.com/aws-controllers-k8s/firehose-controller/pkg/config"
+)
+
+const (
+	driftDetectedReason = "DriftDetected"
+)
+
+// driftPolicy returns the drift policy for the supplied resource. The
+// drift-policy annotation takes precedence over the controller-wide default.
+func driftPolicy(r *resource) (svcapitypes.DriftPolicy, error) {
+	policy := svcapitypes.DriftPolicy(svcconfig.Get().DriftPolicy)
+	if v, ok := r.ko.GetAnnotations()[svcapitypes.DriftPolicyAnnotation]; ok {
+		policy = svcapitypes.DriftPolicy(v)
+	}
+	if !slices.Contains(svcapitypes.DriftPolicies, policy) {
+		return "", fmt.Errorf(
+			"invalid value for annotation %s: %q, must be one of %v",
+			svcapitypes.DriftPolicyAnnotation, policy, svcapitypes.DriftPolicies,
+		)
+	}
+	return policy, nil
+}
+
+// lastAppliedSpec decodes the spec recorded in the last-applied-spec
+// annotation. It returns nil if the annotation is missing or unreadable, as
+// for delivery streams adopted or created by an earlier version of the
+// controller, in which case no difference can be attributed to a change to
+// the custom resource and every difference is treated as drift.
+func lastAppliedSpec(r *resource) *svcapitypes.DeliveryStreamSpec {
+	v, ok := r.ko.GetAnnotations()[svcapitypes.LastAppliedSpecAnnotation]
+	if !ok {
+		return nil
+	}
+	spec := &svcapitypes.DeliveryStreamSpec{}
+	if err := json.Unmarshal([]byte(v), spec); err != nil {
+		return nil
+	}
+	return spec
+}
+
+// setLastAppliedSpec records the spec of the supplied custom resource in the
+// last-applied-spec annotation.
+func setLastAppliedSpec(ko *svcapitypes.DeliveryStream) {
+	lastApplied := ko.Spec.DeepCopy()
+	// Tags are reconciled separately and may carry values that are expanded
+	// by the controller on every reconcile.
+	lastApplied.Tags = nil
+	b, err := json.Marshal(lastApplied)
+	if err != nil {
+		return
+	}
+	annotations := ko.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[svcapitypes.LastAppliedSpecAnnotation] = string(b)
+	ko.SetAnnotations(annotations)
+}
+
+// specPaths lists the dotted paths of the fields of a DeliveryStreamSpec,
+// from Spec down to the fields that aren't structs, as named in a delta.
+var specPaths = fieldPaths("Spec", reflect.TypeOf(svcapitypes.DeliveryStreamSpec{}))
+
+// fieldPaths returns prefix and the dotted paths of the fields of t, and of
+// their fields, under prefix.
+func fieldPaths(prefix string, t reflect.Type) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	paths := []string{prefix}
+	if t.Kind() != reflect.Struct {
+		return paths
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); f.IsExported() {
+			paths = append(paths, fieldPaths(prefix+"."+f.Name, f.Type)...)
+		}
+	}
+	return paths
+}
+
+// differencePath returns the dotted representation of a Difference's path,
+// or an empty string if it is not the path of a spec field.
+func differencePath(diff *ackcompare.Difference) string {
+	path := ""
+	for _, p := range specPaths {
+		if len(p) > len(path) && diff.Path.Contains(p) {
+			path = p
+		}
+	}
+	return path
+}
+
+// driftedPaths returns the paths in delta that differ between the live
+// delivery stream and the desired spec although the desired spec has not
+// changed since it was last applied. An empty result means every difference
+// was introduced by a change to the custom resource. Without a last applied
+// spec every difference is returned, so that the ReportOnly and AdoptLive
+// policies are honoured until the annotation is first recorded.
+func driftedPaths(desired *resource, delta *ackcompare.Delta) []string {
+	var changed *ackcompare.Delta
+	if lastApplied := lastAppliedSpec(desired); lastApplied != nil {
+		applied := desired.ko.DeepCopy()
+		applied.Spec = *lastApplied
+		applied.Spec.Tags = desired.ko.Spec.Tags
+		changed = newResourceDelta(&resource{desired.ko.DeepCopy()}, &resource{applied})
+	} else {
+		changed = ackcompare.NewDelta()
+	}
+
+	drifted := []string{}
+	for _, diff := range delta.Differences {
+		path := differencePath(diff)
+		// Tags are not part of the last applied spec and are always
+		// enforced. A different access key hash means the referenced Secret
+		// was rotated, which is a change made by the user.
+		if path == "" || path == "Spec.Tags" || path == accessKeyPath {
+			continue
+		}
+		if changed.DifferentAt(path) {
+			continue
+		}
+		userChanged := false
+		for _, c := range changed.Differences {
+			if diff.Path.Contains(differencePath(c)) {
+				userChanged = true
+				break
+			}
+		}
+		if !userChanged {
+			drifted = append(drifted, path)
+		}
+	}
+	return drifted
+}
+
+// deltaWithout returns a copy of delta without the differences found at the
+// supplied paths.
+func deltaWithout(delta *ackcompare.Delta, paths []string) *ackcompare.Delta {
+	filtered := ackcompare.NewDelta()
+	for _, diff := range delta.Differences {
+		if !slices.Contains(paths, differencePath(diff)) {
+			filtered.Differences = append(filtered.Differences, diff)
+		}
+	}
+	return filtered
+}
+
+// copyFieldAtPath copies the value found at the dotted path in src into dst.
+// Missing intermediate structs are allocated in dst and a nil intermediate
+// pointer in src clears the matching field in dst.
+func copyFieldAtPath(dst, src reflect.Value, parts []string) {
+	for dst.Kind() == reflect.Ptr {
+		if src.IsNil() {
+			dst.Set(reflect.Zero(dst.Type()))
+			return
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		dst = dst.Elem()
+		src = src.Elem()
+	}
+	if len(parts) == 0 {
+		dst.Set(src)
+		return
+	}
+	dstField := dst.FieldByName(parts[0])
+	srcField := src.FieldByName(parts[0])
+	if !dstField.IsValid() || !srcField.IsValid() {
+		return
+	}
+	if len(parts) == 1 {
+		dstField.Set(srcField)
+		return
+	}
+	copyFieldAtPath(dstField, srcField, parts[1:])
+}
+
+// adoptLiveValues returns a copy of desired where the values found at the
+// drifted paths are replaced by the values observed in latest.
+func adoptLiveValues(desired, latest *resource, paths []string) *resource {
+	ko := desired.ko.DeepCopy()
+	observed := latest.ko.DeepCopy()
+	for _, path := range paths {
+		copyFieldAtPath(
+			reflect.ValueOf(ko).Elem(),
+			reflect.ValueOf(observed).Elem(),
+			strings.Split(path, "."),
+		)
+	}
+	return &resource{ko}
+}
+
+// handleDrift applies the drift policy of the desired resource. It returns
+// the resource that sdkUpdate should continue with, the resource the update
+// request should be built from and the delta of the changes to apply.
+//
+// With the ReportOnly policy, drifted fields are dropped from the delta and
+// the request is built with their live values, so that only the changes
+// made to the custom resource are applied. The returned resource keeps the
+// desired spec and carries a ResourceSynced=False condition naming the
+// drifted fields. With the AdoptLive policy drifted fields are copied from
+// latest into the returned resource and dropped from the returned delta. In
+// every case the returned resource is annotated with the spec about to be
+// applied.
+func handleDrift(
+	desired *resource,
+	latest *resource,
+	delta *ackcompare.Delta,
+) (applied *resource, request *resource, _ *ackcompare.Delta, err error) {
+	policy, err := driftPolicy(desired)
+	if err != nil {
+		return desired, desired, delta, ackerr.NewTerminalError(err)
+	}
+	if drifted := driftedPaths(desired, delta); len(drifted) > 0 {
+		switch policy {
+		case svcapitypes.DriftPolicyReportOnly:
+			applied = &resource{desired.ko.DeepCopy()}
+			setLastAppliedSpec(applied.ko)
+			msg := fmt.Sprintf(
+				"delivery stream has drifted from the last applied spec at %s, not reverting because of %s policy",
+				strings.Join(drifted, ", "), policy,
+			)
+			reason := driftDetectedReason
+			ackcondition.SetSynced(applied, corev1.ConditionFalse, &msg, &reason)
+			return applied, adoptLiveValues(applied, latest, drifted), deltaWithout(delta, drifted), nil
+		case svcapitypes.DriftPolicyAdoptLive:
+			desired = adoptLiveValues(desired, latest, drifted)
+			delta = deltaWithout(delta, drifted)
+		}
+	}
+	applied = &resource{desired.ko.DeepCopy()}
+	setLastAppliedSpec(applied.ko)
+	return applied, applied, delta, nil
+}
@@ -0,0 +1,182 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delivery_stream
+
+import (
+	"testing"
+
+	ackcondition "github.com/aws-controllers-k8s/runtime/pkg/condition"
+	"github.com/aws/aws-sdk-go/aws"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+)
+
+func newDriftTestResource(annotations map[string]string, sizeInMBs int64, url string) *resource {
+	return &resource{
+		ko: &svcapitypes.DeliveryStream{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: annotations,
+			},
+			Spec: svcapitypes.DeliveryStreamSpec{
+				DeliveryStreamName: aws.String("stream"),
+				HTTPEndpointDestinationConfiguration: &svcapitypes.HTTPEndpointDestinationConfiguration{
+					BufferingHints: &svcapitypes.HTTPEndpointBufferingHints{
+						IntervalInSeconds: aws.Int64(60),
+						SizeInMBs:         aws.Int64(sizeInMBs),
+					},
+					EndpointConfiguration: &svcapitypes.HTTPEndpointConfiguration{
+						URL: aws.String(url),
+					},
+				},
+			},
+		},
+	}
+}
+
+// lastApplied returns annotations recording the spec of r as last applied.
+func lastApplied(r *resource, annotations map[string]string) map[string]string {
+	ko := r.ko.DeepCopy()
+	ko.Annotations = annotations
+	setLastAppliedSpec(ko)
+	return ko.Annotations
+}
+
+func TestHandleDrift(t *testing.T) {
+	applied := newDriftTestResource(nil, 5, "https://a.example.com")
+
+	tests := []struct {
+		name            string
+		desired         *resource
+		latest          *resource
+		expectErr       bool
+		expectDiffPaths []string
+		expectSizeInMBs int64
+		// expectRequestSizeInMBs is the SizeInMBs the update request is
+		// built with, expectSizeInMBs if zero.
+		expectRequestSizeInMBs int64
+		expectReported         bool
+	}{
+		{
+			name:            "no last applied spec with Enforce keeps the delta",
+			desired:         newDriftTestResource(map[string]string{svcapitypes.DriftPolicyAnnotation: "Enforce"}, 5, "https://a.example.com"),
+			latest:          newDriftTestResource(nil, 10, "https://a.example.com"),
+			expectDiffPaths: []string{"Spec.HTTPEndpointDestinationConfiguration.BufferingHints.SizeInMBs"},
+			expectSizeInMBs: 5,
+		},
+		{
+			name:                   "no last applied spec with ReportOnly reports every difference",
+			desired:                newDriftTestResource(map[string]string{svcapitypes.DriftPolicyAnnotation: "ReportOnly"}, 5, "https://a.example.com"),
+			latest:                 newDriftTestResource(nil, 10, "https://a.example.com"),
+			expectSizeInMBs:        5,
+			expectRequestSizeInMBs: 10,
+			expectReported:         true,
+		},
+		{
+			name:            "no last applied spec with AdoptLive copies the live value",
+			desired:         newDriftTestResource(map[string]string{svcapitypes.DriftPolicyAnnotation: "AdoptLive"}, 5, "https://a.example.com"),
+			latest:          newDriftTestResource(nil, 10, "https://a.example.com"),
+			expectSizeInMBs: 10,
+		},
+		{
+			name:            "drift with Enforce keeps the delta",
+			desired:         newDriftTestResource(lastApplied(applied, map[string]string{svcapitypes.DriftPolicyAnnotation: "Enforce"}), 5, "https://a.example.com"),
+			latest:          newDriftTestResource(nil, 10, "https://a.example.com"),
+			expectDiffPaths: []string{"Spec.HTTPEndpointDestinationConfiguration.BufferingHints.SizeInMBs"},
+			expectSizeInMBs: 5,
+		},
+		{
+			name:                   "drift with ReportOnly empties the delta and reports",
+			desired:                newDriftTestResource(lastApplied(applied, map[string]string{svcapitypes.DriftPolicyAnnotation: "ReportOnly"}), 5, "https://a.example.com"),
+			latest:                 newDriftTestResource(nil, 10, "https://a.example.com"),
+			expectSizeInMBs:        5,
+			expectRequestSizeInMBs: 10,
+			expectReported:         true,
+		},
+		{
+			name:                   "ReportOnly applies changes made to the custom resource",
+			desired:                newDriftTestResource(lastApplied(applied, map[string]string{svcapitypes.DriftPolicyAnnotation: "ReportOnly"}), 5, "https://b.example.com"),
+			latest:                 newDriftTestResource(nil, 10, "https://a.example.com"),
+			expectDiffPaths:        []string{"Spec.HTTPEndpointDestinationConfiguration.EndpointConfiguration.URL"},
+			expectSizeInMBs:        5,
+			expectRequestSizeInMBs: 10,
+			expectReported:         true,
+		},
+		{
+			name:            "drift with AdoptLive copies the live value",
+			desired:         newDriftTestResource(lastApplied(applied, map[string]string{svcapitypes.DriftPolicyAnnotation: "AdoptLive"}), 5, "https://a.example.com"),
+			latest:          newDriftTestResource(nil, 10, "https://a.example.com"),
+			expectSizeInMBs: 10,
+		},
+		{
+			name:            "AdoptLive keeps changes made to the custom resource",
+			desired:         newDriftTestResource(lastApplied(applied, map[string]string{svcapitypes.DriftPolicyAnnotation: "AdoptLive"}), 5, "https://b.example.com"),
+			latest:          newDriftTestResource(nil, 10, "https://a.example.com"),
+			expectDiffPaths: []string{"Spec.HTTPEndpointDestinationConfiguration.EndpointConfiguration.URL"},
+			expectSizeInMBs: 10,
+		},
+		{
+			name:      "invalid policy is rejected",
+			desired:   newDriftTestResource(map[string]string{svcapitypes.DriftPolicyAnnotation: "Sometimes"}, 5, "https://a.example.com"),
+			latest:    newDriftTestResource(nil, 10, "https://a.example.com"),
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delta := newResourceDelta(tt.desired, tt.latest)
+			got, request, gotDelta, err := handleDrift(tt.desired, tt.latest, delta)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(gotDelta.Differences) != len(tt.expectDiffPaths) {
+				t.Fatalf("expected %d differences, got %d", len(tt.expectDiffPaths), len(gotDelta.Differences))
+			}
+			for _, path := range tt.expectDiffPaths {
+				if !gotDelta.DifferentAt(path) {
+					t.Errorf("expected difference at %s", path)
+				}
+			}
+			size := *got.ko.Spec.HTTPEndpointDestinationConfiguration.BufferingHints.SizeInMBs
+			if size != tt.expectSizeInMBs {
+				t.Errorf("expected SizeInMBs %d, got %d", tt.expectSizeInMBs, size)
+			}
+			expectRequestSize := tt.expectRequestSizeInMBs
+			if expectRequestSize == 0 {
+				expectRequestSize = tt.expectSizeInMBs
+			}
+			if size := *request.ko.Spec.HTTPEndpointDestinationConfiguration.BufferingHints.SizeInMBs; size != expectRequestSize {
+				t.Errorf("expected request SizeInMBs %d, got %d", expectRequestSize, size)
+			}
+			if url := *request.ko.Spec.HTTPEndpointDestinationConfiguration.EndpointConfiguration.URL; url != *tt.desired.ko.Spec.HTTPEndpointDestinationConfiguration.EndpointConfiguration.URL {
+				t.Errorf("expected request URL %s, got %s", *tt.desired.ko.Spec.HTTPEndpointDestinationConfiguration.EndpointConfiguration.URL, url)
+			}
+			synced := ackcondition.Synced(got)
+			if tt.expectReported != (synced != nil && synced.Status == corev1.ConditionFalse) {
+				t.Errorf("expected reported: %v, got condition %v", tt.expectReported, synced)
+			}
+			if _, ok := got.ko.Annotations[svcapitypes.LastAppliedSpecAnnotation]; !ok {
+				t.Errorf("expected %s annotation to be set", svcapitypes.LastAppliedSpecAnnotation)
+			}
+		})
+	}
+}
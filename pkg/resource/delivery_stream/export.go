@@ -0,0 +1,317 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delivery_stream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	ackv1alpha1 "github.com/aws-controllers-k8s/runtime/apis/core/v1alpha1"
+	ackmetrics "github.com/aws-controllers-k8s/runtime/pkg/metrics"
+	ackrt "github.com/aws-controllers-k8s/runtime/pkg/runtime"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	svcsdk "github.com/aws/aws-sdk-go-v2/service/firehose"
+	svcsdktypes "github.com/aws/aws-sdk-go-v2/service/firehose/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+)
+
+// AccessKeySecretKey is the key of the access key in the Secret an exported
+// DeliveryStream refers to.
+const AccessKeySecretKey = "accessKey"
+
+// invalidNameChars matches the characters of a delivery stream name that
+// can't be part of a Kubernetes object name.
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// ExportOptions configures how Export turns a delivery stream into a
+// DeliveryStream custom resource.
+type ExportOptions struct {
+	// Namespace is the namespace of the DeliveryStream, and of the Secret
+	// its access key placeholder refers to.
+	Namespace string
+	// AdoptionPolicy is the adoption policy, adopt or adopt-or-create, the
+	// DeliveryStream is annotated with. No adoption annotations are set when
+	// it is empty.
+	AdoptionPolicy ackrt.AdoptionPolicy
+	// References indexes the ACK resources that references are substituted
+	// for. ARNs and log group names are kept when it is nil.
+	References ReferenceIndex
+}
+
+// ReferenceIndex maps the ARNs of ACK resources, or the names of ACK
+// LogGroups, to the resources, by kind.
+type ReferenceIndex map[schema.GroupVersionKind]map[string]*ackv1alpha1.AWSResourceReference
+
+// NewReferenceIndex lists the resources of the ReferencedGroupVersionKinds
+// in the supplied namespace, or in all namespaces if it is empty, and
+// indexes them. Kinds whose CRD isn't installed are skipped.
+func NewReferenceIndex(
+	ctx context.Context,
+	reader client.Reader,
+	namespace string,
+) (ReferenceIndex, error) {
+	index := ReferenceIndex{}
+	for _, gvk := range ReferencedGroupVersionKinds {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		if err := reader.List(ctx, list, client.InNamespace(namespace)); err != nil {
+			if meta.IsNoMatchError(err) {
+				continue
+			}
+			return nil, fmt.Errorf("listing %s resources: %w", gvk.Kind, err)
+		}
+		for i := range list.Items {
+			index.add(gvk, &list.Items[i])
+		}
+	}
+	return index, nil
+}
+
+// add indexes obj by its ARN, or by its log group name for a LogGroup.
+func (index ReferenceIndex) add(gvk schema.GroupVersionKind, obj *unstructured.Unstructured) {
+	fields := []string{"status", "ackResourceMetadata", "arn"}
+	if gvk == logGroupGroupVersionKind {
+		fields = []string{"spec", "name"}
+	}
+	value, _, _ := unstructured.NestedString(obj.Object, fields...)
+	if value == "" {
+		return
+	}
+	if index[gvk] == nil {
+		index[gvk] = map[string]*ackv1alpha1.AWSResourceReference{}
+	}
+	index[gvk][value] = &ackv1alpha1.AWSResourceReference{
+		Name:      aws.String(obj.GetName()),
+		Namespace: aws.String(obj.GetNamespace()),
+	}
+}
+
+// lookup returns a reference to the resource of the supplied kind indexed
+// under value, or nil if there is none. The namespace of the reference is
+// omitted when it is the namespace of the referencing resource.
+func (index ReferenceIndex) lookup(
+	gvk schema.GroupVersionKind,
+	value *string,
+	namespace string,
+) *ackv1alpha1.AWSResourceReference {
+	if value == nil {
+		return nil
+	}
+	found, ok := index[gvk][*value]
+	if !ok {
+		return nil
+	}
+	ref := &ackv1alpha1.AWSResourceReference{Name: found.Name}
+	if aws.ToString(found.Namespace) != namespace {
+		ref.Namespace = found.Namespace
+	}
+	return ref
+}
+
+// substitute replaces the value of the supplied field with a reference to
+// the resource of the supplied kind it identifies, if there is one.
+func (index ReferenceIndex) substitute(
+	gvk schema.GroupVersionKind,
+	value **string,
+	ref **ackv1alpha1.AWSResourceReferenceWrapper,
+	namespace string,
+) {
+	if from := index.lookup(gvk, *value, namespace); from != nil {
+		*ref = &ackv1alpha1.AWSResourceReferenceWrapper{From: from}
+		*value = nil
+	}
+}
+
+// Export reads the delivery stream with the supplied name and returns it as
+// a DeliveryStream custom resource that can be applied to manage it.
+func Export(
+	ctx context.Context,
+	sdkapi *svcsdk.Client,
+	name string,
+	opts ExportOptions,
+) (*svcapitypes.DeliveryStream, error) {
+	// The delivery stream is read the way the controller reads it when
+	// reconciling, so that applying the exported spec results in no update.
+	rm := &resourceManager{
+		metrics: ackmetrics.NewMetrics("firehose"),
+		sdkapi:  sdkapi,
+	}
+	latest, err := rm.sdkFind(ctx, &resource{&svcapitypes.DeliveryStream{
+		Spec: svcapitypes.DeliveryStreamSpec{DeliveryStreamName: aws.String(name)},
+	}})
+	if err != nil {
+		return nil, fmt.Errorf("reading delivery stream %q: %w", name, err)
+	}
+	return newExportedDeliveryStream(latest.ko, opts)
+}
+
+// newExportedDeliveryStream returns a DeliveryStream holding the spec of
+// latest, without the status and the annotations set while reading it.
+func newExportedDeliveryStream(
+	latest *svcapitypes.DeliveryStream,
+	opts ExportOptions,
+) (*svcapitypes.DeliveryStream, error) {
+	ko := &svcapitypes.DeliveryStream{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: svcapitypes.GroupVersion.String(),
+			Kind:       "DeliveryStream",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      objectName(*latest.Spec.DeliveryStreamName),
+			Namespace: opts.Namespace,
+		},
+		Spec: *latest.Spec.DeepCopy(),
+	}
+
+	// DescribeDeliveryStream reports a disabled encryption configuration,
+	// which the controller would enable if it were part of the spec.
+	if status := aws.ToString(latest.Status.DeliveryStreamEncryptionConfigurationStatus); status != string(svcsdktypes.DeliveryStreamEncryptionStatusEnabled) &&
+		status != string(svcsdktypes.DeliveryStreamEncryptionStatusEnabling) {
+		ko.Spec.DeliveryStreamEncryptionConfiguration = nil
+	}
+
+	tags, keyOrder := convertToOrderedACKTags(ko.Spec.Tags)
+	ignoreSystemTags(tags, nil)
+	ignoreControllerTags(tags)
+	ko.Spec.Tags = fromACKTags(tags, keyOrder)
+	if len(ko.Spec.Tags) == 0 {
+		ko.Spec.Tags = nil
+	}
+
+	setAccessKeyPlaceholder(ko)
+	if opts.References != nil {
+		substituteReferences(ko, opts.References)
+	}
+
+	if opts.AdoptionPolicy != "" {
+		fields, err := json.Marshal(map[string]string{
+			"deliveryStreamName": *ko.Spec.DeliveryStreamName,
+		})
+		if err != nil {
+			return nil, err
+		}
+		ko.SetAnnotations(map[string]string{
+			ackv1alpha1.AnnotationAdoptionPolicy: string(opts.AdoptionPolicy),
+			ackv1alpha1.AnnotationAdoptionFields: string(fields),
+		})
+	}
+	return ko, nil
+}
+
+// objectName returns a Kubernetes object name for the delivery stream with
+// the supplied name, which may contain upper case letters and underscores.
+func objectName(name string) string {
+	name = invalidNameChars.ReplaceAllString(strings.ToLower(name), "-")
+	return strings.Trim(name, ".-")
+}
+
+// setAccessKeyPlaceholder refers the HTTP endpoint access key, which
+// DescribeDeliveryStream never returns, to a Secret named after the
+// DeliveryStream, unless the endpoint reads it from Secrets Manager.
+func setAccessKeyPlaceholder(ko *svcapitypes.DeliveryStream) {
+	dest := ko.Spec.HTTPEndpointDestinationConfiguration
+	if dest == nil || dest.EndpointConfiguration == nil {
+		return
+	}
+	if dest.SecretsManagerConfiguration != nil && aws.ToBool(dest.SecretsManagerConfiguration.Enabled) {
+		return
+	}
+	dest.EndpointConfiguration.AccessKey = &ackv1alpha1.SecretKeyReference{
+		SecretReference: corev1.SecretReference{
+			Name:      ko.Name + "-access-key",
+			Namespace: ko.Namespace,
+		},
+		Key: AccessKeySecretKey,
+	}
+}
+
+// substituteReferences replaces the ARNs and log group names of the spec of
+// ko with references to the indexed ACK resources they identify.
+func substituteReferences(ko *svcapitypes.DeliveryStream, index ReferenceIndex) {
+	ns := ko.Namespace
+	if enc := ko.Spec.DeliveryStreamEncryptionConfiguration; enc != nil {
+		index.substitute(keyGroupVersionKind, &enc.KeyARN, &enc.KeyRef, ns)
+	}
+	dest := ko.Spec.HTTPEndpointDestinationConfiguration
+	if dest == nil {
+		return
+	}
+	index.substitute(roleGroupVersionKind, &dest.RoleARN, &dest.RoleRef, ns)
+	for _, f := range cloudWatchLoggingOptions(ko) {
+		index.substitute(logGroupGroupVersionKind, &f.opts.LogGroupName, &f.opts.LogGroupRef, ns)
+	}
+	for _, p := range httpEndpointProcessors(ko) {
+		if p == nil || aws.ToString(p.Type) != lambdaProcessorType {
+			continue
+		}
+		substituteLambdaReference(p, index, ns)
+	}
+	if s3 := dest.S3Configuration; s3 != nil {
+		index.substitute(bucketGroupVersionKind, &s3.BucketARN, &s3.BucketRef, ns)
+		index.substitute(roleGroupVersionKind, &s3.RoleARN, &s3.RoleRef, ns)
+		if s3.EncryptionConfiguration != nil && s3.EncryptionConfiguration.KMSEncryptionConfig != nil {
+			kms := s3.EncryptionConfiguration.KMSEncryptionConfig
+			index.substitute(keyGroupVersionKind, &kms.AWSKMSKeyARN, &kms.AWSKMSKeyRef, ns)
+		}
+	}
+	if sm := dest.SecretsManagerConfiguration; sm != nil {
+		index.substitute(roleGroupVersionKind, &sm.RoleARN, &sm.RoleRef, ns)
+		index.substitute(secretGroupVersionKind, &sm.SecretARN, &sm.SecretRef, ns)
+	}
+}
+
+// substituteLambdaReference replaces the LambdaArn parameter of a Lambda
+// processor with a reference to the Lambda Function, Alias or Version it
+// identifies.
+func substituteLambdaReference(p *svcapitypes.Processor, index ReferenceIndex, namespace string) {
+	param := processorParameter(p, lambdaArnParameter)
+	if param == nil {
+		return
+	}
+	for _, kind := range []svcapitypes.LambdaReferenceKind{
+		svcapitypes.LambdaReferenceKindFunction,
+		svcapitypes.LambdaReferenceKindAlias,
+		svcapitypes.LambdaReferenceKindVersion,
+	} {
+		from := index.lookup(lambdaAPIGroupVersion.WithKind(string(kind)), param.ParameterValue, namespace)
+		if from == nil {
+			continue
+		}
+		p.LambdaRef = &svcapitypes.LambdaReference{From: from}
+		if kind != svcapitypes.LambdaReferenceKindFunction {
+			p.LambdaRef.Kind = aws.String(string(kind))
+		}
+		params := p.Parameters[:0]
+		for _, other := range p.Parameters {
+			if other != param {
+				params = append(params, other)
+			}
+		}
+		p.Parameters = params
+		if len(p.Parameters) == 0 {
+			p.Parameters = nil
+		}
+		return
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delivery_stream
+
+import (
+	"context"
+	"testing"
+
+	ackv1alpha1 "github.com/aws-controllers-k8s/runtime/apis/core/v1alpha1"
+	ackrt "github.com/aws-controllers-k8s/runtime/pkg/runtime"
+	"github.com/aws/aws-sdk-go/aws"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+)
+
+const (
+	exportRoleARN   = "arn:aws:iam::123456789012:role/firehose"
+	exportLambdaARN = "arn:aws:lambda:us-west-2:123456789012:function:transform"
+)
+
+// createExportedStream creates an ACTIVE delivery stream with a Lambda
+// processor, an owner tag and a user tag.
+func createExportedStream(l *lifecycle) {
+	l.t.Helper()
+	dest := minimalHTTPEndpointDestination()
+	dest.ProcessingConfiguration = &svcapitypes.ProcessingConfiguration{
+		Enabled: aws.Bool(true),
+		Processors: []*svcapitypes.Processor{{
+			Type: aws.String("Lambda"),
+			Parameters: []*svcapitypes.ProcessorParameter{
+				{ParameterName: aws.String("LambdaArn"), ParameterValue: aws.String(exportLambdaARN)},
+				{ParameterName: aws.String("NumberOfRetries"), ParameterValue: aws.String("3")},
+			},
+		}},
+	}
+	_, err := l.rm.sdkCreate(l.ctx, &resource{&svcapitypes.DeliveryStream{
+		Spec: svcapitypes.DeliveryStreamSpec{
+			DeliveryStreamName:                   aws.String("Orders_Stream"),
+			HTTPEndpointDestinationConfiguration: dest,
+			Tags: []*svcapitypes.Tag{
+				{Key: aws.String(ownerTagKey), Value: aws.String("other-cluster/uid")},
+				{Key: aws.String("team"), Value: aws.String("data")},
+			},
+		},
+	}})
+	if err != nil {
+		l.t.Fatalf("sdkCreate() error = %v", err)
+	}
+	l.settle()
+}
+
+func newIndexedObject(gvk schema.GroupVersionKind, namespace, name, arn string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	obj.Object["status"] = map[string]interface{}{
+		"ackResourceMetadata": map[string]interface{}{"arn": arn},
+	}
+	return obj
+}
+
+func TestExport(t *testing.T) {
+	l := newLifecycle(t)
+	createExportedStream(l)
+
+	ko, err := Export(l.ctx, l.rm.sdkapi, "Orders_Stream", ExportOptions{
+		Namespace:      "prod",
+		AdoptionPolicy: ackrt.AdoptionPolicy_Adopt,
+	})
+	if err != nil {
+		t.Fatalf("export() error = %v", err)
+	}
+	if ko.Name != "orders-stream" || ko.Namespace != "prod" {
+		t.Errorf("object = %s/%s, want prod/orders-stream", ko.Namespace, ko.Name)
+	}
+	if got := ko.Annotations[ackv1alpha1.AnnotationAdoptionPolicy]; got != "adopt" {
+		t.Errorf("%s annotation = %q, want adopt", ackv1alpha1.AnnotationAdoptionPolicy, got)
+	}
+	if got, want := ko.Annotations[ackv1alpha1.AnnotationAdoptionFields], `{"deliveryStreamName":"Orders_Stream"}`; got != want {
+		t.Errorf("%s annotation = %q, want %q", ackv1alpha1.AnnotationAdoptionFields, got, want)
+	}
+	if _, ok := ko.Annotations[svcapitypes.TagsHashAnnotation]; ok {
+		t.Errorf("unexpected %s annotation", svcapitypes.TagsHashAnnotation)
+	}
+	if len(ko.Spec.Tags) != 1 || aws.StringValue(ko.Spec.Tags[0].Key) != "team" {
+		t.Errorf("Tags = %v, want only team", ko.Spec.Tags)
+	}
+	if ko.Spec.DeliveryStreamEncryptionConfiguration != nil {
+		t.Errorf("DeliveryStreamEncryptionConfiguration = %v, want nil while encryption is disabled", ko.Spec.DeliveryStreamEncryptionConfiguration)
+	}
+	accessKey := ko.Spec.HTTPEndpointDestinationConfiguration.EndpointConfiguration.AccessKey
+	if accessKey == nil || accessKey.Name != "orders-stream-access-key" || accessKey.Namespace != "prod" || accessKey.Key != AccessKeySecretKey {
+		t.Errorf("AccessKey = %+v, want a placeholder for Secret prod/orders-stream-access-key", accessKey)
+	}
+
+	// Applying the exported resource must not result in an update. The
+	// placeholder Secret doesn't exist, so the access key is left out.
+	desired := &resource{ko.DeepCopy()}
+	desired.ko.Spec.HTTPEndpointDestinationConfiguration.EndpointConfiguration.AccessKey = nil
+	latest := l.find(desired)
+	l.rm.FilterSystemTags(latest, nil)
+	if delta := newResourceDelta(desired, latest); len(delta.Differences) != 0 {
+		for _, d := range delta.Differences {
+			t.Errorf("unexpected difference at %s", d.Path)
+		}
+	}
+}
+
+func TestExportReferences(t *testing.T) {
+	l := newLifecycle(t)
+	createExportedStream(l)
+
+	kc := fake.NewClientBuilder().WithObjects(
+		newIndexedObject(roleGroupVersionKind, "prod", "firehose-role", exportRoleARN),
+		newIndexedObject(bucketGroupVersionKind, "shared", "bucket", "arn:aws:s3:::bucket"),
+		newIndexedObject(lambdaAPIGroupVersion.WithKind("Function"), "prod", "transform", exportLambdaARN),
+	).Build()
+	index, err := NewReferenceIndex(l.ctx, kc, "")
+	if err != nil {
+		t.Fatalf("NewReferenceIndex() error = %v", err)
+	}
+	ko, err := Export(l.ctx, l.rm.sdkapi, "Orders_Stream", ExportOptions{Namespace: "prod", References: index})
+	if err != nil {
+		t.Fatalf("export() error = %v", err)
+	}
+	if len(ko.Annotations) != 0 {
+		t.Errorf("Annotations = %v, want none without an adoption policy", ko.Annotations)
+	}
+
+	dest := ko.Spec.HTTPEndpointDestinationConfiguration
+	if dest.RoleARN != nil || dest.RoleRef == nil || aws.StringValue(dest.RoleRef.From.Name) != "firehose-role" || dest.RoleRef.From.Namespace != nil {
+		t.Errorf("RoleARN = %v, RoleRef = %+v, want a reference to firehose-role", aws.StringValue(dest.RoleARN), dest.RoleRef)
+	}
+	if s3 := dest.S3Configuration; s3.BucketARN != nil || s3.BucketRef == nil || aws.StringValue(s3.BucketRef.From.Namespace) != "shared" {
+		t.Errorf("BucketARN = %v, BucketRef = %+v, want a reference to shared/bucket", aws.StringValue(s3.BucketARN), s3.BucketRef)
+	}
+	if s3 := dest.S3Configuration; s3.RoleRef == nil || s3.RoleARN != nil {
+		t.Errorf("S3Configuration RoleARN = %v, RoleRef = %+v, want a reference", aws.StringValue(s3.RoleARN), s3.RoleRef)
+	}
+	p := dest.ProcessingConfiguration.Processors[0]
+	if p.LambdaRef == nil || aws.StringValue(p.LambdaRef.From.Name) != "transform" || p.LambdaRef.Kind != nil {
+		t.Errorf("LambdaRef = %+v, want a reference to Function transform", p.LambdaRef)
+	}
+	if processorParameter(p, lambdaArnParameter) != nil || len(p.Parameters) != 1 {
+		t.Errorf("Parameters = %v, want only NumberOfRetries", p.Parameters)
+	}
+
+	if _, err := Export(context.Background(), l.rm.sdkapi, "missing", ExportOptions{}); err == nil {
+		t.Error("export() of a missing delivery stream error = nil")
+	}
+}
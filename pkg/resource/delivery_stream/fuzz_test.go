@@ -0,0 +1,307 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delivery_stream
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	ackv1alpha1 "github.com/aws-controllers-k8s/runtime/apis/core/v1alpha1"
+	ackcompare "github.com/aws-controllers-k8s/runtime/pkg/compare"
+	"github.com/aws/aws-sdk-go/aws"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+)
+
+// fuzzInput turns the bytes generated by the fuzzer into spec values. Once
+// the bytes are exhausted every value is zero, so that any input builds a
+// spec.
+type fuzzInput struct {
+	data []byte
+}
+
+func (in *fuzzInput) byte() byte {
+	if len(in.data) == 0 {
+		return 0
+	}
+	b := in.data[0]
+	in.data = in.data[1:]
+	return b
+}
+
+func (in *fuzzInput) bool() bool {
+	return in.byte()&1 == 1
+}
+
+// set decides whether an optional field is set.
+func (in *fuzzInput) set() bool {
+	return in.bool()
+}
+
+func (in *fuzzInput) string() *string {
+	if !in.set() {
+		return nil
+	}
+	n := int(in.byte() % 16)
+	if n > len(in.data) {
+		n = len(in.data)
+	}
+	s := string(in.data[:n])
+	in.data = in.data[n:]
+	return &s
+}
+
+func (in *fuzzInput) boolPtr() *bool {
+	if !in.set() {
+		return nil
+	}
+	return aws.Bool(in.bool())
+}
+
+// int64 favours the bounds of the int32 fields of the SDK.
+func (in *fuzzInput) int64() *int64 {
+	if !in.set() {
+		return nil
+	}
+	switch in.byte() % 6 {
+	case 0:
+		return aws.Int64(math.MaxInt32)
+	case 1:
+		return aws.Int64(math.MaxInt32 + 1)
+	case 2:
+		return aws.Int64(math.MinInt32)
+	case 3:
+		return aws.Int64(math.MinInt32 - 1)
+	case 4:
+		return aws.Int64(int64(in.byte()))
+	}
+	var b [8]byte
+	for i := range b {
+		b[i] = in.byte()
+	}
+	return aws.Int64(int64(binary.LittleEndian.Uint64(b[:])))
+}
+
+// count returns the length of a list.
+func (in *fuzzInput) count() int {
+	return int(in.byte() % 4)
+}
+
+func (in *fuzzInput) reference() *ackv1alpha1.AWSResourceReferenceWrapper {
+	if !in.set() {
+		return nil
+	}
+	return &ackv1alpha1.AWSResourceReferenceWrapper{
+		From: &ackv1alpha1.AWSResourceReference{Name: in.string(), Namespace: in.string()},
+	}
+}
+
+func (in *fuzzInput) cloudWatchLoggingOptions() *svcapitypes.CloudWatchLoggingOptions {
+	if !in.set() {
+		return nil
+	}
+	return &svcapitypes.CloudWatchLoggingOptions{
+		Enabled:       in.boolPtr(),
+		LogGroupName:  in.string(),
+		LogGroupRef:   in.reference(),
+		LogStreamName: in.string(),
+	}
+}
+
+// deliveryStream builds a DeliveryStream from the fuzzer input. The access
+// key of the endpoint is never set, as it is read from a Secret through the
+// Kubernetes API, and list elements are never nil, as the CRD schema does not
+// allow null items and the API server rejects them before they reach the
+// controller.
+func (in *fuzzInput) deliveryStream() *svcapitypes.DeliveryStream {
+	ko := &svcapitypes.DeliveryStream{}
+	ko.Spec.DeliveryStreamName = in.string()
+	ko.Spec.DeliveryStreamType = in.string()
+	if in.set() {
+		ko.Spec.DeliveryStreamEncryptionConfiguration = &svcapitypes.DeliveryStreamEncryptionConfigurationInput{
+			KeyARN:  in.string(),
+			KeyRef:  in.reference(),
+			KeyType: in.string(),
+		}
+	}
+	for i := in.count(); i > 0; i-- {
+		ko.Spec.Tags = append(ko.Spec.Tags, &svcapitypes.Tag{Key: in.string(), Value: in.string()})
+	}
+	if !in.set() {
+		return ko
+	}
+
+	dest := &svcapitypes.HTTPEndpointDestinationConfiguration{
+		CloudWatchLoggingOptions: in.cloudWatchLoggingOptions(),
+		RoleARN:                  in.string(),
+		RoleRef:                  in.reference(),
+		S3BackupMode:             in.string(),
+	}
+	ko.Spec.HTTPEndpointDestinationConfiguration = dest
+	if in.set() {
+		dest.BufferingHints = &svcapitypes.HTTPEndpointBufferingHints{
+			IntervalInSeconds: in.int64(),
+			SizeInMBs:         in.int64(),
+		}
+	}
+	if in.set() {
+		dest.EndpointConfiguration = &svcapitypes.HTTPEndpointConfiguration{
+			Name: in.string(),
+			URL:  in.string(),
+		}
+	}
+	if in.set() {
+		dest.ProcessingConfiguration = &svcapitypes.ProcessingConfiguration{Enabled: in.boolPtr()}
+		for i := in.count(); i > 0; i-- {
+			processor := &svcapitypes.Processor{Type: in.string()}
+			if in.set() {
+				processor.LambdaRef = &svcapitypes.LambdaReference{Kind: in.string()}
+			}
+			for j := in.count(); j > 0; j-- {
+				processor.Parameters = append(processor.Parameters, &svcapitypes.ProcessorParameter{
+					ParameterName:  in.string(),
+					ParameterValue: in.string(),
+				})
+			}
+			dest.ProcessingConfiguration.Processors = append(dest.ProcessingConfiguration.Processors, processor)
+		}
+	}
+	if in.set() {
+		dest.RequestConfiguration = &svcapitypes.HTTPEndpointRequestConfiguration{ContentEncoding: in.string()}
+		for i := in.count(); i > 0; i-- {
+			dest.RequestConfiguration.CommonAttributes = append(dest.RequestConfiguration.CommonAttributes, &svcapitypes.HTTPEndpointCommonAttribute{
+				AttributeName:  in.string(),
+				AttributeValue: in.string(),
+			})
+		}
+	}
+	if in.set() {
+		dest.RetryOptions = &svcapitypes.HTTPEndpointRetryOptions{DurationInSeconds: in.int64()}
+	}
+	if in.set() {
+		s3 := &svcapitypes.S3DestinationConfiguration{
+			BucketARN:                in.string(),
+			BucketRef:                in.reference(),
+			CloudWatchLoggingOptions: in.cloudWatchLoggingOptions(),
+			CompressionFormat:        in.string(),
+			ErrorOutputPrefix:        in.string(),
+			Prefix:                   in.string(),
+			RoleARN:                  in.string(),
+		}
+		if in.set() {
+			s3.BufferingHints = &svcapitypes.BufferingHints{
+				IntervalInSeconds: in.int64(),
+				SizeInMBs:         in.int64(),
+			}
+		}
+		if in.set() {
+			s3.EncryptionConfiguration = &svcapitypes.EncryptionConfiguration{NoEncryptionConfig: in.string()}
+			if in.set() {
+				s3.EncryptionConfiguration.KMSEncryptionConfig = &svcapitypes.KMSEncryptionConfig{
+					AWSKMSKeyARN: in.string(),
+					AWSKMSKeyRef: in.reference(),
+				}
+			}
+		}
+		dest.S3Configuration = s3
+	}
+	if in.set() {
+		dest.SecretsManagerConfiguration = &svcapitypes.SecretsManagerConfiguration{
+			Enabled:   in.boolPtr(),
+			RoleARN:   in.string(),
+			RoleRef:   in.reference(),
+			SecretARN: in.string(),
+			SecretRef: in.reference(),
+		}
+	}
+	return ko
+}
+
+// overflowsInt32 returns whether one of the integers of the spec that the
+// SDK holds in an int32 is out of its range.
+func overflowsInt32(ko *svcapitypes.DeliveryStream) bool {
+	dest := ko.Spec.HTTPEndpointDestinationConfiguration
+	if dest == nil {
+		return false
+	}
+	var values []*int64
+	if dest.BufferingHints != nil {
+		values = append(values, dest.BufferingHints.IntervalInSeconds, dest.BufferingHints.SizeInMBs)
+	}
+	if dest.RetryOptions != nil {
+		values = append(values, dest.RetryOptions.DurationInSeconds)
+	}
+	if dest.S3Configuration != nil && dest.S3Configuration.BufferingHints != nil {
+		values = append(values, dest.S3Configuration.BufferingHints.IntervalInSeconds, dest.S3Configuration.BufferingHints.SizeInMBs)
+	}
+	for _, v := range values {
+		if v != nil && (*v > math.MaxInt32 || *v < math.MinInt32) {
+			return true
+		}
+	}
+	return false
+}
+
+// addFuzzSeeds adds inputs that set every field, with integers at and
+// beyond the int32 bounds. Failing inputs found with `make fuzz` belong in
+// testdata/fuzz/<target>, next to these seeds.
+func addFuzzSeeds(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	every := make([]byte, 512)
+	for i := range every {
+		every[i] = byte(2*i + 1)
+	}
+	f.Add(every)
+}
+
+func FuzzNewCreateRequestPayload(f *testing.F) {
+	addFuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ko := (&fuzzInput{data}).deliveryStream()
+		rm := &resourceManager{}
+		_, err := rm.newCreateRequestPayload(context.Background(), &resource{ko})
+		if overflow := overflowsInt32(ko); overflow != (err != nil) {
+			t.Fatalf("newCreateRequestPayload() error = %v, integer out of the int32 range: %v", err, overflow)
+		}
+	})
+}
+
+func FuzzNewUpdateRequestPayload(f *testing.F) {
+	addFuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ko := (&fuzzInput{data}).deliveryStream()
+		rm := &resourceManager{}
+		_, err := rm.newUpdateRequestPayload(context.Background(), &resource{ko}, ackcompare.NewDelta())
+		if overflow := overflowsInt32(ko); overflow != (err != nil) {
+			t.Fatalf("newUpdateRequestPayload() error = %v, integer out of the int32 range: %v", err, overflow)
+		}
+	})
+}
+
+func FuzzResourceDelta(f *testing.F) {
+	addFuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ko := (&fuzzInput{data}).deliveryStream()
+		a := &resource{ko}
+		if delta := newResourceDelta(a, &resource{ko.DeepCopy()}); len(delta.Differences) > 0 {
+			t.Fatalf("delta of a spec with its copy has differences at %s", delta.Differences[0].Path)
+		}
+		if delta := newResourceDelta(a, a); len(delta.Differences) > 0 {
+			t.Fatalf("delta of a spec with itself has differences at %s", delta.Differences[0].Path)
+		}
+	})
+}
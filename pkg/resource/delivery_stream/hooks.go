@@ -15,15 +15,19 @@ package delivery_stream
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
 	"github.com/aws-controllers-k8s/firehose-controller/pkg/resource/tags"
+	ackerr "github.com/aws-controllers-k8s/runtime/pkg/errors"
 	ackrequeue "github.com/aws-controllers-k8s/runtime/pkg/requeue"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	svcsdk "github.com/aws/aws-sdk-go-v2/service/firehose"
 	svcsdktypes "github.com/aws/aws-sdk-go-v2/service/firehose/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 var (
@@ -41,6 +45,28 @@ var (
 	)
 )
 
+var (
+	kubeReaderMu sync.RWMutex
+	kubeReader   client.Reader
+)
+
+// SetAPIReader sets the reader used to get the labels of the namespace of a
+// DeliveryStream when propagating labels to tags, and the resourceVersion of
+// the Secret holding its access key. It is called once by the controller's
+// main function. Without a reader, namespace labels are not propagated and
+// the access key hash is keyed by the UID of the DeliveryStream only.
+func SetAPIReader(r client.Reader) {
+	kubeReaderMu.Lock()
+	defer kubeReaderMu.Unlock()
+	kubeReader = r
+}
+
+func getAPIReader() client.Reader {
+	kubeReaderMu.RLock()
+	defer kubeReaderMu.RUnlock()
+	return kubeReader
+}
+
 var (
 	requeueWhileCreating = ackrequeue.NeededAfter(
 		ErrDeliveryStreamCreating,
@@ -56,21 +82,59 @@ var (
 	)
 )
 
-// getTags retrieves the resource's associated tags.
+// getTags retrieves the resource's associated tags and records their hash in
+// the tags-hash annotation of the supplied custom resource. The tags are
+// always read back with ListTagsForDeliveryStream, as the owner tag they
+// carry decides whether the delivery stream can be modified at all.
 func (rm *resourceManager) getTags(
 	ctx context.Context,
-	resourceARN string,
+	ko *svcapitypes.DeliveryStream,
 ) ([]*svcapitypes.Tag, error) {
-	return tags.GetResourceTags(ctx, rm.sdkapi, rm.metrics, resourceARN)
+	latest, err := tags.GetResourceTags(ctx, rm.sdkapi, rm.metrics, *ko.Spec.DeliveryStreamName)
+	if err != nil {
+		return nil, err
+	}
+	setTagsHash(ko, latest)
+	return latest, nil
+}
+
+// tagsUnchanged returns true if neither the desired nor the latest tags
+// changed since they were last synced, that is if both match the hash the
+// tags-hash annotation of desired recorded then.
+func tagsUnchanged(desired *resource, latest *resource) bool {
+	hash, ok := desired.ko.GetAnnotations()[svcapitypes.TagsHashAnnotation]
+	return ok && hash == tags.Hash(desired.ko.Spec.Tags) && hash == tags.Hash(latest.ko.Spec.Tags)
 }
 
-// syncTags keeps the resource's tags in sync.
+// setTagsHash records the hash of the supplied tags, which the delivery
+// stream is known to carry, in the tags-hash annotation.
+func setTagsHash(ko *svcapitypes.DeliveryStream, latest []*svcapitypes.Tag) {
+	annotations := ko.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[svcapitypes.TagsHashAnnotation] = tags.Hash(latest)
+	ko.SetAnnotations(annotations)
+}
+
+// clearTagsHash removes the tags-hash annotation, so that the tags are
+// compared on the next reconciliation.
+func clearTagsHash(ko *svcapitypes.DeliveryStream) {
+	annotations := ko.GetAnnotations()
+	if _, ok := annotations[svcapitypes.TagsHashAnnotation]; ok {
+		delete(annotations, svcapitypes.TagsHashAnnotation)
+		ko.SetAnnotations(annotations)
+	}
+}
+
+// syncTags keeps the resource's tags in sync. Invalid tags result in a
+// terminal error, as they can't be synced until the spec is fixed.
 func (rm *resourceManager) syncTags(
 	ctx context.Context,
 	desired *resource,
 	latest *resource,
 ) (err error) {
-	return tags.SyncResourceTags(
+	err = tags.SyncResourceTags(
 		ctx,
 		rm.sdkapi,
 		rm.metrics,
@@ -78,6 +142,24 @@ func (rm *resourceManager) syncTags(
 		desired.ko.Spec.Tags,
 		latest.ko.Spec.Tags,
 	)
+	if errors.Is(err, tags.ErrInvalidTags) {
+		return ackerr.NewTerminalError(err)
+	}
+	if err != nil {
+		clearTagsHash(desired.ko)
+		return err
+	}
+	setTagsHash(desired.ko, desired.ko.Spec.Tags)
+	return nil
+}
+
+// validateTags returns a terminal error if the tags of the resource don't
+// meet the Firehose tag restrictions.
+func validateTags(r *resource) error {
+	if err := tags.ValidateTags(r.ko.Spec.Tags); err != nil {
+		return ackerr.NewTerminalError(err)
+	}
+	return nil
 }
 
 // deliveryStreamEncryptionDisabled checks whether or not server-side encryption is disabled or not.
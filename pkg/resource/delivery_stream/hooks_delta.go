@@ -0,0 +1,198 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delivery_stream
+
+import (
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+	svcsdktypes "github.com/aws/aws-sdk-go-v2/service/firehose/types"
+)
+
+// processorParameterDefaults contains, per processor type, the parameters
+// Firehose adds to a processor when they are not specified, along with the
+// value it sets them to.
+var processorParameterDefaults = map[string]map[string]string{
+	string(svcsdktypes.ProcessorTypeLambda): {
+		string(svcsdktypes.ProcessorParameterNameLambdaNumberOfRetries):   "3",
+		string(svcsdktypes.ProcessorParameterNameBufferSizeInMb):          "1",
+		string(svcsdktypes.ProcessorParameterNameBufferIntervalInSeconds): "60",
+	},
+}
+
+// httpEndpointProcessingConfiguration returns the ProcessingConfiguration of
+// the HTTP endpoint destination or nil if it isn't set.
+func httpEndpointProcessingConfiguration(r *resource) *svcapitypes.ProcessingConfiguration {
+	if r.ko.Spec.HTTPEndpointDestinationConfiguration == nil {
+		return nil
+	}
+	return r.ko.Spec.HTTPEndpointDestinationConfiguration.ProcessingConfiguration
+}
+
+// processorParameters returns the parameters of a processor keyed by
+// ParameterName.
+func processorParameters(p *svcapitypes.Processor) map[string]string {
+	params := make(map[string]string, len(p.Parameters))
+	for _, param := range p.Parameters {
+		if param == nil || param.ParameterName == nil {
+			continue
+		}
+		value := ""
+		if param.ParameterValue != nil {
+			value = *param.ParameterValue
+		}
+		params[*param.ParameterName] = value
+	}
+	return params
+}
+
+// equalProcessorParameters returns true if the desired and latest parameters
+// of a processor are the same regardless of their order. Parameters that
+// are only present in latest and hold the value Firehose sets by default for
+// the processor type are ignored.
+func equalProcessorParameters(processorType string, desired, latest *svcapitypes.Processor) bool {
+	desiredParams := processorParameters(desired)
+	latestParams := processorParameters(latest)
+	defaults := processorParameterDefaults[processorType]
+	for name, value := range latestParams {
+		if _, ok := desiredParams[name]; ok {
+			continue
+		}
+		if defaultValue, ok := defaults[name]; ok && defaultValue == value {
+			delete(latestParams, name)
+		}
+	}
+	if len(desiredParams) != len(latestParams) {
+		return false
+	}
+	for name, value := range desiredParams {
+		if latestValue, ok := latestParams[name]; !ok || latestValue != value {
+			return false
+		}
+	}
+	return true
+}
+
+// equalProcessors returns true if the desired and latest processors are
+// semantically equal. Firehose runs processors in order, so they are
+// compared pairwise, and the parameters of each processor are compared as a
+// map keyed by ParameterName, ignoring parameters injected by Firehose with
+// their default value.
+func equalProcessors(desired, latest []*svcapitypes.Processor) bool {
+	if len(desired) != len(latest) {
+		return false
+	}
+	for i, desiredProcessor := range desired {
+		latestProcessor := latest[i]
+		if desiredProcessor == nil || latestProcessor == nil {
+			if desiredProcessor != latestProcessor {
+				return false
+			}
+			continue
+		}
+		desiredType, latestType := "", ""
+		if desiredProcessor.Type != nil {
+			desiredType = *desiredProcessor.Type
+		}
+		if latestProcessor.Type != nil {
+			latestType = *latestProcessor.Type
+		}
+		if desiredType != latestType {
+			return false
+		}
+		if !equalProcessorParameters(desiredType, desiredProcessor, latestProcessor) {
+			return false
+		}
+	}
+	return true
+}
+
+// httpEndpointRequestConfiguration returns the RequestConfiguration of the
+// HTTP endpoint destination or nil if it isn't set.
+func httpEndpointRequestConfiguration(r *resource) *svcapitypes.HTTPEndpointRequestConfiguration {
+	if r.ko.Spec.HTTPEndpointDestinationConfiguration == nil {
+		return nil
+	}
+	return r.ko.Spec.HTTPEndpointDestinationConfiguration.RequestConfiguration
+}
+
+// commonAttributesByName indexes common attributes by their AttributeName. It
+// returns false if two attributes share the same name, in which case they
+// can't be compared independently of their order.
+func commonAttributesByName(attributes []*svcapitypes.HTTPEndpointCommonAttribute) (map[string]string, bool) {
+	byName := make(map[string]string, len(attributes))
+	for _, attr := range attributes {
+		if attr == nil {
+			continue
+		}
+		name := ""
+		if attr.AttributeName != nil {
+			name = *attr.AttributeName
+		}
+		if _, ok := byName[name]; ok {
+			return nil, false
+		}
+		value := ""
+		if attr.AttributeValue != nil {
+			value = *attr.AttributeValue
+		}
+		byName[name] = value
+	}
+	return byName, true
+}
+
+// equalCommonAttributes returns true if the desired and latest common
+// attributes hold the same values keyed by AttributeName, regardless of
+// their order.
+func equalCommonAttributes(desired, latest []*svcapitypes.HTTPEndpointCommonAttribute) bool {
+	desiredByName, ok := commonAttributesByName(desired)
+	if !ok {
+		return false
+	}
+	latestByName, ok := commonAttributesByName(latest)
+	if !ok {
+		return false
+	}
+	if len(desiredByName) != len(latestByName) {
+		return false
+	}
+	for name, value := range desiredByName {
+		if latestValue, ok := latestByName[name]; !ok || latestValue != value {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeForCompare returns deep copies of a and b in which the values
+// that only differ by representation are made equal:
+//
+//   - destination fields unset on one side and set to the value Firehose
+//     uses by default on the other,
+//   - processors whose parameters only differ by order or by the defaults
+//     Firehose adds,
+//   - CommonAttributes that only differ by order.
+//
+// The copies may share values with each other and must only be used for
+// the comparison.
+func normalizeForCompare(a, b *resource) (*resource, *resource) {
+	a = &resource{ko: a.ko.DeepCopy()}
+	b = &resource{ko: b.ko.DeepCopy()}
+	normalizeDestinationDefaults(a, b)
+	if pa, pb := httpEndpointProcessingConfiguration(a), httpEndpointProcessingConfiguration(b); pa != nil && pb != nil && equalProcessors(pa.Processors, pb.Processors) {
+		pa.Processors = pb.Processors
+	}
+	if ra, rb := httpEndpointRequestConfiguration(a), httpEndpointRequestConfiguration(b); ra != nil && rb != nil && equalCommonAttributes(ra.CommonAttributes, rb.CommonAttributes) {
+		ra.CommonAttributes = rb.CommonAttributes
+	}
+	return a, b
+}
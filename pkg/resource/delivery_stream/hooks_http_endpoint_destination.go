@@ -123,11 +123,20 @@ func readProcessingConfiguration(specHttpEndpointDestConfig *svcapitypes.HTTPEnd
 		specHttpEndpointDestConfig.ProcessingConfiguration.Enabled = respProcessingConfiguration.Enabled
 	}
 	if respProcessingConfiguration.Processors != nil {
+		// LambdaRef isn't returned by Firehose, keep the references of the
+		// processors in the spec so that they can be cleared afterwards.
+		lambdaRefs := map[string]*svcapitypes.LambdaReference{}
+		for _, proc := range specHttpEndpointDestConfig.ProcessingConfiguration.Processors {
+			if proc != nil && proc.Type != nil && proc.LambdaRef != nil {
+				lambdaRefs[*proc.Type] = proc.LambdaRef
+			}
+		}
 		specHttpEndpointDestConfig.ProcessingConfiguration.Processors = make([]*svcapitypes.Processor, len(respProcessingConfiguration.Processors))
 		for i, proc := range respProcessingConfiguration.Processors {
 			specHttpEndpointDestConfig.ProcessingConfiguration.Processors[i] = &svcapitypes.Processor{}
 			if proc.Type != "" {
 				specHttpEndpointDestConfig.ProcessingConfiguration.Processors[i].Type = aws.String(string(proc.Type))
+				specHttpEndpointDestConfig.ProcessingConfiguration.Processors[i].LambdaRef = lambdaRefs[string(proc.Type)]
 			}
 			if proc.Parameters != nil {
 				specHttpEndpointDestConfig.ProcessingConfiguration.Processors[i].Parameters = make([]*svcapitypes.ProcessorParameter, len(proc.Parameters))
@@ -0,0 +1,184 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delivery_stream
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	svcsdk "github.com/aws/aws-sdk-go-v2/service/firehose"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files of the description readers")
+
+// describeTestdata holds recorded DescribeDeliveryStream responses, <name>.json,
+// and the spec read from each of them, <name>.spec.golden.json.
+const describeTestdata = "testdata/describe"
+
+// recordedDescription decodes a recorded DescribeDeliveryStream response
+// with the SDK client, as the controller receives it.
+func recordedDescription(t *testing.T, path string) *svcsdk.DescribeDeliveryStreamOutput {
+	t.Helper()
+	body, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		w.Write(body)
+	}))
+	defer server.Close()
+	client := svcsdk.NewFromConfig(aws.Config{
+		Region:       "us-west-2",
+		Credentials:  aws.AnonymousCredentials{},
+		BaseEndpoint: aws.String(server.URL),
+	})
+	resp, err := client.DescribeDeliveryStream(context.Background(), &svcsdk.DescribeDeliveryStreamInput{
+		DeliveryStreamName: aws.String("recorded"),
+	})
+	if err != nil {
+		t.Fatalf("decoding %s: %v", path, err)
+	}
+	return resp
+}
+
+// wireMembers returns the members of an SDK shape as they are sent over the
+// wire. Unset members and empty lists, which the service treats alike, are
+// left out.
+func wireMembers(t *testing.T, shape any) map[string]any {
+	t.Helper()
+	b, err := json.Marshal(shape)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var members map[string]any
+	if err := json.Unmarshal(b, &members); err != nil {
+		t.Fatal(err)
+	}
+	return pruneUnset(members).(map[string]any)
+}
+
+func pruneUnset(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			value = pruneUnset(value)
+			if value == nil || value == "" {
+				delete(v, key)
+				continue
+			}
+			if list, ok := value.([]any); ok && len(list) == 0 {
+				delete(v, key)
+				continue
+			}
+			v[key] = value
+		}
+	case []any:
+		for i := range v {
+			v[i] = pruneUnset(v[i])
+		}
+	}
+	return v
+}
+
+// TestDestinationDescriptionRoundTrip reads recorded destination
+// descriptions into a spec, compares the spec with its golden file, and
+// checks that the UpdateDestination request built from the spec carries
+// the whole description and that the delta of the spec with itself, as a
+// user would apply it, is empty. Run the test with -update to rewrite the
+// golden files after a deliberate change to the readers.
+func TestDestinationDescriptionRoundTrip(t *testing.T) {
+	recordings, err := filepath.Glob(filepath.Join(describeTestdata, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, recording := range recordings {
+		if strings.HasSuffix(recording, ".golden.json") {
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(recording), ".json")
+		t.Run(name, func(t *testing.T) {
+			resp := recordedDescription(t, recording)
+			latest := &resource{ko: &svcapitypes.DeliveryStream{}}
+			latest.ko.Spec.DeliveryStreamName = resp.DeliveryStreamDescription.DeliveryStreamName
+			latest.ko.Status.DestinationID = resp.DeliveryStreamDescription.Destinations[0].DestinationId
+			if err := setDestinations(latest.ko, resp); err != nil {
+				t.Fatalf("setDestinations() error = %v", err)
+			}
+
+			// The spec read from the description matches the golden file.
+			got, err := json.MarshalIndent(latest.ko.Spec, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, '\n')
+			golden := filepath.Join(describeTestdata, name+".spec.golden.json")
+			if *updateGolden {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("reading the golden file, run the test with -update to create it: %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("spec read from %s differs from %s:\ngot:\n%s\nwant:\n%s", recording, golden, got, want)
+			}
+
+			// The same spec applied by a user is in sync.
+			desired := &resource{ko: &svcapitypes.DeliveryStream{}}
+			if err := json.Unmarshal(want, &desired.ko.Spec); err != nil {
+				t.Fatalf("decoding %s: %v", golden, err)
+			}
+			delta := newResourceDelta(desired, latest)
+			if differences := delta.Differences; len(differences) > 0 {
+				for _, d := range differences {
+					t.Errorf("unexpected difference at %s", d.Path)
+				}
+			}
+
+			// UpdateDestination carries every member of the description.
+			rm := &resourceManager{}
+			input, err := rm.newUpdateRequestPayload(context.Background(), latest, delta)
+			if err != nil {
+				t.Fatalf("newUpdateRequestPayload() error = %v", err)
+			}
+			description := wireMembers(t, resp.DeliveryStreamDescription.Destinations[0].HttpEndpointDestinationDescription)
+			description["S3Update"] = description["S3DestinationDescription"]
+			delete(description, "S3DestinationDescription")
+			update := wireMembers(t, input.HttpEndpointDestinationUpdate)
+			if !reflect.DeepEqual(update, description) {
+				d, _ := json.MarshalIndent(description, "", "  ")
+				u, _ := json.MarshalIndent(update, "", "  ")
+				t.Errorf("the UpdateDestination request doesn't match the description:\ngot:\n%s\nwant:\n%s", u, d)
+			}
+			if got := aws.ToString(input.DestinationId); got != aws.ToString(latest.ko.Status.DestinationID) {
+				t.Errorf("DestinationId = %q, want %q", got, aws.ToString(latest.ko.Status.DestinationID))
+			}
+		})
+	}
+}
@@ -0,0 +1,347 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delivery_stream
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	ackerr "github.com/aws-controllers-k8s/runtime/pkg/errors"
+	ackmetrics "github.com/aws-controllers-k8s/runtime/pkg/metrics"
+	ackrequeue "github.com/aws-controllers-k8s/runtime/pkg/requeue"
+	awsv2 "github.com/aws/aws-sdk-go-v2/aws"
+	svcsdk "github.com/aws/aws-sdk-go-v2/service/firehose"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/smithy-go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+	svcconfig "github.com/aws-controllers-k8s/firehose-controller/pkg/config"
+	"github.com/aws-controllers-k8s/firehose-controller/pkg/fakefirehose"
+)
+
+// lifecycle drives a resource manager backed by the in-memory Firehose
+// fake, with a clock the test advances past the state transitions.
+type lifecycle struct {
+	t    *testing.T
+	ctx  context.Context
+	now  time.Time
+	rm   *resourceManager
+	fake *fakefirehose.Firehose
+}
+
+func newLifecycle(t *testing.T) *lifecycle {
+	l := &lifecycle{
+		t:   t,
+		ctx: context.Background(),
+		now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	l.fake = fakefirehose.New(fakefirehose.Options{
+		AccountID:       "123456789012",
+		TransitionDelay: time.Minute,
+		Now:             func() time.Time { return l.now },
+	})
+	server := httptest.NewServer(fakefirehose.Handler(l.fake))
+	t.Cleanup(server.Close)
+	l.rm = &resourceManager{
+		metrics: ackmetrics.NewMetrics("firehose"),
+		sdkapi: svcsdk.NewFromConfig(awsv2.Config{
+			Region:           "us-west-2",
+			Credentials:      awsv2.AnonymousCredentials{},
+			BaseEndpoint:     awsv2.String(server.URL),
+			RetryMaxAttempts: 1,
+		}),
+	}
+	return l
+}
+
+// settle advances the clock past any pending state transition.
+func (l *lifecycle) settle() {
+	l.now = l.now.Add(time.Hour)
+}
+
+func (l *lifecycle) find(r *resource) *resource {
+	l.t.Helper()
+	latest, err := l.rm.sdkFind(l.ctx, r)
+	if err != nil {
+		l.t.Fatalf("sdkFind() error = %v", err)
+	}
+	return latest
+}
+
+// update applies mutate to a copy of latest and calls sdkUpdate with it.
+func (l *lifecycle) update(latest *resource, mutate func(*svcapitypes.DeliveryStream)) (*resource, error) {
+	desired := &resource{latest.ko.DeepCopy()}
+	mutate(desired.ko)
+	return l.rm.sdkUpdate(l.ctx, desired, latest, newResourceDelta(desired, latest))
+}
+
+// create creates a delivery stream with a minimal destination and waits for
+// it to be ACTIVE.
+func (l *lifecycle) create() *resource {
+	l.t.Helper()
+	created, err := l.rm.sdkCreate(l.ctx, &resource{
+		ko: &svcapitypes.DeliveryStream{
+			ObjectMeta: metav1.ObjectMeta{Name: "stream", Namespace: "default"},
+			Spec: svcapitypes.DeliveryStreamSpec{
+				DeliveryStreamName:                   aws.String("stream"),
+				HTTPEndpointDestinationConfiguration: minimalHTTPEndpointDestination(),
+			},
+		},
+	})
+	if err != nil {
+		l.t.Fatalf("sdkCreate() error = %v", err)
+	}
+	l.settle()
+	return l.find(created)
+}
+
+func expectStatus(t *testing.T, r *resource, want string) {
+	t.Helper()
+	if got := aws.StringValue(r.ko.Status.DeliveryStreamStatus); got != want {
+		t.Fatalf("DeliveryStreamStatus = %q, want %q", got, want)
+	}
+}
+
+func expectEncryptionStatus(t *testing.T, r *resource, want string) {
+	t.Helper()
+	if got := aws.StringValue(r.ko.Status.DeliveryStreamEncryptionConfigurationStatus); got != want {
+		t.Fatalf("DeliveryStreamEncryptionConfigurationStatus = %q, want %q", got, want)
+	}
+}
+
+func expectErrorCode(t *testing.T, err error, want string) {
+	t.Helper()
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode() != want {
+		t.Fatalf("error = %v, want %s", err, want)
+	}
+}
+
+func expectRequeue(t *testing.T, err error) {
+	t.Helper()
+	var requeueNeeded *ackrequeue.RequeueNeeded
+	var requeueNeededAfter *ackrequeue.RequeueNeededAfter
+	if !errors.As(err, &requeueNeeded) && !errors.As(err, &requeueNeededAfter) {
+		t.Fatalf("error = %v, want a requeue", err)
+	}
+}
+
+func TestLifecycleCreate(t *testing.T) {
+	l := newLifecycle(t)
+	desired := &resource{
+		ko: &svcapitypes.DeliveryStream{
+			Spec: svcapitypes.DeliveryStreamSpec{
+				DeliveryStreamName:                   aws.String("stream"),
+				HTTPEndpointDestinationConfiguration: minimalHTTPEndpointDestination(),
+				Tags: []*svcapitypes.Tag{
+					{Key: aws.String("team"), Value: aws.String("data")},
+				},
+			},
+		},
+	}
+	created, err := l.rm.sdkCreate(l.ctx, desired)
+	if err != nil {
+		t.Fatalf("sdkCreate() error = %v", err)
+	}
+	if got, want := string(*created.ko.Status.ACKResourceMetadata.ARN), "arn:aws:firehose:us-west-2:123456789012:deliverystream/stream"; got != want {
+		t.Errorf("ARN = %q, want %q", got, want)
+	}
+
+	latest := l.find(created)
+	expectStatus(t, latest, "CREATING")
+	if _, err := l.update(latest, func(ko *svcapitypes.DeliveryStream) {}); err != requeueWhileCreating {
+		t.Errorf("sdkUpdate() while creating error = %v, want %v", err, requeueWhileCreating)
+	}
+
+	l.settle()
+	latest = l.find(created)
+	expectStatus(t, latest, "ACTIVE")
+	if got := aws.StringValue(latest.ko.Status.VersionID); got != "1" {
+		t.Errorf("VersionID = %q, want 1", got)
+	}
+	if got := aws.StringValue(latest.ko.Status.DestinationID); got == "" {
+		t.Error("DestinationID is not set")
+	}
+	if len(latest.ko.Spec.Tags) != 1 || aws.StringValue(latest.ko.Spec.Tags[0].Value) != "data" {
+		t.Errorf("Tags = %v, want team=data", latest.ko.Spec.Tags)
+	}
+	// The described destination, with its service defaults, must not be
+	// reported as a difference from the spec it was created with.
+	if delta := newResourceDelta(desired, latest); delta.DifferentAt("Spec.HTTPEndpointDestinationConfiguration") {
+		t.Errorf("unexpected destination difference: %v", delta.Differences)
+	}
+
+	_, err = l.rm.sdkCreate(l.ctx, desired)
+	expectErrorCode(t, err, "ResourceInUseException")
+}
+
+func TestLifecycleUpdate(t *testing.T) {
+	l := newLifecycle(t)
+	latest := l.create()
+
+	updated, err := l.update(latest, func(ko *svcapitypes.DeliveryStream) {
+		ko.Spec.HTTPEndpointDestinationConfiguration.BufferingHints = &svcapitypes.HTTPEndpointBufferingHints{
+			IntervalInSeconds: aws.Int64(60),
+			SizeInMBs:         aws.Int64(1),
+		}
+		ko.Spec.Tags = []*svcapitypes.Tag{{Key: aws.String("team"), Value: aws.String("data")}}
+	})
+	if err != nil {
+		t.Fatalf("sdkUpdate() error = %v", err)
+	}
+	current := l.find(updated)
+	if got := aws.StringValue(current.ko.Status.VersionID); got != "2" {
+		t.Errorf("VersionID = %q, want 2", got)
+	}
+	hints := current.ko.Spec.HTTPEndpointDestinationConfiguration.BufferingHints
+	if aws.Int64Value(hints.IntervalInSeconds) != 60 || aws.Int64Value(hints.SizeInMBs) != 1 {
+		t.Errorf("BufferingHints = %d s, %d MB, want 60 s, 1 MB", aws.Int64Value(hints.IntervalInSeconds), aws.Int64Value(hints.SizeInMBs))
+	}
+	if len(current.ko.Spec.Tags) != 1 {
+		t.Errorf("Tags = %v, want team=data", current.ko.Spec.Tags)
+	}
+	if delta := newResourceDelta(updated, current); delta.DifferentAt("Spec") {
+		t.Errorf("unexpected difference after update: %v", delta.Differences)
+	}
+
+	// An update based on a stale description is rejected by the service.
+	_, err = l.update(latest, func(ko *svcapitypes.DeliveryStream) {
+		ko.Spec.HTTPEndpointDestinationConfiguration.RetryOptions = &svcapitypes.HTTPEndpointRetryOptions{
+			DurationInSeconds: aws.Int64(60),
+		}
+	})
+	expectErrorCode(t, err, "ConcurrentModificationException")
+}
+
+func TestLifecycleEncryption(t *testing.T) {
+	l := newLifecycle(t)
+	latest := l.create()
+	expectEncryptionStatus(t, latest, "DISABLED")
+
+	_, err := l.update(latest, func(ko *svcapitypes.DeliveryStream) {
+		ko.Spec.DeliveryStreamEncryptionConfiguration = &svcapitypes.DeliveryStreamEncryptionConfigurationInput{
+			KeyType: aws.String("AWS_OWNED_CMK"),
+		}
+	})
+	expectRequeue(t, err)
+	latest = l.find(latest)
+	expectEncryptionStatus(t, latest, "ENABLING")
+	if _, err := l.update(latest, func(ko *svcapitypes.DeliveryStream) {}); err != requeueWhileEncryptionEnabling {
+		t.Errorf("sdkUpdate() while enabling error = %v, want %v", err, requeueWhileEncryptionEnabling)
+	}
+	l.settle()
+	latest = l.find(latest)
+	expectEncryptionStatus(t, latest, "ENABLED")
+	if got := aws.StringValue(latest.ko.Spec.DeliveryStreamEncryptionConfiguration.KeyType); got != "AWS_OWNED_CMK" {
+		t.Errorf("KeyType = %q, want AWS_OWNED_CMK", got)
+	}
+
+	_, err = l.update(latest, func(ko *svcapitypes.DeliveryStream) {
+		ko.Spec.DeliveryStreamEncryptionConfiguration = nil
+	})
+	expectRequeue(t, err)
+	latest = l.find(latest)
+	expectEncryptionStatus(t, latest, "DISABLING")
+	if _, err := l.update(latest, func(ko *svcapitypes.DeliveryStream) {}); err != requeueWhileEncryptionDisabling {
+		t.Errorf("sdkUpdate() while disabling error = %v, want %v", err, requeueWhileEncryptionDisabling)
+	}
+	l.settle()
+	latest = l.find(latest)
+	expectEncryptionStatus(t, latest, "DISABLED")
+	if got := latest.ko.Spec.DeliveryStreamEncryptionConfiguration; got != nil && got.KeyType != nil {
+		t.Errorf("KeyType = %q, want none", *got.KeyType)
+	}
+}
+
+func TestLifecycleDelete(t *testing.T) {
+	l := newLifecycle(t)
+	latest := l.create()
+
+	l.fake.FailNext("DeleteDeliveryStream", errors.New("boom"))
+	if _, err := l.rm.sdkDelete(l.ctx, latest); err == nil {
+		t.Fatal("sdkDelete() with an injected failure returned no error")
+	}
+	expectStatus(t, l.find(latest), "ACTIVE")
+
+	if _, err := l.rm.sdkDelete(l.ctx, latest); err != nil {
+		t.Fatalf("sdkDelete() error = %v", err)
+	}
+	expectStatus(t, l.find(latest), "DELETING")
+
+	l.settle()
+	if _, err := l.rm.sdkFind(l.ctx, latest); err != ackerr.NotFound {
+		t.Fatalf("sdkFind() after deletion error = %v, want %v", err, ackerr.NotFound)
+	}
+	_, err := l.rm.sdkDelete(l.ctx, latest)
+	expectErrorCode(t, err, "ResourceNotFoundException")
+}
+
+func TestLifecycleTakeOverThenDeleteOldResource(t *testing.T) {
+	defer svcconfig.Set(svcconfig.Get())
+	cfg := svcconfig.Get()
+	cfg.ClusterID = "cluster"
+	svcconfig.Set(cfg)
+
+	l := newLifecycle(t)
+	newOwner := func(uid types.UID, annotations map[string]string) *resource {
+		return &resource{ko: &svcapitypes.DeliveryStream{
+			ObjectMeta: metav1.ObjectMeta{Name: "stream", Namespace: "default", UID: uid, Annotations: annotations},
+			Spec: svcapitypes.DeliveryStreamSpec{
+				DeliveryStreamName:                   aws.String("stream"),
+				HTTPEndpointDestinationConfiguration: minimalHTTPEndpointDestination(),
+				Tags: []*svcapitypes.Tag{
+					{Key: aws.String(ownerTagKey), Value: aws.String("cluster/" + string(uid))},
+				},
+			},
+		}}
+	}
+
+	old := newOwner("old", nil)
+	if _, err := l.rm.sdkCreate(l.ctx, old); err != nil {
+		t.Fatalf("sdkCreate() error = %v", err)
+	}
+	l.settle()
+	l.find(old)
+
+	current := newOwner("new", nil)
+	if _, err := l.rm.sdkFind(l.ctx, current); !errors.Is(err, ErrDeliveryStreamOwnedElsewhere) {
+		t.Fatalf("sdkFind() of a delivery stream owned elsewhere error = %v, want %v", err, ErrDeliveryStreamOwnedElsewhere)
+	}
+	current = newOwner("new", map[string]string{svcapitypes.TakeOwnershipAnnotation: "true"})
+	latest := l.find(current)
+	if _, err := l.rm.sdkUpdate(l.ctx, current, latest, newResourceDelta(current, latest)); err != nil {
+		t.Fatalf("sdkUpdate() taking ownership error = %v", err)
+	}
+	if owner := tagValue(l.find(current).ko.Spec.Tags, ownerTagKey); owner != "cluster/new" {
+		t.Fatalf("owner tag = %q, want %q", owner, "cluster/new")
+	}
+
+	// The old custom resource no longer reads the delivery stream, but is
+	// released on deletion without deleting it.
+	if _, err := l.rm.sdkFind(l.ctx, old); !errors.Is(err, ErrDeliveryStreamOwnedElsewhere) {
+		t.Fatalf("sdkFind() by the previous owner error = %v, want %v", err, ErrDeliveryStreamOwnedElsewhere)
+	}
+	now := metav1.Now()
+	old.ko.DeletionTimestamp = &now
+	if _, err := l.rm.sdkDelete(l.ctx, l.find(old)); err != nil {
+		t.Fatalf("sdkDelete() by the previous owner error = %v", err)
+	}
+	l.settle()
+	expectStatus(t, l.find(current), "ACTIVE")
+}
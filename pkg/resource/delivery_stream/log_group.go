@@ -0,0 +1,69 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delivery_stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	ackrequeue "github.com/aws-controllers-k8s/runtime/pkg/requeue"
+
+	"github.com/aws-controllers-k8s/firehose-controller/pkg/cloudwatchlogs"
+	svcconfig "github.com/aws-controllers-k8s/firehose-controller/pkg/config"
+)
+
+// ErrLogGroupNotFound is returned, with the validate-log-groups option set,
+// when a delivery stream logs to a log group that doesn't exist.
+var ErrLogGroupNotFound = errors.New("CloudWatch Logs log group not found")
+
+// requeueWaitLogGroup is how long the creation or update of a delivery
+// stream waits for a missing log group to be created.
+const requeueWaitLogGroup = 30 * time.Second
+
+// validateLogGroups returns a requeue error if, with the validate-log-groups
+// option set, logging is enabled for a destination of desired but its log
+// group doesn't exist. Firehose accepts such a configuration and silently
+// drops the delivery errors it should log.
+func (rm *resourceManager) validateLogGroups(
+	ctx context.Context,
+	desired *resource,
+) error {
+	if !svcconfig.Get().ValidateLogGroups {
+		return nil
+	}
+	// The client is built from the AWS configuration of rm, so that it uses
+	// the account, region, endpoint and retryer the delivery stream is managed
+	// with.
+	client := cloudwatchlogs.New(rm.clientcfg)
+	for _, f := range cloudWatchLoggingOptions(desired.ko) {
+		if f.opts.Enabled == nil || !*f.opts.Enabled ||
+			f.opts.LogGroupName == nil || *f.opts.LogGroupName == "" {
+			continue
+		}
+		exists, err := client.LogGroupExists(ctx, *f.opts.LogGroupName)
+		rm.metrics.RecordAPICall("READ_MANY", "DescribeLogGroups", err)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ackrequeue.NeededAfter(
+				fmt.Errorf("%w: %s.LogGroupName %q", ErrLogGroupNotFound, f.path, *f.opts.LogGroupName),
+				requeueWaitLogGroup,
+			)
+		}
+	}
+	return nil
+}
@@ -50,7 +50,7 @@ var (
 // +kubebuilder:rbac:groups=firehose.services.k8s.aws,resources=deliverystreams,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=firehose.services.k8s.aws,resources=deliverystreams/status,verbs=get;update;patch
 
-var lateInitializeFieldNames = []string{"DeliveryStreamEncryptionConfiguration", "HTTPEndpointDestinationConfiguration", "BufferingHints", "CloudWatchLoggingOptions", "EndpointConfiguration", "ProcessingConfiguration", "RequestConfiguration", "RetryOptions", "RoleARN", "S3BackupMode", "S3Configuration", "BufferingHints", "CloudWatchLoggingOptions", "CompressionFormat", "EncryptionConfiguration", "ErrorOutputPrefix", "Prefix", "SecretsManagerConfiguration"}
+var lateInitializeFieldNames = []string{"DeliveryStreamEncryptionConfiguration", "HTTPEndpointDestinationConfiguration", "BufferingHints", "IntervalInSeconds", "SizeInMBs", "CloudWatchLoggingOptions", "Enabled", "EndpointConfiguration", "ProcessingConfiguration", "RequestConfiguration", "ContentEncoding", "RetryOptions", "DurationInSeconds", "RoleARN", "S3BackupMode", "S3Configuration", "CompressionFormat", "EncryptionConfiguration", "ErrorOutputPrefix", "Prefix", "SecretsManagerConfiguration"}
 
 // resourceManager is responsible for providing a consistent way to perform
 // CRUD operations in a backend AWS service API for Book custom resources.
@@ -277,11 +277,32 @@ func (rm *resourceManager) lateInitializeFromReadOneOutput(
 			latestKo.Spec.HTTPEndpointDestinationConfiguration.BufferingHints = observedKo.Spec.HTTPEndpointDestinationConfiguration.BufferingHints
 		}
 	}
+	if observedKo.Spec.HTTPEndpointDestinationConfiguration != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration != nil {
+		if observedKo.Spec.HTTPEndpointDestinationConfiguration.BufferingHints != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration.BufferingHints != nil {
+			if observedKo.Spec.HTTPEndpointDestinationConfiguration.BufferingHints.IntervalInSeconds != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration.BufferingHints.IntervalInSeconds == nil {
+				latestKo.Spec.HTTPEndpointDestinationConfiguration.BufferingHints.IntervalInSeconds = observedKo.Spec.HTTPEndpointDestinationConfiguration.BufferingHints.IntervalInSeconds
+			}
+		}
+	}
+	if observedKo.Spec.HTTPEndpointDestinationConfiguration != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration != nil {
+		if observedKo.Spec.HTTPEndpointDestinationConfiguration.BufferingHints != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration.BufferingHints != nil {
+			if observedKo.Spec.HTTPEndpointDestinationConfiguration.BufferingHints.SizeInMBs != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration.BufferingHints.SizeInMBs == nil {
+				latestKo.Spec.HTTPEndpointDestinationConfiguration.BufferingHints.SizeInMBs = observedKo.Spec.HTTPEndpointDestinationConfiguration.BufferingHints.SizeInMBs
+			}
+		}
+	}
 	if observedKo.Spec.HTTPEndpointDestinationConfiguration != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration != nil {
 		if observedKo.Spec.HTTPEndpointDestinationConfiguration.CloudWatchLoggingOptions != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration.CloudWatchLoggingOptions == nil {
 			latestKo.Spec.HTTPEndpointDestinationConfiguration.CloudWatchLoggingOptions = observedKo.Spec.HTTPEndpointDestinationConfiguration.CloudWatchLoggingOptions
 		}
 	}
+	if observedKo.Spec.HTTPEndpointDestinationConfiguration != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration != nil {
+		if observedKo.Spec.HTTPEndpointDestinationConfiguration.CloudWatchLoggingOptions != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration.CloudWatchLoggingOptions != nil {
+			if observedKo.Spec.HTTPEndpointDestinationConfiguration.CloudWatchLoggingOptions.Enabled != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration.CloudWatchLoggingOptions.Enabled == nil {
+				latestKo.Spec.HTTPEndpointDestinationConfiguration.CloudWatchLoggingOptions.Enabled = observedKo.Spec.HTTPEndpointDestinationConfiguration.CloudWatchLoggingOptions.Enabled
+			}
+		}
+	}
 	if observedKo.Spec.HTTPEndpointDestinationConfiguration != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration != nil {
 		if observedKo.Spec.HTTPEndpointDestinationConfiguration.EndpointConfiguration != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration.EndpointConfiguration == nil {
 			latestKo.Spec.HTTPEndpointDestinationConfiguration.EndpointConfiguration = observedKo.Spec.HTTPEndpointDestinationConfiguration.EndpointConfiguration
@@ -292,16 +313,37 @@ func (rm *resourceManager) lateInitializeFromReadOneOutput(
 			latestKo.Spec.HTTPEndpointDestinationConfiguration.ProcessingConfiguration = observedKo.Spec.HTTPEndpointDestinationConfiguration.ProcessingConfiguration
 		}
 	}
+	if observedKo.Spec.HTTPEndpointDestinationConfiguration != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration != nil {
+		if observedKo.Spec.HTTPEndpointDestinationConfiguration.ProcessingConfiguration != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration.ProcessingConfiguration != nil {
+			if observedKo.Spec.HTTPEndpointDestinationConfiguration.ProcessingConfiguration.Enabled != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration.ProcessingConfiguration.Enabled == nil {
+				latestKo.Spec.HTTPEndpointDestinationConfiguration.ProcessingConfiguration.Enabled = observedKo.Spec.HTTPEndpointDestinationConfiguration.ProcessingConfiguration.Enabled
+			}
+		}
+	}
 	if observedKo.Spec.HTTPEndpointDestinationConfiguration != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration != nil {
 		if observedKo.Spec.HTTPEndpointDestinationConfiguration.RequestConfiguration != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration.RequestConfiguration == nil {
 			latestKo.Spec.HTTPEndpointDestinationConfiguration.RequestConfiguration = observedKo.Spec.HTTPEndpointDestinationConfiguration.RequestConfiguration
 		}
 	}
+	if observedKo.Spec.HTTPEndpointDestinationConfiguration != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration != nil {
+		if observedKo.Spec.HTTPEndpointDestinationConfiguration.RequestConfiguration != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration.RequestConfiguration != nil {
+			if observedKo.Spec.HTTPEndpointDestinationConfiguration.RequestConfiguration.ContentEncoding != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration.RequestConfiguration.ContentEncoding == nil {
+				latestKo.Spec.HTTPEndpointDestinationConfiguration.RequestConfiguration.ContentEncoding = observedKo.Spec.HTTPEndpointDestinationConfiguration.RequestConfiguration.ContentEncoding
+			}
+		}
+	}
 	if observedKo.Spec.HTTPEndpointDestinationConfiguration != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration != nil {
 		if observedKo.Spec.HTTPEndpointDestinationConfiguration.RetryOptions != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration.RetryOptions == nil {
 			latestKo.Spec.HTTPEndpointDestinationConfiguration.RetryOptions = observedKo.Spec.HTTPEndpointDestinationConfiguration.RetryOptions
 		}
 	}
+	if observedKo.Spec.HTTPEndpointDestinationConfiguration != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration != nil {
+		if observedKo.Spec.HTTPEndpointDestinationConfiguration.RetryOptions != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration.RetryOptions != nil {
+			if observedKo.Spec.HTTPEndpointDestinationConfiguration.RetryOptions.DurationInSeconds != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration.RetryOptions.DurationInSeconds == nil {
+				latestKo.Spec.HTTPEndpointDestinationConfiguration.RetryOptions.DurationInSeconds = observedKo.Spec.HTTPEndpointDestinationConfiguration.RetryOptions.DurationInSeconds
+			}
+		}
+	}
 	if observedKo.Spec.HTTPEndpointDestinationConfiguration != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration != nil {
 		if observedKo.Spec.HTTPEndpointDestinationConfiguration.RoleARN != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration.RoleARN == nil {
 			latestKo.Spec.HTTPEndpointDestinationConfiguration.RoleARN = observedKo.Spec.HTTPEndpointDestinationConfiguration.RoleARN
@@ -324,6 +366,24 @@ func (rm *resourceManager) lateInitializeFromReadOneOutput(
 			}
 		}
 	}
+	if observedKo.Spec.HTTPEndpointDestinationConfiguration != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration != nil {
+		if observedKo.Spec.HTTPEndpointDestinationConfiguration.S3Configuration != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration.S3Configuration != nil {
+			if observedKo.Spec.HTTPEndpointDestinationConfiguration.S3Configuration.BufferingHints != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration.S3Configuration.BufferingHints != nil {
+				if observedKo.Spec.HTTPEndpointDestinationConfiguration.S3Configuration.BufferingHints.IntervalInSeconds != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration.S3Configuration.BufferingHints.IntervalInSeconds == nil {
+					latestKo.Spec.HTTPEndpointDestinationConfiguration.S3Configuration.BufferingHints.IntervalInSeconds = observedKo.Spec.HTTPEndpointDestinationConfiguration.S3Configuration.BufferingHints.IntervalInSeconds
+				}
+			}
+		}
+	}
+	if observedKo.Spec.HTTPEndpointDestinationConfiguration != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration != nil {
+		if observedKo.Spec.HTTPEndpointDestinationConfiguration.S3Configuration != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration.S3Configuration != nil {
+			if observedKo.Spec.HTTPEndpointDestinationConfiguration.S3Configuration.BufferingHints != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration.S3Configuration.BufferingHints != nil {
+				if observedKo.Spec.HTTPEndpointDestinationConfiguration.S3Configuration.BufferingHints.SizeInMBs != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration.S3Configuration.BufferingHints.SizeInMBs == nil {
+					latestKo.Spec.HTTPEndpointDestinationConfiguration.S3Configuration.BufferingHints.SizeInMBs = observedKo.Spec.HTTPEndpointDestinationConfiguration.S3Configuration.BufferingHints.SizeInMBs
+				}
+			}
+		}
+	}
 	if observedKo.Spec.HTTPEndpointDestinationConfiguration != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration != nil {
 		if observedKo.Spec.HTTPEndpointDestinationConfiguration.S3Configuration != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration.S3Configuration != nil {
 			if observedKo.Spec.HTTPEndpointDestinationConfiguration.S3Configuration.CloudWatchLoggingOptions != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration.S3Configuration.CloudWatchLoggingOptions == nil {
@@ -331,6 +391,15 @@ func (rm *resourceManager) lateInitializeFromReadOneOutput(
 			}
 		}
 	}
+	if observedKo.Spec.HTTPEndpointDestinationConfiguration != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration != nil {
+		if observedKo.Spec.HTTPEndpointDestinationConfiguration.S3Configuration != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration.S3Configuration != nil {
+			if observedKo.Spec.HTTPEndpointDestinationConfiguration.S3Configuration.CloudWatchLoggingOptions != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration.S3Configuration.CloudWatchLoggingOptions != nil {
+				if observedKo.Spec.HTTPEndpointDestinationConfiguration.S3Configuration.CloudWatchLoggingOptions.Enabled != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration.S3Configuration.CloudWatchLoggingOptions.Enabled == nil {
+					latestKo.Spec.HTTPEndpointDestinationConfiguration.S3Configuration.CloudWatchLoggingOptions.Enabled = observedKo.Spec.HTTPEndpointDestinationConfiguration.S3Configuration.CloudWatchLoggingOptions.Enabled
+				}
+			}
+		}
+	}
 	if observedKo.Spec.HTTPEndpointDestinationConfiguration != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration != nil {
 		if observedKo.Spec.HTTPEndpointDestinationConfiguration.S3Configuration != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration.S3Configuration != nil {
 			if observedKo.Spec.HTTPEndpointDestinationConfiguration.S3Configuration.CompressionFormat != nil && latestKo.Spec.HTTPEndpointDestinationConfiguration.S3Configuration.CompressionFormat == nil {
@@ -414,7 +483,15 @@ func (rm *resourceManager) EnsureTags(
 	var existingTags []*svcapitypes.Tag
 	existingTags = r.ko.Spec.Tags
 	resourceTags, keyOrder := convertToOrderedACKTags(existingTags)
+	// The tags the controller manages, the owner tag and the labels listed in
+	// the propagate-label-keys option, are set last so that neither the spec
+	// nor the default tags override them. See setControllerTags for the
+	// precedence rules.
+	ignoreControllerTags(resourceTags)
 	tags := acktags.Merge(resourceTags, defaultTags)
+	if err := setControllerTags(ctx, tags, r.ko); err != nil {
+		return err
+	}
 	r.ko.Spec.Tags = fromACKTags(tags, keyOrder)
 	return nil
 }
@@ -447,6 +524,8 @@ func (rm *resourceManager) FilterSystemTags(res acktypes.AWSResource, systemTags
 	existingTags = r.ko.Spec.Tags
 	resourceTags, tagKeyOrder := convertToOrderedACKTags(existingTags)
 	ignoreSystemTags(resourceTags, systemTags)
+	// The tags the controller manages are not shown in the spec either.
+	ignoreControllerTags(resourceTags)
 	r.ko.Spec.Tags = fromACKTags(resourceTags, tagKeyOrder)
 }
 
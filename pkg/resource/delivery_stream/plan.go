@@ -0,0 +1,365 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delivery_stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	ackv1alpha1 "github.com/aws-controllers-k8s/runtime/apis/core/v1alpha1"
+	ackcfg "github.com/aws-controllers-k8s/runtime/pkg/config"
+	ackerr "github.com/aws-controllers-k8s/runtime/pkg/errors"
+	ackmetrics "github.com/aws-controllers-k8s/runtime/pkg/metrics"
+	ackrt "github.com/aws-controllers-k8s/runtime/pkg/runtime"
+	acktypes "github.com/aws-controllers-k8s/runtime/pkg/types"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	svcsdk "github.com/aws/aws-sdk-go-v2/service/firehose"
+	svcsdktypes "github.com/aws/aws-sdk-go-v2/service/firehose/types"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlreconcile "sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+)
+
+// redactedAccessKey replaces the HTTP endpoint access key in the inputs of
+// the planned calls.
+const redactedAccessKey = "<redacted>"
+
+// ErrPlanNoAPIReader is returned when planning the reconciliation of a
+// DeliveryStream that refers to Kubernetes resources or Secrets without a
+// reader to get them from.
+var ErrPlanNoAPIReader = errors.New("no Kubernetes API reader to resolve references and Secrets with")
+
+// controllerAnnotations lists the annotations the controller records on a
+// DeliveryStream, which a manifest doesn't carry but the reconciliation of
+// the custom resource stored in the cluster depends on.
+var controllerAnnotations = []string{
+	svcapitypes.LastAppliedSpecAnnotation,
+	svcapitypes.AccessKeyHashAnnotation,
+}
+
+// PlanOptions configures how Plan previews the reconciliation of a
+// DeliveryStream.
+type PlanOptions struct {
+	// APIReader gets the resources and Secrets the DeliveryStream refers
+	// to, the labels of its namespace and the DeliveryStream as stored in
+	// the cluster, whose UID and controller annotations are used when
+	// present. Planning fails if the DeliveryStream has references or an
+	// access key and APIReader is nil.
+	APIReader client.Reader
+	// Metadata describes the controller, for the default resource tags.
+	Metadata acktypes.ServiceControllerMetadata
+}
+
+// PlannedCall is a Firehose API call the controller would make.
+type PlannedCall struct {
+	// Operation is the name of the Firehose API operation.
+	Operation string
+	// Input is the input of the call, with the access key redacted.
+	Input interface{}
+}
+
+// DeliveryStreamPlan describes what the controller would do to reconcile a
+// DeliveryStream.
+type DeliveryStreamPlan struct {
+	// Create is true when the delivery stream doesn't exist and would be
+	// created.
+	Create bool
+	// Differences lists the paths of the fields that differ between the
+	// DeliveryStream and the live delivery stream.
+	Differences []string
+	// Calls lists the Firehose API calls that would be made, in order.
+	Calls []PlannedCall
+}
+
+// Plan previews the reconciliation of the supplied DeliveryStream by the
+// controller configured with cfg, which has been validated, and clientcfg.
+// References are resolved and the live delivery stream is read as the
+// controller does, then the create or update is run against a Firehose
+// client that records the calls that change the delivery stream instead of
+// making them.
+//
+// Updating the encryption of a delivery stream requeues the reconciliation,
+// the rest of the update is then planned as if the encryption change had
+// completed.
+func Plan(
+	ctx context.Context,
+	cfg ackcfg.Config,
+	clientcfg aws.Config,
+	ko *svcapitypes.DeliveryStream,
+	opts PlanOptions,
+) (*DeliveryStreamPlan, error) {
+	rm, err := newResourceManager(
+		cfg, clientcfg, logr.Discard(), ackmetrics.NewMetrics("firehose"), nil,
+		ackv1alpha1.AWSAccountID(cfg.AccountID), ackv1alpha1.AWSRegion(cfg.Region),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return plan(ctx, rm, ko, opts)
+}
+
+func plan(
+	ctx context.Context,
+	base *resourceManager,
+	ko *svcapitypes.DeliveryStream,
+	opts PlanOptions,
+) (*DeliveryStreamPlan, error) {
+	apiReader := opts.APIReader
+	if apiReader == nil {
+		apiReader = noAPIReader{}
+	}
+	recorder := &callRecorder{}
+	rm := *base
+	rm.sdkapi = recorder.client(base.sdkapi)
+	rm.rr = &planReconciler{cfg: rm.cfg, apiReader: apiReader, namespace: ko.Namespace}
+
+	desired := &resource{ko.DeepCopy()}
+	if err := mergeStoredDeliveryStream(ctx, opts.APIReader, desired.ko); err != nil {
+		return nil, err
+	}
+	// The tags are always read back, the live delivery stream may carry
+	// other tags than those last synced.
+	clearTagsHash(desired.ko)
+
+	res, _, err := rm.ResolveReferences(ctx, apiReader, desired)
+	if err != nil {
+		return nil, fmt.Errorf("resolving references: %w", err)
+	}
+	resolved := rm.concreteResource(res)
+	if err := rm.EnsureTags(ctx, resolved, opts.Metadata); err != nil {
+		return nil, err
+	}
+
+	p := &DeliveryStreamPlan{}
+	res, err = rm.ReadOne(ctx, resolved)
+	if err == ackerr.NotFound {
+		p.Create = true
+		if _, err := rm.sdkCreate(ctx, resolved); err != nil {
+			return nil, err
+		}
+		p.Calls = recorder.calls
+		return p, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading the delivery stream: %w", err)
+	}
+	latest := rm.concreteResource(res)
+	if ackrt.AdoptionPolicy(resolved.ko.GetAnnotations()[ackv1alpha1.AnnotationAdoptionPolicy]) == ackrt.AdoptionPolicy_Adopt {
+		rm.FilterSystemTags(latest, rm.cfg.ResourceTagKeys)
+	}
+
+	delta := newResourceDelta(resolved, latest)
+	for _, d := range delta.Differences {
+		p.Differences = append(p.Differences, differencePath(d))
+	}
+	if !delta.DifferentAt("Spec") {
+		return p, nil
+	}
+	_, err = rm.sdkUpdate(ctx, &resource{resolved.ko.DeepCopy()}, latest, delta)
+	if err != nil && recorder.changedEncryption() {
+		latest = assumeEncryptionUpdated(resolved, latest)
+		delta = newResourceDelta(resolved, latest)
+		_, err = rm.sdkUpdate(ctx, &resource{resolved.ko.DeepCopy()}, latest, delta)
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.Calls = recorder.calls
+	return p, nil
+}
+
+// mergeStoredDeliveryStream copies to ko the UID and the controller
+// annotations of the DeliveryStream of the same name stored in the cluster,
+// if any, so that ownership, drift and access key rotations are evaluated
+// as they are for that custom resource.
+func mergeStoredDeliveryStream(
+	ctx context.Context,
+	apiReader client.Reader,
+	ko *svcapitypes.DeliveryStream,
+) error {
+	if apiReader == nil || ko.Name == "" {
+		return nil
+	}
+	stored := &svcapitypes.DeliveryStream{}
+	err := apiReader.Get(ctx, types.NamespacedName{Namespace: ko.Namespace, Name: ko.Name}, stored)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("getting DeliveryStream %s/%s: %w", ko.Namespace, ko.Name, err)
+	}
+	ko.UID = stored.UID
+	annotations := ko.GetAnnotations()
+	for _, key := range controllerAnnotations {
+		value, ok := stored.GetAnnotations()[key]
+		if _, set := annotations[key]; !ok || set {
+			continue
+		}
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[key] = value
+	}
+	ko.SetAnnotations(annotations)
+	return nil
+}
+
+// assumeEncryptionUpdated returns a copy of latest whose encryption matches
+// desired, as it does once StartDeliveryStreamEncryption or
+// StopDeliveryStreamEncryption completes.
+func assumeEncryptionUpdated(desired *resource, latest *resource) *resource {
+	ko := latest.ko.DeepCopy()
+	ko.Spec.DeliveryStreamEncryptionConfiguration = desired.ko.Spec.DeliveryStreamEncryptionConfiguration.DeepCopy()
+	status := svcsdktypes.DeliveryStreamEncryptionStatusDisabled
+	if enc := ko.Spec.DeliveryStreamEncryptionConfiguration; enc != nil && enc.KeyType != nil {
+		status = svcsdktypes.DeliveryStreamEncryptionStatusEnabled
+	}
+	ko.Status.DeliveryStreamEncryptionConfigurationStatus = aws.String(string(status))
+	return &resource{ko}
+}
+
+// callRecorder records the calls of a Firehose client that would change a
+// delivery stream, in place of making them. The calls that only read are
+// sent as usual.
+type callRecorder struct {
+	calls []PlannedCall
+}
+
+// client returns a copy of c whose calls that would change a delivery stream
+// are recorded by the recorder.
+func (r *callRecorder) client(c *svcsdk.Client) *svcsdk.Client {
+	return svcsdk.New(c.Options(), func(o *svcsdk.Options) {
+		o.APIOptions = append(slices.Clone(o.APIOptions), func(stack *middleware.Stack) error {
+			return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("RecordPlannedCall", r.handleInitialize), middleware.After)
+		})
+	})
+}
+
+// handleInitialize records the input of the calls that would change a
+// delivery stream and returns an empty output for them without sending
+// them.
+func (r *callRecorder) handleInitialize(
+	ctx context.Context,
+	in middleware.InitializeInput,
+	next middleware.InitializeHandler,
+) (middleware.InitializeOutput, middleware.Metadata, error) {
+	var output interface{}
+	switch params := in.Parameters.(type) {
+	case *svcsdk.CreateDeliveryStreamInput:
+		if dest := params.HttpEndpointDestinationConfiguration; dest != nil && dest.EndpointConfiguration != nil && dest.EndpointConfiguration.AccessKey != nil {
+			dest.EndpointConfiguration.AccessKey = aws.String(redactedAccessKey)
+		}
+		output = &svcsdk.CreateDeliveryStreamOutput{}
+	case *svcsdk.UpdateDestinationInput:
+		if dest := params.HttpEndpointDestinationUpdate; dest != nil && dest.EndpointConfiguration != nil && dest.EndpointConfiguration.AccessKey != nil {
+			dest.EndpointConfiguration.AccessKey = aws.String(redactedAccessKey)
+		}
+		output = &svcsdk.UpdateDestinationOutput{}
+	case *svcsdk.DeleteDeliveryStreamInput:
+		output = &svcsdk.DeleteDeliveryStreamOutput{}
+	case *svcsdk.StartDeliveryStreamEncryptionInput:
+		output = &svcsdk.StartDeliveryStreamEncryptionOutput{}
+	case *svcsdk.StopDeliveryStreamEncryptionInput:
+		output = &svcsdk.StopDeliveryStreamEncryptionOutput{}
+	case *svcsdk.TagDeliveryStreamInput:
+		output = &svcsdk.TagDeliveryStreamOutput{}
+	case *svcsdk.UntagDeliveryStreamInput:
+		output = &svcsdk.UntagDeliveryStreamOutput{}
+	default:
+		return next.HandleInitialize(ctx, in)
+	}
+	r.calls = append(r.calls, PlannedCall{Operation: awsmiddleware.GetOperationName(ctx), Input: in.Parameters})
+	return middleware.InitializeOutput{Result: output}, middleware.Metadata{}, nil
+}
+
+// changedEncryption returns true if the last recorded call starts or stops
+// the encryption of the delivery stream.
+func (r *callRecorder) changedEncryption() bool {
+	if len(r.calls) == 0 {
+		return false
+	}
+	switch r.calls[len(r.calls)-1].Operation {
+	case "StartDeliveryStreamEncryption", "StopDeliveryStreamEncryption":
+		return true
+	}
+	return false
+}
+
+// planReconciler is the acktypes.Reconciler of the resource manager used by
+// Plan. It only reads Secrets, the way the runtime's reconciler does while
+// reconciling a DeliveryStream of the supplied namespace.
+type planReconciler struct {
+	cfg       ackcfg.Config
+	apiReader client.Reader
+	namespace string
+}
+
+var _ acktypes.Reconciler = (*planReconciler)(nil)
+
+func (r *planReconciler) Reconcile(context.Context, ctrlreconcile.Request) (ctrlreconcile.Result, error) {
+	return ctrlreconcile.Result{}, errors.New("planReconciler doesn't reconcile resources")
+}
+
+func (r *planReconciler) SecretValueFromReference(
+	ctx context.Context,
+	ref *ackv1alpha1.SecretKeyReference,
+) (string, error) {
+	if ref == nil {
+		return "", nil
+	}
+	namespace, _, err := ackrt.ValidateCrossNamespaceReferenceString(
+		r.cfg.EnableCrossNamespace, r.namespace, ref.Namespace, ref.Name,
+	)
+	if err != nil {
+		return "", ackerr.NewTerminalError(err)
+	}
+	var secret corev1.Secret
+	if err := r.apiReader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, &secret); err != nil {
+		if errors.Is(err, ErrPlanNoAPIReader) {
+			return "", err
+		}
+		return "", ackerr.SecretNotFound
+	}
+	if secret.Type != corev1.SecretTypeOpaque {
+		return "", ackerr.SecretTypeNotSupported
+	}
+	if value, ok := secret.Data[ref.Key]; ok {
+		return string(value), nil
+	}
+	return "", ackerr.SecretNotFound
+}
+
+func (r *planReconciler) WriteToSecret(context.Context, string, string, string, string) error {
+	return errors.New("planReconciler doesn't write Secrets")
+}
+
+// noAPIReader is the client.Reader used by Plan without an API reader.
+type noAPIReader struct{}
+
+func (noAPIReader) Get(_ context.Context, key client.ObjectKey, obj client.Object, _ ...client.GetOption) error {
+	return fmt.Errorf("%w: getting %T %s", ErrPlanNoAPIReader, obj, key)
+}
+
+func (noAPIReader) List(_ context.Context, list client.ObjectList, _ ...client.ListOption) error {
+	return fmt.Errorf("%w: listing %T", ErrPlanNoAPIReader, list)
+}
@@ -0,0 +1,140 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delivery_stream
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	ackv1alpha1 "github.com/aws-controllers-k8s/runtime/apis/core/v1alpha1"
+	svcsdk "github.com/aws/aws-sdk-go-v2/service/firehose"
+	"github.com/aws/aws-sdk-go/aws"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+)
+
+func planOperations(p *DeliveryStreamPlan) []string {
+	var ops []string
+	for _, c := range p.Calls {
+		ops = append(ops, c.Operation)
+	}
+	return ops
+}
+
+func TestPlanUpdate(t *testing.T) {
+	l := newLifecycle(t)
+	latest := l.create()
+
+	ko := latest.ko.DeepCopy()
+	ko.Spec.DeliveryStreamEncryptionConfiguration = &svcapitypes.DeliveryStreamEncryptionConfigurationInput{
+		KeyType: aws.String("AWS_OWNED_CMK"),
+	}
+	ko.Spec.Tags = []*svcapitypes.Tag{{Key: aws.String("team"), Value: aws.String("data")}}
+	ko.Spec.HTTPEndpointDestinationConfiguration.BufferingHints = &svcapitypes.HTTPEndpointBufferingHints{
+		IntervalInSeconds: aws.Int64(120),
+		SizeInMBs:         aws.Int64(10),
+	}
+
+	p, err := plan(l.ctx, l.rm, ko, PlanOptions{})
+	if err != nil {
+		t.Fatalf("plan() error = %v", err)
+	}
+	if p.Create {
+		t.Error("Create = true, want an update")
+	}
+	want := []string{"StartDeliveryStreamEncryption", "TagDeliveryStream", "UpdateDestination"}
+	if got := planOperations(p); !reflect.DeepEqual(got, want) {
+		t.Fatalf("calls = %v, want %v", got, want)
+	}
+	input := p.Calls[2].Input.(*svcsdk.UpdateDestinationInput)
+	if input.CurrentDeliveryStreamVersionId == nil || input.DestinationId == nil {
+		t.Errorf("UpdateDestination input = %+v, want the version and destination IDs of the live delivery stream", input)
+	}
+	if hints := input.HttpEndpointDestinationUpdate.BufferingHints; hints == nil || hints.IntervalInSeconds == nil || *hints.IntervalInSeconds != 120 {
+		t.Errorf("BufferingHints = %+v, want the desired hints", hints)
+	}
+
+	// Nothing is changed by planning.
+	after := l.find(latest)
+	if delta := newResourceDelta(latest, after); len(delta.Differences) != 0 {
+		for _, d := range delta.Differences {
+			t.Errorf("delivery stream changed at %s", differencePath(d))
+		}
+	}
+}
+
+func TestPlanNoChange(t *testing.T) {
+	l := newLifecycle(t)
+	latest := l.create()
+
+	p, err := plan(l.ctx, l.rm, latest.ko, PlanOptions{})
+	if err != nil {
+		t.Fatalf("plan() error = %v", err)
+	}
+	if len(p.Differences) != 0 || len(p.Calls) != 0 {
+		t.Errorf("plan = %+v, want no differences and no calls", p)
+	}
+}
+
+func TestPlanCreate(t *testing.T) {
+	l := newLifecycle(t)
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = svcapitypes.AddToScheme(scheme)
+	kc := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "endpoint"},
+		Type:       corev1.SecretTypeOpaque,
+		Data:       map[string][]byte{"accessKey": []byte("s3cr3t")},
+	}).Build()
+
+	ko := &svcapitypes.DeliveryStream{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "new-stream"},
+		Spec: svcapitypes.DeliveryStreamSpec{
+			DeliveryStreamName:                   aws.String("new-stream"),
+			HTTPEndpointDestinationConfiguration: minimalHTTPEndpointDestination(),
+		},
+	}
+	ko.Spec.HTTPEndpointDestinationConfiguration.EndpointConfiguration.AccessKey = &ackv1alpha1.SecretKeyReference{
+		SecretReference: corev1.SecretReference{Name: "endpoint"},
+		Key:             "accessKey",
+	}
+
+	if _, err := plan(l.ctx, l.rm, ko, PlanOptions{}); !errors.Is(err, ErrPlanNoAPIReader) {
+		t.Errorf("plan() without an API reader error = %v, want %v", err, ErrPlanNoAPIReader)
+	}
+
+	p, err := plan(l.ctx, l.rm, ko, PlanOptions{APIReader: kc})
+	if err != nil {
+		t.Fatalf("plan() error = %v", err)
+	}
+	if !p.Create {
+		t.Error("Create = false, want a create")
+	}
+	if got, want := planOperations(p), []string{"CreateDeliveryStream"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("calls = %v, want %v", got, want)
+	}
+	input := p.Calls[0].Input.(*svcsdk.CreateDeliveryStreamInput)
+	if got := aws.StringValue(input.HttpEndpointDestinationConfiguration.EndpointConfiguration.AccessKey); got != redactedAccessKey {
+		t.Errorf("AccessKey = %q, want %q", got, redactedAccessKey)
+	}
+	if _, err := l.rm.sdkFind(l.ctx, &resource{ko}); err == nil {
+		t.Error("delivery stream was created")
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delivery_stream
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	ackv1alpha1 "github.com/aws-controllers-k8s/runtime/apis/core/v1alpha1"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+	svcconfig "github.com/aws-controllers-k8s/firehose-controller/pkg/config"
+)
+
+// ErrReferenceAccountNotAllowed is returned when a reference resolves to a
+// resource of an account that is neither the account of the delivery stream
+// nor listed in its reference-owner-accounts annotation.
+var ErrReferenceAccountNotAllowed = errors.New("referenced resource belongs to an account that is not allowed")
+
+var accountIDRegexp = regexp.MustCompile(`^[0-9]{12}$`)
+
+// referenceOwnerAccounts returns the set of AWS account IDs listed in the
+// reference-owner-accounts annotation of ko.
+func referenceOwnerAccounts(ko *svcapitypes.DeliveryStream) (map[string]bool, error) {
+	value, ok := ko.GetAnnotations()[svcapitypes.ReferenceOwnerAccountsAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	accounts := map[string]bool{}
+	for _, id := range strings.Split(value, ",") {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		if !accountIDRegexp.MatchString(id) {
+			return nil, fmt.Errorf(
+				"invalid AWS account ID %q in the %s annotation",
+				id, svcapitypes.ReferenceOwnerAccountsAnnotation,
+			)
+		}
+		accounts[id] = true
+	}
+	return accounts, nil
+}
+
+// referenceAccountsRestricted returns whether the accounts of the references
+// of ko are checked: when ko carries the reference-owner-accounts annotation
+// or the controller runs with --restrict-reference-accounts. Otherwise the
+// references may resolve to resources of any account, as they do with CARM.
+func referenceAccountsRestricted(ko *svcapitypes.DeliveryStream) bool {
+	if _, ok := ko.GetAnnotations()[svcapitypes.ReferenceOwnerAccountsAnnotation]; ok {
+		return true
+	}
+	return svcconfig.Get().RestrictReferenceAccounts
+}
+
+// validateReferenceOwnerAccounts validates the reference-owner-accounts
+// annotation of ko.
+func validateReferenceOwnerAccounts(ko *svcapitypes.DeliveryStream) error {
+	_, err := referenceOwnerAccounts(ko)
+	return err
+}
+
+// referencedResourceOwnerAccount returns the account the referenced ACK
+// resource belongs to: the account of its owner-account-id annotation if it
+// has one, otherwise the owner account recorded in its status.
+func referencedResourceOwnerAccount(obj *unstructured.Unstructured) string {
+	if owner := obj.GetAnnotations()[ackv1alpha1.AnnotationOwnerAccountID]; owner != "" {
+		return owner
+	}
+	owner, _, _ := unstructured.NestedString(obj.Object, "status", "ackResourceMetadata", "ownerAccountID")
+	return owner
+}
+
+// validateReferencedResourceAccount returns an ErrReferenceAccountNotAllowed
+// error if the ACK resource referenced from ko belongs to an account other
+// than the account of the delivery stream that isn't listed in the
+// reference-owner-accounts annotation of ko, or if its ARN is not in the
+// account it belongs to. Nothing is checked unless the accounts of the
+// references of ko are restricted.
+func (rm *resourceManager) validateReferencedResourceAccount(
+	ko *svcapitypes.DeliveryStream,
+	obj *unstructured.Unstructured,
+) error {
+	if !referenceAccountsRestricted(ko) {
+		return nil
+	}
+	owner := referencedResourceOwnerAccount(obj)
+	if owner == "" {
+		return nil
+	}
+	if owner != string(rm.awsAccountID) {
+		allowed, err := referenceOwnerAccounts(ko)
+		if err != nil {
+			return err
+		}
+		if !allowed[owner] {
+			return fmt.Errorf(
+				"%w: %s %s/%s belongs to account %s, which is not listed in the %s annotation",
+				ErrReferenceAccountNotAllowed, obj.GetKind(), obj.GetNamespace(), obj.GetName(),
+				owner, svcapitypes.ReferenceOwnerAccountsAnnotation,
+			)
+		}
+	}
+	resourceARN, _, _ := unstructured.NestedString(obj.Object, "status", "ackResourceMetadata", "arn")
+	if parsed, err := arn.Parse(resourceARN); err == nil && parsed.AccountID != "" && parsed.AccountID != owner {
+		return fmt.Errorf(
+			"%w: %s %s/%s belongs to account %s but its ARN %s is in account %s",
+			ErrReferenceAccountNotAllowed, obj.GetKind(), obj.GetNamespace(), obj.GetName(),
+			owner, resourceARN, parsed.AccountID,
+		)
+	}
+	return nil
+}
+
+// validateReferenceAccounts validates that every ARN a reference of ko
+// resolved to is in the account of the delivery stream, ownAccount, or in an
+// account listed in the reference-owner-accounts annotation of ko. ARNs that
+// carry no account, like those of S3 buckets, are not checked, nor is
+// anything unless the accounts of the references of ko are restricted.
+func validateReferenceAccounts(ko *svcapitypes.DeliveryStream, ownAccount string) error {
+	if !referenceAccountsRestricted(ko) {
+		return nil
+	}
+	allowed, err := referenceOwnerAccounts(ko)
+	if err != nil {
+		return err
+	}
+	for _, f := range referenceFields(ko) {
+		if f.target == nil || *f.target == nil {
+			continue
+		}
+		parsed, err := arn.Parse(**f.target)
+		if err != nil || parsed.AccountID == "" {
+			continue
+		}
+		if parsed.AccountID == ownAccount || allowed[parsed.AccountID] {
+			continue
+		}
+		return fmt.Errorf(
+			"%w: %s resolved to %s, which is in account %s. Add the account to the %s annotation to allow it",
+			ErrReferenceAccountNotAllowed, f.path, **f.target,
+			parsed.AccountID, svcapitypes.ReferenceOwnerAccountsAnnotation,
+		)
+	}
+	return nil
+}
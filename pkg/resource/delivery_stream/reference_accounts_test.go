@@ -0,0 +1,156 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delivery_stream
+
+import (
+	"errors"
+	"testing"
+
+	ackv1alpha1 "github.com/aws-controllers-k8s/runtime/apis/core/v1alpha1"
+	"github.com/aws/aws-sdk-go/aws"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+	svcconfig "github.com/aws-controllers-k8s/firehose-controller/pkg/config"
+)
+
+const (
+	workloadAccount = "111111111111"
+	loggingAccount  = "222222222222"
+)
+
+func newCrossAccountRole(ownerAnnotation, arn string) *unstructured.Unstructured {
+	obj := newReferencedObject(roleGroupVersionKind, "role", ackv1alpha1.ConditionTypeResourceSynced)
+	if ownerAnnotation != "" {
+		obj.SetAnnotations(map[string]string{ackv1alpha1.AnnotationOwnerAccountID: ownerAnnotation})
+	}
+	_ = unstructured.SetNestedField(obj.Object, arn, "status", "ackResourceMetadata", "arn")
+	return obj
+}
+
+func newCrossAccountResource(allowedAccounts string, roleARN *string) *resource {
+	r := newReferencesResource("role", "bucket")
+	if allowedAccounts != "" {
+		r.ko.SetAnnotations(map[string]string{
+			svcapitypes.ReferenceOwnerAccountsAnnotation: allowedAccounts,
+		})
+	}
+	r.ko.Spec.HTTPEndpointDestinationConfiguration.RoleARN = roleARN
+	return r
+}
+
+func TestValidateReferencedResourceAccount(t *testing.T) {
+	rm := &resourceManager{awsAccountID: workloadAccount}
+	loggingRoleARN := "arn:aws:iam::" + loggingAccount + ":role/firehose"
+
+	tests := []struct {
+		name            string
+		restricted      bool
+		allowedAccounts string
+		obj             *unstructured.Unstructured
+		expectErr       bool
+	}{
+		{
+			name: "resource of the delivery stream account",
+			obj:  newCrossAccountRole("", "arn:aws:iam::"+workloadAccount+":role/firehose"),
+		},
+		{
+			name: "resource of another account when the accounts are not restricted",
+			obj:  newCrossAccountRole(loggingAccount, loggingRoleARN),
+		},
+		{
+			name:       "resource of an account not listed in the annotation",
+			restricted: true,
+			obj:        newCrossAccountRole(loggingAccount, loggingRoleARN),
+			expectErr:  true,
+		},
+		{
+			name:            "resource of an account listed in the annotation",
+			allowedAccounts: "333333333333, " + loggingAccount,
+			obj:             newCrossAccountRole(loggingAccount, loggingRoleARN),
+		},
+		{
+			name:            "ARN outside of the annotated owner account",
+			allowedAccounts: loggingAccount,
+			obj:             newCrossAccountRole(loggingAccount, "arn:aws:iam::"+workloadAccount+":role/firehose"),
+			expectErr:       true,
+		},
+		{
+			name:            "invalid account in the annotation",
+			allowedAccounts: "logging",
+			obj:             newCrossAccountRole(loggingAccount, loggingRoleARN),
+			expectErr:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer svcconfig.Set(svcconfig.Get())
+			svcconfig.Set(svcconfig.Config{RestrictReferenceAccounts: tt.restricted})
+			r := newCrossAccountResource(tt.allowedAccounts, nil)
+			err := rm.validateReferencedResourceAccount(r.ko, tt.obj)
+			if tt.expectErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateReferenceAccounts(t *testing.T) {
+	tests := []struct {
+		name            string
+		restricted      bool
+		allowedAccounts string
+		roleARN         *string
+		expectErr       bool
+	}{
+		{
+			name: "unresolved reference",
+		},
+		{
+			name:    "ARN in the delivery stream account",
+			roleARN: aws.String("arn:aws:iam::" + workloadAccount + ":role/firehose"),
+		},
+		{
+			name:    "ARN in another account when the accounts are not restricted",
+			roleARN: aws.String("arn:aws:iam::" + loggingAccount + ":role/firehose"),
+		},
+		{
+			name:       "ARN in another account",
+			restricted: true,
+			roleARN:    aws.String("arn:aws:iam::" + loggingAccount + ":role/firehose"),
+			expectErr:  true,
+		},
+		{
+			name:            "ARN in an account listed in the annotation",
+			allowedAccounts: loggingAccount,
+			roleARN:         aws.String("arn:aws:iam::" + loggingAccount + ":role/firehose"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer svcconfig.Set(svcconfig.Get())
+			svcconfig.Set(svcconfig.Config{RestrictReferenceAccounts: tt.restricted})
+			r := newCrossAccountResource(tt.allowedAccounts, tt.roleARN)
+			err := validateReferenceAccounts(r.ko, workloadAccount)
+			if tt.expectErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.expectErr, err)
+			}
+			if err != nil && !errors.Is(err, ErrReferenceAccountNotAllowed) {
+				t.Errorf("expected ErrReferenceAccountNotAllowed, got %v", err)
+			}
+		})
+	}
+}
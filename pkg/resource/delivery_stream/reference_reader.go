@@ -0,0 +1,120 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delivery_stream
+
+import (
+	"context"
+
+	iamapitypes "github.com/aws-controllers-k8s/iam-controller/apis/v1alpha1"
+	kmsapitypes "github.com/aws-controllers-k8s/kms-controller/apis/v1alpha1"
+	s3apitypes "github.com/aws-controllers-k8s/s3-controller/apis/v1alpha1"
+	secretsmanagerapitypes "github.com/aws-controllers-k8s/secretsmanager-controller/apis/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// referenceReadKey identifies a referenced resource read by a
+// referenceReader.
+type referenceReadKey struct {
+	gvk schema.GroupVersionKind
+	types.NamespacedName
+}
+
+// referenceReader is a client.Reader that reads each referenced ACK resource
+// at most once. ResolveReferences reads the referenced resources through a
+// referenceReader, so that the objects read to report the state of the
+// references in Status.References are the ones the references are resolved
+// from. Other objects, and failed reads, are not remembered.
+type referenceReader struct {
+	client.Reader
+	objects map[referenceReadKey]*unstructured.Unstructured
+}
+
+func newReferenceReader(r client.Reader) *referenceReader {
+	return &referenceReader{
+		Reader:  r,
+		objects: map[referenceReadKey]*unstructured.Unstructured{},
+	}
+}
+
+// Get reads the object identified by key into obj, from the objects already
+// read if it is a referenced resource.
+func (r *referenceReader) Get(
+	ctx context.Context,
+	key client.ObjectKey,
+	obj client.Object,
+	opts ...client.GetOption,
+) error {
+	gvk, ok := referencedObjectKind(obj)
+	if !ok {
+		return r.Reader.Get(ctx, key, obj, opts...)
+	}
+	k := referenceReadKey{gvk: gvk, NamespacedName: key}
+	if read, ok := r.objects[k]; ok {
+		return fromUnstructured(read.DeepCopy(), obj)
+	}
+	if err := r.Reader.Get(ctx, key, obj, opts...); err != nil {
+		return err
+	}
+	u, err := toUnstructured(obj, gvk)
+	if err != nil {
+		return err
+	}
+	r.objects[k] = u
+	return nil
+}
+
+// referencedObjectKind returns the kind of a referenced resource, read either
+// as an unstructured object or as one of the types of the ACK controllers
+// the Firehose controller depends on. Returns false for any other object.
+func referencedObjectKind(obj client.Object) (schema.GroupVersionKind, bool) {
+	switch obj := obj.(type) {
+	case *unstructured.Unstructured:
+		gvk := obj.GroupVersionKind()
+		return gvk, gvk.Kind != ""
+	case *kmsapitypes.Key:
+		return keyGroupVersionKind, true
+	case *iamapitypes.Role:
+		return roleGroupVersionKind, true
+	case *s3apitypes.Bucket:
+		return bucketGroupVersionKind, true
+	case *secretsmanagerapitypes.Secret:
+		return secretGroupVersionKind, true
+	}
+	return schema.GroupVersionKind{}, false
+}
+
+func toUnstructured(obj client.Object, gvk schema.GroupVersionKind) (*unstructured.Unstructured, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return u.DeepCopy(), nil
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	u := &unstructured.Unstructured{Object: content}
+	u.SetGroupVersionKind(gvk)
+	return u, nil
+}
+
+func fromUnstructured(u *unstructured.Unstructured, obj client.Object) error {
+	if dst, ok := obj.(*unstructured.Unstructured); ok {
+		dst.Object = u.Object
+		return nil
+	}
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, obj)
+}
@@ -0,0 +1,226 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delivery_stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	iamapitypes "github.com/aws-controllers-k8s/iam-controller/apis/v1alpha1"
+	kmsapitypes "github.com/aws-controllers-k8s/kms-controller/apis/v1alpha1"
+	ackv1alpha1 "github.com/aws-controllers-k8s/runtime/apis/core/v1alpha1"
+	ackerr "github.com/aws-controllers-k8s/runtime/pkg/errors"
+	ackrequeue "github.com/aws-controllers-k8s/runtime/pkg/requeue"
+	ackrt "github.com/aws-controllers-k8s/runtime/pkg/runtime"
+	s3apitypes "github.com/aws-controllers-k8s/s3-controller/apis/v1alpha1"
+	secretsmanagerapitypes "github.com/aws-controllers-k8s/secretsmanager-controller/apis/v1alpha1"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+)
+
+// requeueWaitReferences is how long the reconciliation of a delivery stream
+// waits for its unresolved references. Changes to the referenced resources
+// are also watched, so this is only a fallback.
+const requeueWaitReferences = 30 * time.Second
+
+var (
+	keyGroupVersionKind    = kmsapitypes.GroupVersion.WithKind("Key")
+	roleGroupVersionKind   = iamapitypes.GroupVersion.WithKind("Role")
+	bucketGroupVersionKind = s3apitypes.GroupVersion.WithKind("Bucket")
+	secretGroupVersionKind = secretsmanagerapitypes.GroupVersion.WithKind("Secret")
+)
+
+// ReferencedGroupVersionKinds lists the kinds of the resources a
+// DeliveryStream can reference.
+var ReferencedGroupVersionKinds = []schema.GroupVersionKind{
+	keyGroupVersionKind,
+	roleGroupVersionKind,
+	bucketGroupVersionKind,
+	secretGroupVersionKind,
+	lambdaAPIGroupVersion.WithKind(string(svcapitypes.LambdaReferenceKindFunction)),
+	lambdaAPIGroupVersion.WithKind(string(svcapitypes.LambdaReferenceKindAlias)),
+	lambdaAPIGroupVersion.WithKind(string(svcapitypes.LambdaReferenceKindVersion)),
+	logGroupGroupVersionKind,
+}
+
+// referenceField is a resource reference of the spec.
+type referenceField struct {
+	// path is the path of the reference field, relative to the spec.
+	path string
+	gvk  schema.GroupVersionKind
+	from *ackv1alpha1.AWSResourceReference
+	// target is the field holding the ARN the reference resolves to, nil if
+	// the reference doesn't resolve to an ARN.
+	target **string
+}
+
+// referenceFields returns the resource references set in the spec of ko.
+func referenceFields(ko *svcapitypes.DeliveryStream) []referenceField {
+	var fields []referenceField
+	add := func(path string, gvk schema.GroupVersionKind, ref *ackv1alpha1.AWSResourceReferenceWrapper, target **string) {
+		if ref != nil && ref.From != nil {
+			fields = append(fields, referenceField{path: path, gvk: gvk, from: ref.From, target: target})
+		}
+	}
+
+	if ko.Spec.DeliveryStreamEncryptionConfiguration != nil {
+		add("DeliveryStreamEncryptionConfiguration.KeyRef", keyGroupVersionKind,
+			ko.Spec.DeliveryStreamEncryptionConfiguration.KeyRef,
+			&ko.Spec.DeliveryStreamEncryptionConfiguration.KeyARN)
+	}
+	dest := ko.Spec.HTTPEndpointDestinationConfiguration
+	if dest == nil {
+		return fields
+	}
+	for _, f := range cloudWatchLoggingOptions(ko) {
+		add(f.path+".LogGroupRef", logGroupGroupVersionKind, f.opts.LogGroupRef, nil)
+	}
+	for i, p := range httpEndpointProcessors(ko) {
+		if p != nil && p.LambdaRef != nil && p.LambdaRef.From != nil {
+			field := referenceField{
+				path: fmt.Sprintf("HTTPEndpointDestinationConfiguration.ProcessingConfiguration.Processors[%d].LambdaRef", i),
+				gvk:  lambdaAPIGroupVersion.WithKind(lambdaReferenceKind(p.LambdaRef)),
+				from: p.LambdaRef.From,
+			}
+			if param := processorParameter(p, lambdaArnParameter); param != nil {
+				field.target = &param.ParameterValue
+			}
+			fields = append(fields, field)
+		}
+	}
+	add("HTTPEndpointDestinationConfiguration.RoleRef", roleGroupVersionKind, dest.RoleRef, &dest.RoleARN)
+	if dest.S3Configuration != nil {
+		add("HTTPEndpointDestinationConfiguration.S3Configuration.BucketRef", bucketGroupVersionKind,
+			dest.S3Configuration.BucketRef, &dest.S3Configuration.BucketARN)
+		if dest.S3Configuration.EncryptionConfiguration != nil &&
+			dest.S3Configuration.EncryptionConfiguration.KMSEncryptionConfig != nil {
+			add("HTTPEndpointDestinationConfiguration.S3Configuration.EncryptionConfiguration.KMSEncryptionConfig.AWSKMSKeyRef", keyGroupVersionKind,
+				dest.S3Configuration.EncryptionConfiguration.KMSEncryptionConfig.AWSKMSKeyRef,
+				&dest.S3Configuration.EncryptionConfiguration.KMSEncryptionConfig.AWSKMSKeyARN)
+		}
+		add("HTTPEndpointDestinationConfiguration.S3Configuration.RoleRef", roleGroupVersionKind,
+			dest.S3Configuration.RoleRef, &dest.S3Configuration.RoleARN)
+	}
+	if dest.SecretsManagerConfiguration != nil {
+		add("HTTPEndpointDestinationConfiguration.SecretsManagerConfiguration.RoleRef", roleGroupVersionKind,
+			dest.SecretsManagerConfiguration.RoleRef, &dest.SecretsManagerConfiguration.RoleARN)
+		add("HTTPEndpointDestinationConfiguration.SecretsManagerConfiguration.SecretRef", secretGroupVersionKind,
+			dest.SecretsManagerConfiguration.SecretRef, &dest.SecretsManagerConfiguration.SecretARN)
+	}
+	return fields
+}
+
+// setReferenceStatuses records in Status.References the resolution state of
+// every resource reference of ko. Returns a boolean indicating whether ko
+// contains references and, if any of them can't be resolved, an error naming
+// each of them. The error requeues the resource unless a referenced resource
+// is in a terminal state, is in another namespace while cross-namespace
+// references are disabled, or belongs to an account it may not be
+// referenced from when the accounts of the references are restricted. The referenced resources are read through apiReader,
+// which ResolveReferences shares with the resolution of the references.
+func (rm *resourceManager) setReferenceStatuses(
+	ctx context.Context,
+	apiReader client.Reader,
+	ko *svcapitypes.DeliveryStream,
+) (hasReferences bool, err error) {
+	fields := referenceFields(ko)
+	if len(fields) == 0 {
+		ko.Status.References = nil
+		return false, nil
+	}
+
+	statuses := make([]*svcapitypes.ReferenceStatus, 0, len(fields))
+	var unresolved []string
+	requeue := true
+	for _, f := range fields {
+		var name string
+		if f.from.Name != nil {
+			name = *f.from.Name
+		}
+		// The namespace is resolved the way the references are, a resource of
+		// another namespace is only read if cross-namespace references are
+		// enabled.
+		namespace, nsErr := ackrt.ResolveCrossNamespaceReference(
+			ctx,
+			rm.cfg.EnableCrossNamespace,
+			&ko.Status.Conditions,
+			ackrt.CrossNamespaceRefKindResource,
+			ko.GetNamespace(),
+			f.from.Namespace,
+			name,
+		)
+		if nsErr != nil {
+			namespace = aws.ToString(f.from.Namespace)
+		}
+		status := &svcapitypes.ReferenceStatus{
+			Path:       aws.String(f.path),
+			APIVersion: aws.String(f.gvk.GroupVersion().String()),
+			Kind:       aws.String(f.gvk.Kind),
+			Namespace:  aws.String(namespace),
+			Name:       aws.String(name),
+			State:      aws.String(string(svcapitypes.ReferenceStateResolved)),
+		}
+		statuses = append(statuses, status)
+
+		err := nsErr
+		switch {
+		case name == "":
+			err = fmt.Errorf("provided resource reference is nil or empty")
+		case err == nil:
+			obj := &unstructured.Unstructured{}
+			obj.SetGroupVersionKind(f.gvk)
+			err = getReferencedResourceState_Unstructured(ctx, apiReader, obj, name, namespace)
+			if err == nil {
+				err = rm.validateReferencedResourceAccount(ko, obj)
+			}
+		}
+		if err == nil {
+			continue
+		}
+		state := svcapitypes.ReferenceStateUnresolved
+		switch {
+		case errors.Is(err, ackerr.ResourceReferenceTerminal):
+			state = svcapitypes.ReferenceStateNotSynced
+			requeue = false
+		case errors.Is(err, ackerr.ResourceReferenceNotSynced):
+			state = svcapitypes.ReferenceStateNotSynced
+		case errors.Is(err, ErrReferenceAccountNotAllowed),
+			errors.Is(err, ackerr.ResourceReferenceCrossNamespaceNotAllowed):
+			requeue = false
+		}
+		status.State = aws.String(string(state))
+		status.Message = aws.String(err.Error())
+		unresolved = append(unresolved, fmt.Sprintf("%s (%s %s/%s): %s", f.path, f.gvk.Kind, namespace, name, err))
+	}
+	ko.Status.References = statuses
+
+	if len(unresolved) == 0 {
+		return true, nil
+	}
+	err = fmt.Errorf(
+		"%d of %d references not resolved: %s",
+		len(unresolved), len(fields), strings.Join(unresolved, "; "),
+	)
+	if !requeue {
+		return true, err
+	}
+	return true, ackrequeue.NeededAfter(err, requeueWaitReferences)
+}
@@ -0,0 +1,280 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delivery_stream
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	iamapitypes "github.com/aws-controllers-k8s/iam-controller/apis/v1alpha1"
+	ackv1alpha1 "github.com/aws-controllers-k8s/runtime/apis/core/v1alpha1"
+	ackerr "github.com/aws-controllers-k8s/runtime/pkg/errors"
+	ackrequeue "github.com/aws-controllers-k8s/runtime/pkg/requeue"
+	s3apitypes "github.com/aws-controllers-k8s/s3-controller/apis/v1alpha1"
+	"github.com/aws/aws-sdk-go/aws"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+)
+
+func newReferencedObject(gvk schema.GroupVersionKind, name string, conditions ...ackv1alpha1.ConditionType) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	obj.SetNamespace("default")
+	obj.SetName(name)
+	conds := []interface{}{}
+	for _, c := range conditions {
+		conds = append(conds, map[string]interface{}{
+			"type":   string(c),
+			"status": "True",
+		})
+	}
+	obj.Object["status"] = map[string]interface{}{
+		"ackResourceMetadata": map[string]interface{}{
+			"arn": "arn:aws:iam::123456789012:role/" + name,
+		},
+		"conditions": conds,
+	}
+	return obj
+}
+
+func newReferencesResource(roleName, bucketName string) *resource {
+	r := newHTTPEndpointDestinationResource(&svcapitypes.HTTPEndpointDestinationConfiguration{
+		RoleRef: newResourceReference(roleName),
+		S3Configuration: &svcapitypes.S3DestinationConfiguration{
+			BucketRef: newResourceReference(bucketName),
+		},
+	})
+	r.ko.ObjectMeta = metav1.ObjectMeta{Namespace: "default", Name: "stream"}
+	return r
+}
+
+func TestSetReferenceStatuses(t *testing.T) {
+	kc := fake.NewClientBuilder().WithObjects(
+		newReferencedObject(roleGroupVersionKind, "role", ackv1alpha1.ConditionTypeResourceSynced),
+		newReferencedObject(bucketGroupVersionKind, "bucket", ackv1alpha1.ConditionTypeResourceSynced),
+		newReferencedObject(bucketGroupVersionKind, "pending"),
+		newReferencedObject(bucketGroupVersionKind, "failed", ackv1alpha1.ConditionTypeTerminal),
+	).Build()
+	rm := &resourceManager{}
+
+	tests := []struct {
+		name          string
+		r             *resource
+		expectStates  []svcapitypes.ReferenceState
+		expectErr     bool
+		expectRequeue bool
+	}{
+		{
+			name: "no references",
+			r:    newHTTPEndpointDestinationResource(&svcapitypes.HTTPEndpointDestinationConfiguration{}),
+		},
+		{
+			name: "all references resolved",
+			r:    newReferencesResource("role", "bucket"),
+			expectStates: []svcapitypes.ReferenceState{
+				svcapitypes.ReferenceStateResolved,
+				svcapitypes.ReferenceStateResolved,
+			},
+		},
+		{
+			name: "unsynced bucket",
+			r:    newReferencesResource("role", "pending"),
+			expectStates: []svcapitypes.ReferenceState{
+				svcapitypes.ReferenceStateResolved,
+				svcapitypes.ReferenceStateNotSynced,
+			},
+			expectErr:     true,
+			expectRequeue: true,
+		},
+		{
+			name: "missing role and unsynced bucket",
+			r:    newReferencesResource("missing", "pending"),
+			expectStates: []svcapitypes.ReferenceState{
+				svcapitypes.ReferenceStateUnresolved,
+				svcapitypes.ReferenceStateNotSynced,
+			},
+			expectErr:     true,
+			expectRequeue: true,
+		},
+		{
+			name: "terminal bucket is not requeued",
+			r:    newReferencesResource("role", "failed"),
+			expectStates: []svcapitypes.ReferenceState{
+				svcapitypes.ReferenceStateResolved,
+				svcapitypes.ReferenceStateNotSynced,
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hasReferences, err := rm.setReferenceStatuses(context.TODO(), kc, tt.r.ko)
+			if hasReferences != (len(tt.expectStates) > 0) {
+				t.Errorf("expected hasReferences %v, got %v", len(tt.expectStates) > 0, hasReferences)
+			}
+			if tt.expectErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.expectErr, err)
+			}
+			var requeue *ackrequeue.RequeueNeededAfter
+			if tt.expectRequeue != errors.As(err, &requeue) {
+				t.Errorf("expected requeue %v, got %v", tt.expectRequeue, err)
+			}
+
+			refs := tt.r.ko.Status.References
+			if len(refs) != len(tt.expectStates) {
+				t.Fatalf("expected %d reference statuses, got %d", len(tt.expectStates), len(refs))
+			}
+			for i, ref := range refs {
+				if *ref.State != string(tt.expectStates[i]) {
+					t.Errorf("expected %s to be %s, got %s", *ref.Path, tt.expectStates[i], *ref.State)
+				}
+				if (ref.Message != nil) != (tt.expectStates[i] != svcapitypes.ReferenceStateResolved) {
+					t.Errorf("unexpected message for %s: %v", *ref.Path, aws.StringValue(ref.Message))
+				}
+			}
+		})
+	}
+}
+
+// countingClient returns a client of objs and the number of Get calls made
+// through it, by kind.
+func countingClient(t *testing.T, objs ...client.Object) (client.Client, map[string]int) {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{
+		clientgoscheme.AddToScheme,
+		iamapitypes.AddToScheme,
+		s3apitypes.AddToScheme,
+	} {
+		if err := add(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gets := map[string]int{}
+	kc := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).WithInterceptorFuncs(interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			gvk, _ := apiutil.GVKForObject(obj, scheme)
+			gets[gvk.Kind]++
+			return c.Get(ctx, key, obj, opts...)
+		},
+	}).Build()
+	return kc, gets
+}
+
+func TestSetReferenceStatusesCrossNamespace(t *testing.T) {
+	role := newReferencedObject(roleGroupVersionKind, "role", ackv1alpha1.ConditionTypeResourceSynced)
+	role.SetNamespace("other")
+	kc, gets := countingClient(t, role)
+
+	tests := []struct {
+		name                 string
+		enableCrossNamespace bool
+		expectState          svcapitypes.ReferenceState
+		expectErr            bool
+		expectGets           int
+	}{
+		{
+			name:        "cross-namespace reference disabled is not read",
+			expectState: svcapitypes.ReferenceStateUnresolved,
+			expectErr:   true,
+		},
+		{
+			name:                 "cross-namespace reference enabled",
+			enableCrossNamespace: true,
+			expectState:          svcapitypes.ReferenceStateResolved,
+			expectGets:           1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k := range gets {
+				delete(gets, k)
+			}
+			rm := &resourceManager{}
+			rm.cfg.EnableCrossNamespace = tt.enableCrossNamespace
+			r := newHTTPEndpointDestinationResource(&svcapitypes.HTTPEndpointDestinationConfiguration{
+				RoleRef: &ackv1alpha1.AWSResourceReferenceWrapper{
+					From: &ackv1alpha1.AWSResourceReference{Name: aws.String("role"), Namespace: aws.String("other")},
+				},
+			})
+			r.ko.ObjectMeta = metav1.ObjectMeta{Namespace: "default", Name: "stream"}
+
+			_, err := rm.setReferenceStatuses(context.TODO(), kc, r.ko)
+			if tt.expectErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.expectErr, err)
+			}
+			var requeue *ackrequeue.RequeueNeededAfter
+			if errors.As(err, &requeue) {
+				t.Errorf("expected no requeue, got %v", err)
+			}
+			if got := *r.ko.Status.References[0].State; got != string(tt.expectState) {
+				t.Errorf("expected state %s, got %s", tt.expectState, got)
+			}
+			if gets["Role"] != tt.expectGets {
+				t.Errorf("expected %d reads of the role, got %d", tt.expectGets, gets["Role"])
+			}
+		})
+	}
+}
+
+func TestResolveReferencesReadsReferencesOnce(t *testing.T) {
+	kc, gets := countingClient(t,
+		newReferencedObject(roleGroupVersionKind, "role", ackv1alpha1.ConditionTypeResourceSynced),
+		newReferencedObject(bucketGroupVersionKind, "bucket", ackv1alpha1.ConditionTypeResourceSynced),
+	)
+	rm := &resourceManager{awsAccountID: "123456789012"}
+
+	t.Run("references are read once", func(t *testing.T) {
+		res, _, err := rm.ResolveReferences(context.TODO(), kc, newReferencesResource("role", "bucket"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if gets["Role"] != 1 || gets["Bucket"] != 1 {
+			t.Errorf("expected one read of each referenced resource, got %v", gets)
+		}
+		dest := res.(*resource).ko.Spec.HTTPEndpointDestinationConfiguration
+		if aws.StringValue(dest.RoleARN) != "arn:aws:iam::123456789012:role/role" {
+			t.Errorf("unexpected RoleARN %v", aws.StringValue(dest.RoleARN))
+		}
+	})
+
+	t.Run("invalid reference fields are reported before reading", func(t *testing.T) {
+		for k := range gets {
+			delete(gets, k)
+		}
+		r := newReferencesResource("role", "bucket")
+		r.ko.Spec.HTTPEndpointDestinationConfiguration.RoleARN = aws.String("arn:aws:iam::123456789012:role/other")
+		_, hasReferences, err := rm.ResolveReferences(context.TODO(), kc, r)
+		if !errors.Is(err, ackerr.ResourceReferenceAndIDNotSupported) {
+			t.Errorf("expected %v, got %v", ackerr.ResourceReferenceAndIDNotSupported, err)
+		}
+		if !hasReferences {
+			t.Error("expected resource to have references")
+		}
+		if len(gets) != 0 || r.ko.Status.References != nil {
+			t.Errorf("expected no reads and no reference statuses, got %v reads and %v", gets, r.ko.Status.References)
+		}
+	})
+}
@@ -119,6 +119,8 @@ func (rm *resourceManager) ClearResolvedReferences(res acktypes.AWSResource) ack
 		}
 	}
 
+	clearCustomResolvedReferences(ko)
+
 	return &resource{ko}
 }
 
@@ -135,6 +137,19 @@ func (rm *resourceManager) ResolveReferences(
 	res acktypes.AWSResource,
 ) (acktypes.AWSResource, bool, error) {
 	ko := rm.concreteResource(res).ko
+	// The reference fields are validated before any of them is resolved, and
+	// the referenced resources read once, to report their state and to resolve
+	// the references from them.
+	if err := validateReferenceFields(ko); err != nil {
+		return &resource{ko}, hasAnyReferences(ko), err
+	}
+	if err := validateCustomReferenceFields(ko); err != nil {
+		return &resource{ko}, hasAnyReferences(ko), err
+	}
+	apiReader = newReferenceReader(apiReader)
+	if fieldHasReferences, err := rm.setReferenceStatuses(ctx, apiReader, ko); err != nil {
+		return &resource{ko}, fieldHasReferences, err
+	}
 
 	resourceHasReferences := false
 	err := validateReferenceFields(ko)
@@ -180,6 +195,12 @@ func (rm *resourceManager) ResolveReferences(
 		resourceHasReferences = resourceHasReferences || fieldHasReferences
 	}
 
+	if err == nil {
+		fieldHasReferences, resolveErr := rm.resolveCustomReferences(ctx, apiReader, ko)
+		resourceHasReferences = resourceHasReferences || fieldHasReferences
+		err = resolveErr
+	}
+
 	return &resource{ko}, resourceHasReferences, err
 }
 
@@ -0,0 +1,81 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delivery_stream
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+)
+
+// The references the code generator doesn't know about, to log groups,
+// Lambda functions and through ValueFrom, are validated, resolved and cleared
+// from the references_pre_resolve, references_post_resolve and
+// references_post_clear hooks of the generated references.go.
+
+// validateCustomReferenceFields validates the reference fields that are not
+// generated, and the accounts the references may be owned by.
+func validateCustomReferenceFields(ko *svcapitypes.DeliveryStream) error {
+	if err := validateReferenceOwnerAccounts(ko); err != nil {
+		return err
+	}
+	if err := validateLogGroupReferenceFields(ko); err != nil {
+		return err
+	}
+	if err := validateLambdaReferenceFields(ko); err != nil {
+		return err
+	}
+	return validateValueFromFields(ko)
+}
+
+// hasAnyReferences returns true if ko contains resource references or
+// ValueFrom fields.
+func hasAnyReferences(ko *svcapitypes.DeliveryStream) bool {
+	return len(referenceFields(ko)) > 0 || len(valueFromFields(ko)) > 0
+}
+
+// resolveCustomReferences resolves the reference fields that are not
+// generated and checks the accounts of every resolved reference. Returns a
+// boolean indicating whether ko contains any of those references and an error
+// if they could not be resolved.
+func (rm *resourceManager) resolveCustomReferences(
+	ctx context.Context,
+	apiReader client.Reader,
+	ko *svcapitypes.DeliveryStream,
+) (hasReferences bool, err error) {
+	resolvers := []func(context.Context, client.Reader, *svcapitypes.DeliveryStream) (bool, error){
+		rm.resolveReferenceForHTTPEndpointDestinationConfiguration_CloudWatchLoggingOptions_LogGroupName,
+		rm.resolveReferenceForHTTPEndpointDestinationConfiguration_S3Configuration_CloudWatchLoggingOptions_LogGroupName,
+		rm.resolveReferenceForHTTPEndpointDestinationConfiguration_ProcessingConfiguration_Processors_LambdaARN,
+		rm.resolveValueFromReferences,
+	}
+	for _, resolve := range resolvers {
+		fieldHasReferences, err := resolve(ctx, apiReader, ko)
+		hasReferences = hasReferences || fieldHasReferences
+		if err != nil {
+			return hasReferences, err
+		}
+	}
+	return hasReferences, validateReferenceAccounts(ko, string(rm.awsAccountID))
+}
+
+// clearCustomResolvedReferences removes the values resolved from the
+// reference fields that are not generated.
+func clearCustomResolvedReferences(ko *svcapitypes.DeliveryStream) {
+	clearResolvedLogGroupReferences(ko)
+	clearResolvedLambdaReferences(ko)
+	clearResolvedValueFromReferences(ko)
+}
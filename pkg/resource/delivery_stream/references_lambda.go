@@ -0,0 +1,177 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delivery_stream
+
+import (
+	"context"
+	"fmt"
+
+	ackerr "github.com/aws-controllers-k8s/runtime/pkg/errors"
+	ackrt "github.com/aws-controllers-k8s/runtime/pkg/runtime"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	svcsdktypes "github.com/aws/aws-sdk-go-v2/service/firehose/types"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+)
+
+// The ACK Lambda controller API types are read as unstructured objects so
+// that the Firehose controller doesn't depend on the Lambda controller
+// module.
+
+// +kubebuilder:rbac:groups=lambda.services.k8s.aws,resources=functions;aliases;versions,verbs=get;list;watch
+// +kubebuilder:rbac:groups=lambda.services.k8s.aws,resources=functions/status;aliases/status;versions/status,verbs=get;list
+
+var (
+	lambdaAPIGroupVersion = schema.GroupVersion{
+		Group:   "lambda.services.k8s.aws",
+		Version: "v1alpha1",
+	}
+	lambdaProcessorType = string(svcsdktypes.ProcessorTypeLambda)
+	lambdaArnParameter  = string(svcsdktypes.ProcessorParameterNameLambdaArn)
+)
+
+// httpEndpointProcessors returns the processors of the HTTP endpoint
+// destination or nil if there are none.
+func httpEndpointProcessors(ko *svcapitypes.DeliveryStream) []*svcapitypes.Processor {
+	if ko.Spec.HTTPEndpointDestinationConfiguration == nil ||
+		ko.Spec.HTTPEndpointDestinationConfiguration.ProcessingConfiguration == nil {
+		return nil
+	}
+	return ko.Spec.HTTPEndpointDestinationConfiguration.ProcessingConfiguration.Processors
+}
+
+// lambdaReferenceKind returns the kind of the Lambda resource referenced by
+// ref, which defaults to Function.
+func lambdaReferenceKind(ref *svcapitypes.LambdaReference) string {
+	if ref.Kind == nil || *ref.Kind == "" {
+		return string(svcapitypes.LambdaReferenceKindFunction)
+	}
+	return *ref.Kind
+}
+
+// processorParameter returns the parameter with the supplied name or nil if
+// the processor doesn't have one.
+func processorParameter(p *svcapitypes.Processor, name string) *svcapitypes.ProcessorParameter {
+	for _, param := range p.Parameters {
+		if param != nil && param.ParameterName != nil && *param.ParameterName == name {
+			return param
+		}
+	}
+	return nil
+}
+
+// clearResolvedLambdaReferences removes the LambdaArn parameter from every
+// processor that sets a lambdaRef or a lambdaValueFrom.
+func clearResolvedLambdaReferences(ko *svcapitypes.DeliveryStream) {
+	for _, p := range httpEndpointProcessors(ko) {
+		if p == nil || (p.LambdaRef == nil && p.LambdaValueFrom == nil) {
+			continue
+		}
+		params := p.Parameters[:0:0]
+		for _, param := range p.Parameters {
+			if param != nil && param.ParameterName != nil && *param.ParameterName == lambdaArnParameter {
+				continue
+			}
+			params = append(params, param)
+		}
+		if len(params) == 0 {
+			params = nil
+		}
+		p.Parameters = params
+	}
+}
+
+// validateLambdaReferenceFields validates that processors setting a
+// lambdaRef are Lambda processors and don't also set the LambdaArn
+// parameter.
+func validateLambdaReferenceFields(ko *svcapitypes.DeliveryStream) error {
+	for i, p := range httpEndpointProcessors(ko) {
+		if p == nil || p.LambdaRef == nil {
+			continue
+		}
+		field := fmt.Sprintf("HTTPEndpointDestinationConfiguration.ProcessingConfiguration.Processors[%d]", i)
+		if p.Type == nil || *p.Type != lambdaProcessorType {
+			return fmt.Errorf("%s.LambdaRef is only supported for processors of type %s", field, lambdaProcessorType)
+		}
+		if processorParameter(p, lambdaArnParameter) != nil {
+			return ackerr.ResourceReferenceAndIDNotSupportedFor(
+				field+".Parameters."+lambdaArnParameter,
+				field+".LambdaRef",
+			)
+		}
+	}
+	return nil
+}
+
+// resolveReferenceForHTTPEndpointDestinationConfiguration_ProcessingConfiguration_Processors_LambdaARN
+// reads the Lambda resources referenced from the
+// HTTPEndpointDestinationConfiguration.ProcessingConfiguration.Processors.LambdaRef
+// fields and sets the LambdaArn parameter of each processor from the
+// referenced resource. Returns a boolean indicating whether a reference
+// contains references, or an error
+func (rm *resourceManager) resolveReferenceForHTTPEndpointDestinationConfiguration_ProcessingConfiguration_Processors_LambdaARN(
+	ctx context.Context,
+	apiReader client.Reader,
+	ko *svcapitypes.DeliveryStream,
+) (hasReferences bool, err error) {
+	for i, p := range httpEndpointProcessors(ko) {
+		if p == nil || p.LambdaRef == nil || p.LambdaRef.From == nil {
+			continue
+		}
+		hasReferences = true
+		arr := p.LambdaRef.From
+		if arr.Name == nil || *arr.Name == "" {
+			return hasReferences, fmt.Errorf(
+				"provided resource reference is nil or empty: HTTPEndpointDestinationConfiguration.ProcessingConfiguration.Processors[%d].LambdaRef", i,
+			)
+		}
+		namespace, err := ackrt.ResolveCrossNamespaceReference(
+			ctx,
+			rm.cfg.EnableCrossNamespace,
+			&ko.Status.Conditions,
+			ackrt.CrossNamespaceRefKindResource,
+			ko.ObjectMeta.GetNamespace(),
+			arr.Namespace,
+			*arr.Name,
+		)
+		if err != nil {
+			return hasReferences, err
+		}
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(lambdaAPIGroupVersion.WithKind(lambdaReferenceKind(p.LambdaRef)))
+		if err := getReferencedResourceState_Unstructured(ctx, apiReader, obj, *arr.Name, namespace); err != nil {
+			return hasReferences, err
+		}
+		arn, found, _ := unstructured.NestedString(obj.Object, "status", "ackResourceMetadata", "arn")
+		if !found || arn == "" {
+			return hasReferences, ackerr.ResourceReferenceMissingTargetFieldFor(
+				obj.GetKind(),
+				namespace, *arr.Name,
+				"Status.ACKResourceMetadata.ARN")
+		}
+		if param := processorParameter(p, lambdaArnParameter); param != nil {
+			param.ParameterValue = aws.String(arn)
+		} else {
+			p.Parameters = append(p.Parameters, &svcapitypes.ProcessorParameter{
+				ParameterName:  aws.String(lambdaArnParameter),
+				ParameterValue: aws.String(arn),
+			})
+		}
+	}
+
+	return hasReferences, nil
+}
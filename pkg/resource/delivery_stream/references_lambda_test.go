@@ -0,0 +1,137 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delivery_stream
+
+import (
+	"context"
+	"testing"
+
+	ackv1alpha1 "github.com/aws-controllers-k8s/runtime/apis/core/v1alpha1"
+	"github.com/aws/aws-sdk-go/aws"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+)
+
+func newLambdaObject(kind, name, arn string, synced bool) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(lambdaAPIGroupVersion.WithKind(kind))
+	obj.SetNamespace("default")
+	obj.SetName(name)
+	status := "False"
+	if synced {
+		status = "True"
+	}
+	obj.Object["status"] = map[string]interface{}{
+		"ackResourceMetadata": map[string]interface{}{
+			"arn": arn,
+		},
+		"conditions": []interface{}{
+			map[string]interface{}{
+				"type":   string(ackv1alpha1.ConditionTypeResourceSynced),
+				"status": status,
+			},
+		},
+	}
+	return obj
+}
+
+func newLambdaRefResource(kind *string, name string, params ...string) *resource {
+	r := newProcessingConfigurationResource(newProcessor("Lambda", params...))
+	r.ko.ObjectMeta = metav1.ObjectMeta{Namespace: "default", Name: "stream"}
+	r.ko.Spec.HTTPEndpointDestinationConfiguration.ProcessingConfiguration.Processors[0].LambdaRef = &svcapitypes.LambdaReference{
+		Kind: kind,
+		From: &ackv1alpha1.AWSResourceReference{Name: aws.String(name)},
+	}
+	return r
+}
+
+func TestResolveLambdaReferences(t *testing.T) {
+	functionARN := "arn:aws:lambda:us-east-1:123456789012:function:transform"
+	aliasARN := functionARN + ":live"
+	kc := fake.NewClientBuilder().WithObjects(
+		newLambdaObject("Function", "transform", functionARN, true),
+		newLambdaObject("Alias", "live", aliasARN, true),
+		newLambdaObject("Function", "pending", functionARN, false),
+	).Build()
+	rm := &resourceManager{awsAccountID: "123456789012"}
+
+	tests := []struct {
+		name      string
+		r         *resource
+		expectErr bool
+		expectARN string
+	}{
+		{
+			name:      "function reference sets the LambdaArn parameter",
+			r:         newLambdaRefResource(nil, "transform", "NumberOfRetries", "5"),
+			expectARN: functionARN,
+		},
+		{
+			name:      "alias reference sets the LambdaArn parameter",
+			r:         newLambdaRefResource(aws.String("Alias"), "live"),
+			expectARN: aliasARN,
+		},
+		{
+			name:      "unsynced function is not resolved",
+			r:         newLambdaRefResource(nil, "pending"),
+			expectErr: true,
+		},
+		{
+			name:      "missing function is not resolved",
+			r:         newLambdaRefResource(nil, "missing"),
+			expectErr: true,
+		},
+		{
+			name:      "reference and LambdaArn parameter are mutually exclusive",
+			r:         newLambdaRefResource(nil, "transform", "LambdaArn", functionARN),
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, hasReferences, err := rm.ResolveReferences(context.TODO(), kc, tt.r)
+			if !hasReferences && !tt.expectErr {
+				t.Errorf("expected resource to have references")
+			}
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			resolved := res.(*resource)
+			p := resolved.ko.Spec.HTTPEndpointDestinationConfiguration.ProcessingConfiguration.Processors[0]
+			param := processorParameter(p, "LambdaArn")
+			if param == nil || *param.ParameterValue != tt.expectARN {
+				t.Fatalf("expected LambdaArn parameter %q, got %v", tt.expectARN, param)
+			}
+
+			cleared := rm.ClearResolvedReferences(resolved).(*resource)
+			p = cleared.ko.Spec.HTTPEndpointDestinationConfiguration.ProcessingConfiguration.Processors[0]
+			if processorParameter(p, "LambdaArn") != nil {
+				t.Errorf("expected LambdaArn parameter to be cleared")
+			}
+			if p.LambdaRef == nil {
+				t.Errorf("expected LambdaRef to be kept")
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delivery_stream
+
+import (
+	"context"
+	"fmt"
+
+	ackerr "github.com/aws-controllers-k8s/runtime/pkg/errors"
+	ackrt "github.com/aws-controllers-k8s/runtime/pkg/runtime"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+)
+
+// The ACK CloudWatch Logs controller API types are read as unstructured
+// objects so that the Firehose controller doesn't depend on the CloudWatch
+// Logs controller module.
+
+// +kubebuilder:rbac:groups=cloudwatchlogs.services.k8s.aws,resources=loggroups,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cloudwatchlogs.services.k8s.aws,resources=loggroups/status,verbs=get;list
+
+var logGroupGroupVersionKind = schema.GroupVersionKind{
+	Group:   "cloudwatchlogs.services.k8s.aws",
+	Version: "v1alpha1",
+	Kind:    "LogGroup",
+}
+
+// loggingOptionsField is a CloudWatchLoggingOptions field of the spec.
+type loggingOptionsField struct {
+	// path is the path of the field, relative to the spec.
+	path string
+	opts *svcapitypes.CloudWatchLoggingOptions
+}
+
+// cloudWatchLoggingOptions returns the CloudWatchLoggingOptions set in the
+// spec of ko.
+func cloudWatchLoggingOptions(ko *svcapitypes.DeliveryStream) []loggingOptionsField {
+	dest := ko.Spec.HTTPEndpointDestinationConfiguration
+	if dest == nil {
+		return nil
+	}
+	var fields []loggingOptionsField
+	if dest.CloudWatchLoggingOptions != nil {
+		fields = append(fields, loggingOptionsField{
+			path: "HTTPEndpointDestinationConfiguration.CloudWatchLoggingOptions",
+			opts: dest.CloudWatchLoggingOptions,
+		})
+	}
+	if dest.S3Configuration != nil && dest.S3Configuration.CloudWatchLoggingOptions != nil {
+		fields = append(fields, loggingOptionsField{
+			path: "HTTPEndpointDestinationConfiguration.S3Configuration.CloudWatchLoggingOptions",
+			opts: dest.S3Configuration.CloudWatchLoggingOptions,
+		})
+	}
+	return fields
+}
+
+// clearResolvedLogGroupReferences removes the LogGroupName of every
+// CloudWatchLoggingOptions that sets a logGroupRef.
+func clearResolvedLogGroupReferences(ko *svcapitypes.DeliveryStream) {
+	for _, f := range cloudWatchLoggingOptions(ko) {
+		if f.opts.LogGroupRef != nil {
+			f.opts.LogGroupName = nil
+		}
+	}
+}
+
+// validateLogGroupReferenceFields validates that CloudWatchLoggingOptions
+// don't set both a logGroupRef and a LogGroupName.
+func validateLogGroupReferenceFields(ko *svcapitypes.DeliveryStream) error {
+	for _, f := range cloudWatchLoggingOptions(ko) {
+		if f.opts.LogGroupRef != nil && f.opts.LogGroupName != nil {
+			return ackerr.ResourceReferenceAndIDNotSupportedFor(
+				f.path+".LogGroupName",
+				f.path+".LogGroupRef",
+			)
+		}
+	}
+	return nil
+}
+
+// resolveReferenceForHTTPEndpointDestinationConfiguration_CloudWatchLoggingOptions_LogGroupName
+// reads the resource referenced from the
+// HTTPEndpointDestinationConfiguration.CloudWatchLoggingOptions.LogGroupRef
+// field and sets the
+// HTTPEndpointDestinationConfiguration.CloudWatchLoggingOptions.LogGroupName
+// from referenced resource. Returns a boolean indicating whether a reference
+// contains references, or an error
+func (rm *resourceManager) resolveReferenceForHTTPEndpointDestinationConfiguration_CloudWatchLoggingOptions_LogGroupName(
+	ctx context.Context,
+	apiReader client.Reader,
+	ko *svcapitypes.DeliveryStream,
+) (hasReferences bool, err error) {
+	if ko.Spec.HTTPEndpointDestinationConfiguration != nil {
+		return rm.resolveLogGroupReference(
+			ctx, apiReader, ko,
+			"HTTPEndpointDestinationConfiguration.CloudWatchLoggingOptions",
+			ko.Spec.HTTPEndpointDestinationConfiguration.CloudWatchLoggingOptions,
+		)
+	}
+	return false, nil
+}
+
+// resolveReferenceForHTTPEndpointDestinationConfiguration_S3Configuration_CloudWatchLoggingOptions_LogGroupName
+// reads the resource referenced from the
+// HTTPEndpointDestinationConfiguration.S3Configuration.CloudWatchLoggingOptions.LogGroupRef
+// field and sets the
+// HTTPEndpointDestinationConfiguration.S3Configuration.CloudWatchLoggingOptions.LogGroupName
+// from referenced resource. Returns a boolean indicating whether a reference
+// contains references, or an error
+func (rm *resourceManager) resolveReferenceForHTTPEndpointDestinationConfiguration_S3Configuration_CloudWatchLoggingOptions_LogGroupName(
+	ctx context.Context,
+	apiReader client.Reader,
+	ko *svcapitypes.DeliveryStream,
+) (hasReferences bool, err error) {
+	if ko.Spec.HTTPEndpointDestinationConfiguration != nil &&
+		ko.Spec.HTTPEndpointDestinationConfiguration.S3Configuration != nil {
+		return rm.resolveLogGroupReference(
+			ctx, apiReader, ko,
+			"HTTPEndpointDestinationConfiguration.S3Configuration.CloudWatchLoggingOptions",
+			ko.Spec.HTTPEndpointDestinationConfiguration.S3Configuration.CloudWatchLoggingOptions,
+		)
+	}
+	return false, nil
+}
+
+// resolveLogGroupReference sets the LogGroupName of opts, found at path in
+// the spec of ko, from the LogGroup its logGroupRef references. The LogGroup
+// must be synced, so that the delivery stream is only created or updated
+// once the log group it logs to exists.
+func (rm *resourceManager) resolveLogGroupReference(
+	ctx context.Context,
+	apiReader client.Reader,
+	ko *svcapitypes.DeliveryStream,
+	path string,
+	opts *svcapitypes.CloudWatchLoggingOptions,
+) (hasReferences bool, err error) {
+	if opts == nil || opts.LogGroupRef == nil || opts.LogGroupRef.From == nil {
+		return false, nil
+	}
+	hasReferences = true
+	arr := opts.LogGroupRef.From
+	if arr.Name == nil || *arr.Name == "" {
+		return hasReferences, fmt.Errorf("provided resource reference is nil or empty: %s.LogGroupRef", path)
+	}
+	namespace, err := ackrt.ResolveCrossNamespaceReference(
+		ctx,
+		rm.cfg.EnableCrossNamespace,
+		&ko.Status.Conditions,
+		ackrt.CrossNamespaceRefKindResource,
+		ko.ObjectMeta.GetNamespace(),
+		arr.Namespace,
+		*arr.Name,
+	)
+	if err != nil {
+		return hasReferences, err
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(logGroupGroupVersionKind)
+	if err := getReferencedResourceState_Unstructured(ctx, apiReader, obj, *arr.Name, namespace); err != nil {
+		return hasReferences, err
+	}
+	name, found, _ := unstructured.NestedString(obj.Object, "spec", "name")
+	if !found || name == "" {
+		return hasReferences, ackerr.ResourceReferenceMissingTargetFieldFor(
+			logGroupGroupVersionKind.Kind,
+			namespace, *arr.Name,
+			"Spec.Name")
+	}
+	opts.LogGroupName = aws.String(name)
+	return hasReferences, nil
+}
@@ -0,0 +1,170 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delivery_stream
+
+import (
+	"context"
+	"testing"
+
+	ackv1alpha1 "github.com/aws-controllers-k8s/runtime/apis/core/v1alpha1"
+	"github.com/aws/aws-sdk-go/aws"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+)
+
+func newLogGroupObject(name, logGroupName string, synced bool) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(logGroupGroupVersionKind)
+	obj.SetNamespace("default")
+	obj.SetName(name)
+	status := "False"
+	if synced {
+		status = "True"
+	}
+	obj.Object["spec"] = map[string]interface{}{
+		"name": logGroupName,
+	}
+	obj.Object["status"] = map[string]interface{}{
+		"conditions": []interface{}{
+			map[string]interface{}{
+				"type":   string(ackv1alpha1.ConditionTypeResourceSynced),
+				"status": status,
+			},
+		},
+	}
+	return obj
+}
+
+func newResourceReference(name string) *ackv1alpha1.AWSResourceReferenceWrapper {
+	return &ackv1alpha1.AWSResourceReferenceWrapper{
+		From: &ackv1alpha1.AWSResourceReference{Name: aws.String(name)},
+	}
+}
+
+func newLogGroupRefResource(destRef, s3Ref string) *resource {
+	dest := &svcapitypes.HTTPEndpointDestinationConfiguration{
+		CloudWatchLoggingOptions: &svcapitypes.CloudWatchLoggingOptions{
+			Enabled: aws.Bool(true),
+		},
+		S3Configuration: &svcapitypes.S3DestinationConfiguration{
+			CloudWatchLoggingOptions: &svcapitypes.CloudWatchLoggingOptions{
+				Enabled: aws.Bool(true),
+			},
+		},
+	}
+	if destRef != "" {
+		dest.CloudWatchLoggingOptions.LogGroupRef = newResourceReference(destRef)
+	}
+	if s3Ref != "" {
+		dest.S3Configuration.CloudWatchLoggingOptions.LogGroupRef = newResourceReference(s3Ref)
+	}
+	r := newHTTPEndpointDestinationResource(dest)
+	r.ko.ObjectMeta = metav1.ObjectMeta{Namespace: "default", Name: "stream"}
+	return r
+}
+
+func TestResolveLogGroupReferences(t *testing.T) {
+	kc := fake.NewClientBuilder().WithObjects(
+		newLogGroupObject("delivery", "/aws/firehose/delivery", true),
+		newLogGroupObject("backup", "/aws/firehose/backup", true),
+		newLogGroupObject("pending", "/aws/firehose/pending", false),
+	).Build()
+	rm := &resourceManager{}
+
+	tests := []struct {
+		name             string
+		r                *resource
+		expectErr        bool
+		expectLogGroup   string
+		expectS3LogGroup string
+	}{
+		{
+			name:           "destination reference sets the log group name",
+			r:              newLogGroupRefResource("delivery", ""),
+			expectLogGroup: "/aws/firehose/delivery",
+		},
+		{
+			name:             "S3 configuration reference sets the log group name",
+			r:                newLogGroupRefResource("", "backup"),
+			expectS3LogGroup: "/aws/firehose/backup",
+		},
+		{
+			name:             "both references are resolved",
+			r:                newLogGroupRefResource("delivery", "backup"),
+			expectLogGroup:   "/aws/firehose/delivery",
+			expectS3LogGroup: "/aws/firehose/backup",
+		},
+		{
+			name:      "unsynced log group blocks the resolution",
+			r:         newLogGroupRefResource("delivery", "pending"),
+			expectErr: true,
+		},
+		{
+			name:      "missing log group blocks the resolution",
+			r:         newLogGroupRefResource("missing", ""),
+			expectErr: true,
+		},
+		{
+			name: "reference and log group name are mutually exclusive",
+			r: func() *resource {
+				r := newLogGroupRefResource("delivery", "")
+				r.ko.Spec.HTTPEndpointDestinationConfiguration.CloudWatchLoggingOptions.LogGroupName = aws.String("/aws/firehose/delivery")
+				return r
+			}(),
+			expectErr: true,
+		},
+	}
+
+	logGroupName := func(opts *svcapitypes.CloudWatchLoggingOptions) string {
+		if opts.LogGroupName == nil {
+			return ""
+		}
+		return *opts.LogGroupName
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, hasReferences, err := rm.ResolveReferences(context.TODO(), kc, tt.r)
+			if !hasReferences {
+				t.Errorf("expected resource to have references")
+			}
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			dest := res.(*resource).ko.Spec.HTTPEndpointDestinationConfiguration
+			if got := logGroupName(dest.CloudWatchLoggingOptions); got != tt.expectLogGroup {
+				t.Errorf("expected log group name %q, got %q", tt.expectLogGroup, got)
+			}
+			if got := logGroupName(dest.S3Configuration.CloudWatchLoggingOptions); got != tt.expectS3LogGroup {
+				t.Errorf("expected S3 configuration log group name %q, got %q", tt.expectS3LogGroup, got)
+			}
+
+			cleared := rm.ClearResolvedReferences(res).(*resource)
+			for _, f := range cloudWatchLoggingOptions(cleared.ko) {
+				if f.opts.LogGroupRef != nil && f.opts.LogGroupName != nil {
+					t.Errorf("expected %s.LogGroupName to be cleared", f.path)
+				}
+			}
+		})
+	}
+}
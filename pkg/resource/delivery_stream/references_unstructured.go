@@ -0,0 +1,74 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delivery_stream
+
+import (
+	"context"
+
+	ackv1alpha1 "github.com/aws-controllers-k8s/runtime/apis/core/v1alpha1"
+	ackerr "github.com/aws-controllers-k8s/runtime/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// getReferencedResourceState_Unstructured looks up whether a referenced
+// resource of another ACK controller, read as an unstructured object of the
+// kind set on obj, exists and is in a ACK.ResourceSynced=True state. If the
+// referenced resource does exist and is in a Synced state, returns nil,
+// otherwise returns `ackerr.ResourceReferenceTerminalFor` or
+// `ResourceReferenceNotSyncedFor` depending on if the resource is in a
+// Terminal state.
+func getReferencedResourceState_Unstructured(
+	ctx context.Context,
+	apiReader client.Reader,
+	obj *unstructured.Unstructured,
+	name string, // the Kubernetes name of the referenced resource
+	namespace string, // the Kubernetes namespace of the referenced resource
+) error {
+	kind := obj.GetKind()
+	namespacedName := types.NamespacedName{
+		Namespace: namespace,
+		Name:      name,
+	}
+	err := apiReader.Get(ctx, namespacedName, obj)
+	if err != nil {
+		return err
+	}
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	var refResourceSynced bool
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if cond["type"] == string(ackv1alpha1.ConditionTypeTerminal) &&
+			cond["status"] == string(corev1.ConditionTrue) {
+			return ackerr.ResourceReferenceTerminalFor(
+				kind,
+				namespace, name)
+		}
+		if cond["type"] == string(ackv1alpha1.ConditionTypeResourceSynced) &&
+			cond["status"] == string(corev1.ConditionTrue) {
+			refResourceSynced = true
+		}
+	}
+	if !refResourceSynced {
+		return ackerr.ResourceReferenceNotSyncedFor(
+			kind,
+			namespace, name)
+	}
+	return nil
+}
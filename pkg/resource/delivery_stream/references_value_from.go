@@ -0,0 +1,292 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delivery_stream
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	ackerr "github.com/aws-controllers-k8s/runtime/pkg/errors"
+	ackrequeue "github.com/aws-controllers-k8s/runtime/pkg/requeue"
+	ackrt "github.com/aws-controllers-k8s/runtime/pkg/runtime"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+)
+
+// crossNamespaceRefKindConfigMap labels cross-namespace ConfigMap key
+// references in the cross-namespace warnings of the ACK runtime.
+const crossNamespaceRefKindConfigMap ackrt.CrossNamespaceRefKind = "configmap reference"
+
+// valueFromField is a valueFrom field of the spec.
+type valueFromField struct {
+	// path is the path of the valueFrom field, relative to the spec.
+	path string
+	// targetPath is the path of the field the value is written to.
+	targetPath string
+	// refPath is the path of the resource reference field the valueFrom
+	// field is an alternative to.
+	refPath string
+	from    *svcapitypes.ValueFrom
+	// hasRef and hasTarget indicate whether the resource reference and the
+	// target field are set.
+	hasRef    bool
+	hasTarget bool
+	// set writes the value to the target field.
+	set func(value string)
+	// processor is the processor the field belongs to, nil if it doesn't
+	// belong to a processor.
+	processor *svcapitypes.Processor
+}
+
+// valueFromFields returns the valueFrom fields set in the spec of ko.
+func valueFromFields(ko *svcapitypes.DeliveryStream) []valueFromField {
+	var fields []valueFromField
+	add := func(path, target, ref string, from *svcapitypes.ValueFrom, hasRef bool, targetField **string) {
+		if from == nil {
+			return
+		}
+		fields = append(fields, valueFromField{
+			path:       path + "ValueFrom",
+			targetPath: path + target,
+			refPath:    path + ref,
+			from:       from,
+			hasRef:     hasRef,
+			hasTarget:  *targetField != nil,
+			set:        func(value string) { *targetField = aws.String(value) },
+		})
+	}
+
+	if enc := ko.Spec.DeliveryStreamEncryptionConfiguration; enc != nil {
+		add("DeliveryStreamEncryptionConfiguration.Key", "ARN", "Ref",
+			enc.KeyValueFrom, enc.KeyRef != nil, &enc.KeyARN)
+	}
+	dest := ko.Spec.HTTPEndpointDestinationConfiguration
+	if dest == nil {
+		return fields
+	}
+	for _, f := range cloudWatchLoggingOptions(ko) {
+		add(f.path+".LogGroup", "Name", "Ref",
+			f.opts.LogGroupValueFrom, f.opts.LogGroupRef != nil, &f.opts.LogGroupName)
+	}
+	for i, p := range httpEndpointProcessors(ko) {
+		if p == nil || p.LambdaValueFrom == nil {
+			continue
+		}
+		p := p
+		path := fmt.Sprintf("HTTPEndpointDestinationConfiguration.ProcessingConfiguration.Processors[%d]", i)
+		fields = append(fields, valueFromField{
+			path:       path + ".LambdaValueFrom",
+			targetPath: path + ".Parameters." + lambdaArnParameter,
+			refPath:    path + ".LambdaRef",
+			from:       p.LambdaValueFrom,
+			hasRef:     p.LambdaRef != nil,
+			hasTarget:  processorParameter(p, lambdaArnParameter) != nil,
+			set: func(value string) {
+				if param := processorParameter(p, lambdaArnParameter); param != nil {
+					param.ParameterValue = aws.String(value)
+					return
+				}
+				p.Parameters = append(p.Parameters, &svcapitypes.ProcessorParameter{
+					ParameterName:  aws.String(lambdaArnParameter),
+					ParameterValue: aws.String(value),
+				})
+			},
+			processor: p,
+		})
+	}
+	add("HTTPEndpointDestinationConfiguration.Role", "ARN", "Ref",
+		dest.RoleValueFrom, dest.RoleRef != nil, &dest.RoleARN)
+	if s3 := dest.S3Configuration; s3 != nil {
+		add("HTTPEndpointDestinationConfiguration.S3Configuration.Bucket", "ARN", "Ref",
+			s3.BucketValueFrom, s3.BucketRef != nil, &s3.BucketARN)
+		if s3.EncryptionConfiguration != nil && s3.EncryptionConfiguration.KMSEncryptionConfig != nil {
+			kms := s3.EncryptionConfiguration.KMSEncryptionConfig
+			add("HTTPEndpointDestinationConfiguration.S3Configuration.EncryptionConfiguration.KMSEncryptionConfig.AWSKMSKey", "ARN", "Ref",
+				kms.AWSKMSKeyValueFrom, kms.AWSKMSKeyRef != nil, &kms.AWSKMSKeyARN)
+		}
+		add("HTTPEndpointDestinationConfiguration.S3Configuration.Role", "ARN", "Ref",
+			s3.RoleValueFrom, s3.RoleRef != nil, &s3.RoleARN)
+	}
+	if sm := dest.SecretsManagerConfiguration; sm != nil {
+		add("HTTPEndpointDestinationConfiguration.SecretsManagerConfiguration.Role", "ARN", "Ref",
+			sm.RoleValueFrom, sm.RoleRef != nil, &sm.RoleARN)
+		add("HTTPEndpointDestinationConfiguration.SecretsManagerConfiguration.Secret", "ARN", "Ref",
+			sm.SecretValueFrom, sm.SecretRef != nil, &sm.SecretARN)
+	}
+	return fields
+}
+
+// clearResolvedValueFromReferences removes the values of the target fields
+// of every valueFrom field. The LambdaArn parameters of processors are
+// removed by clearResolvedLambdaReferences.
+func clearResolvedValueFromReferences(ko *svcapitypes.DeliveryStream) {
+	enc := ko.Spec.DeliveryStreamEncryptionConfiguration
+	if enc != nil && enc.KeyValueFrom != nil {
+		enc.KeyARN = nil
+	}
+	for _, f := range cloudWatchLoggingOptions(ko) {
+		if f.opts.LogGroupValueFrom != nil {
+			f.opts.LogGroupName = nil
+		}
+	}
+	dest := ko.Spec.HTTPEndpointDestinationConfiguration
+	if dest == nil {
+		return
+	}
+	if dest.RoleValueFrom != nil {
+		dest.RoleARN = nil
+	}
+	if s3 := dest.S3Configuration; s3 != nil {
+		if s3.BucketValueFrom != nil {
+			s3.BucketARN = nil
+		}
+		if s3.EncryptionConfiguration != nil && s3.EncryptionConfiguration.KMSEncryptionConfig != nil &&
+			s3.EncryptionConfiguration.KMSEncryptionConfig.AWSKMSKeyValueFrom != nil {
+			s3.EncryptionConfiguration.KMSEncryptionConfig.AWSKMSKeyARN = nil
+		}
+		if s3.RoleValueFrom != nil {
+			s3.RoleARN = nil
+		}
+	}
+	if sm := dest.SecretsManagerConfiguration; sm != nil {
+		if sm.RoleValueFrom != nil {
+			sm.RoleARN = nil
+		}
+		if sm.SecretValueFrom != nil {
+			sm.SecretARN = nil
+		}
+	}
+}
+
+// validateValueFromFields validates that valueFrom fields set exactly one
+// complete source and are set neither together with the resource reference
+// they are an alternative to nor with their target field.
+func validateValueFromFields(ko *svcapitypes.DeliveryStream) error {
+	for _, f := range valueFromFields(ko) {
+		if f.hasRef {
+			return fmt.Errorf("only one of %s and %s may be set", f.refPath, f.path)
+		}
+		if f.hasTarget {
+			return ackerr.ResourceReferenceAndIDNotSupportedFor(f.targetPath, f.path)
+		}
+		if f.processor != nil && (f.processor.Type == nil || *f.processor.Type != lambdaProcessorType) {
+			return fmt.Errorf("%s is only supported for processors of type %s", f.path, lambdaProcessorType)
+		}
+		cm, secret := f.from.ConfigMapKeyRef, f.from.SecretKeyRef
+		switch {
+		case (cm == nil) == (secret == nil):
+			return fmt.Errorf("exactly one of %s.ConfigMapKeyRef and %s.SecretKeyRef must be set", f.path, f.path)
+		case cm != nil && (cm.Name == "" || cm.Key == ""):
+			return fmt.Errorf("%s.ConfigMapKeyRef must set a name and a key", f.path)
+		case secret != nil && (secret.Name == "" || secret.Key == ""):
+			return fmt.Errorf("%s.SecretKeyRef must set a name and a key", f.path)
+		}
+	}
+	return nil
+}
+
+// resolveValueFromReferences sets the target field of every valueFrom field
+// of ko from the ConfigMap or Secret key it selects. Leading and trailing
+// whitespace of the value is ignored. Returns a boolean indicating whether ko
+// contains valueFrom fields, or an error. Missing ConfigMaps, Secrets and
+// keys requeue the resource, as they may be created after it.
+func (rm *resourceManager) resolveValueFromReferences(
+	ctx context.Context,
+	apiReader client.Reader,
+	ko *svcapitypes.DeliveryStream,
+) (hasReferences bool, err error) {
+	for _, f := range valueFromFields(ko) {
+		hasReferences = true
+		value, err := rm.readValueFrom(ctx, apiReader, ko, f.from)
+		if err != nil {
+			return hasReferences, fmt.Errorf("unable to resolve %s: %w", f.path, err)
+		}
+		f.set(value)
+	}
+	return hasReferences, nil
+}
+
+// readValueFrom returns the value of the ConfigMap or Secret key selected by
+// from.
+func (rm *resourceManager) readValueFrom(
+	ctx context.Context,
+	apiReader client.Reader,
+	ko *svcapitypes.DeliveryStream,
+	from *svcapitypes.ValueFrom,
+) (string, error) {
+	var (
+		kind      string
+		refKind   ackrt.CrossNamespaceRefKind
+		name, key string
+		refNs     string
+		obj       client.Object
+	)
+	if cm := from.ConfigMapKeyRef; cm != nil {
+		kind, refKind, name, key, refNs = "ConfigMap", crossNamespaceRefKindConfigMap, cm.Name, cm.Key, cm.Namespace
+		obj = &corev1.ConfigMap{}
+	} else {
+		secret := from.SecretKeyRef
+		kind, refKind, name, key, refNs = "Secret", ackrt.CrossNamespaceRefKindSecret, secret.Name, secret.Key, secret.Namespace
+		obj = &corev1.Secret{}
+	}
+	namespace, err := ackrt.ResolveCrossNamespaceReferenceString(
+		ctx,
+		rm.cfg.EnableCrossNamespace,
+		&ko.Status.Conditions,
+		refKind,
+		ko.ObjectMeta.GetNamespace(),
+		refNs,
+		name,
+	)
+	if err != nil {
+		return "", err
+	}
+	nsn := types.NamespacedName{Namespace: namespace, Name: name}
+	if err := apiReader.Get(ctx, nsn, obj); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", ackrequeue.NeededAfter(
+				fmt.Errorf("%s %s not found", kind, nsn), requeueWaitReferences)
+		}
+		return "", err
+	}
+
+	var (
+		value string
+		found bool
+	)
+	switch o := obj.(type) {
+	case *corev1.ConfigMap:
+		if value, found = o.Data[key]; !found {
+			var data []byte
+			data, found = o.BinaryData[key]
+			value = string(data)
+		}
+	case *corev1.Secret:
+		var data []byte
+		data, found = o.Data[key]
+		value = string(data)
+	}
+	value = strings.TrimSpace(value)
+	if !found || value == "" {
+		return "", ackrequeue.NeededAfter(
+			fmt.Errorf("key %q of %s %s is missing or empty", key, kind, nsn), requeueWaitReferences)
+	}
+	return value, nil
+}
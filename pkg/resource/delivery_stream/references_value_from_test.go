@@ -0,0 +1,189 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package delivery_stream
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	ackv1alpha1 "github.com/aws-controllers-k8s/runtime/apis/core/v1alpha1"
+	ackrequeue "github.com/aws-controllers-k8s/runtime/pkg/requeue"
+	"github.com/aws/aws-sdk-go/aws"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+)
+
+func newConfigMapValueFrom(name, key string) *svcapitypes.ValueFrom {
+	return &svcapitypes.ValueFrom{
+		ConfigMapKeyRef: &svcapitypes.ConfigMapKeyReference{Name: name, Key: key},
+	}
+}
+
+func newSecretValueFrom(name, key string) *svcapitypes.ValueFrom {
+	ref := &ackv1alpha1.SecretKeyReference{Key: key}
+	ref.Name = name
+	return &svcapitypes.ValueFrom{SecretKeyRef: ref}
+}
+
+func newValueFromResource(dest *svcapitypes.HTTPEndpointDestinationConfiguration) *resource {
+	r := newHTTPEndpointDestinationResource(dest)
+	r.ko.ObjectMeta = metav1.ObjectMeta{Namespace: "default", Name: "stream"}
+	return r
+}
+
+func TestResolveValueFromReferences(t *testing.T) {
+	kc := fake.NewClientBuilder().WithObjects(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "outputs"},
+			Data: map[string]string{
+				"role":   "arn:aws:iam::123456789012:role/firehose\n",
+				"lambda": "arn:aws:lambda:us-west-2:123456789012:function:transform",
+				"empty":  "",
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "bucket"},
+			Data:       map[string][]byte{"arn": []byte("arn:aws:s3:::bucket")},
+		},
+	).Build()
+	rm := &resourceManager{awsAccountID: "123456789012"}
+
+	tests := []struct {
+		name          string
+		r             *resource
+		expectErr     bool
+		expectRequeue bool
+		expectRole    string
+		expectBucket  string
+		expectLambda  string
+	}{
+		{
+			name: "role from a ConfigMap and bucket from a Secret",
+			r: newValueFromResource(&svcapitypes.HTTPEndpointDestinationConfiguration{
+				RoleValueFrom: newConfigMapValueFrom("outputs", "role"),
+				S3Configuration: &svcapitypes.S3DestinationConfiguration{
+					BucketValueFrom: newSecretValueFrom("bucket", "arn"),
+				},
+			}),
+			expectRole:   "arn:aws:iam::123456789012:role/firehose",
+			expectBucket: "arn:aws:s3:::bucket",
+		},
+		{
+			name: "lambda processor from a ConfigMap",
+			r: newValueFromResource(&svcapitypes.HTTPEndpointDestinationConfiguration{
+				ProcessingConfiguration: &svcapitypes.ProcessingConfiguration{
+					Processors: []*svcapitypes.Processor{{
+						Type:            aws.String(lambdaProcessorType),
+						LambdaValueFrom: newConfigMapValueFrom("outputs", "lambda"),
+					}},
+				},
+			}),
+			expectLambda: "arn:aws:lambda:us-west-2:123456789012:function:transform",
+		},
+		{
+			name: "missing ConfigMap is requeued",
+			r: newValueFromResource(&svcapitypes.HTTPEndpointDestinationConfiguration{
+				RoleValueFrom: newConfigMapValueFrom("missing", "role"),
+			}),
+			expectErr:     true,
+			expectRequeue: true,
+		},
+		{
+			name: "empty key is requeued",
+			r: newValueFromResource(&svcapitypes.HTTPEndpointDestinationConfiguration{
+				RoleValueFrom: newConfigMapValueFrom("outputs", "empty"),
+			}),
+			expectErr:     true,
+			expectRequeue: true,
+		},
+		{
+			name: "reference and valueFrom are mutually exclusive",
+			r: newValueFromResource(&svcapitypes.HTTPEndpointDestinationConfiguration{
+				RoleRef:       newResourceReference("role"),
+				RoleValueFrom: newConfigMapValueFrom("outputs", "role"),
+			}),
+			expectErr: true,
+		},
+		{
+			name: "target field and valueFrom are mutually exclusive",
+			r: newValueFromResource(&svcapitypes.HTTPEndpointDestinationConfiguration{
+				RoleARN:       aws.String("arn:aws:iam::123456789012:role/firehose"),
+				RoleValueFrom: newConfigMapValueFrom("outputs", "role"),
+			}),
+			expectErr: true,
+		},
+		{
+			name: "exactly one source must be set",
+			r: newValueFromResource(&svcapitypes.HTTPEndpointDestinationConfiguration{
+				RoleValueFrom: &svcapitypes.ValueFrom{
+					ConfigMapKeyRef: newConfigMapValueFrom("outputs", "role").ConfigMapKeyRef,
+					SecretKeyRef:    newSecretValueFrom("bucket", "arn").SecretKeyRef,
+				},
+			}),
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, hasReferences, err := rm.ResolveReferences(context.TODO(), kc, tt.r)
+			if !hasReferences {
+				t.Errorf("expected resource to have references")
+			}
+			if tt.expectErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.expectErr, err)
+			}
+			var requeue *ackrequeue.RequeueNeededAfter
+			if tt.expectRequeue && !errors.As(err, &requeue) {
+				t.Errorf("expected requeue %v, got %v", tt.expectRequeue, err)
+			}
+			if err != nil {
+				return
+			}
+
+			ko := res.(*resource).ko
+			dest := ko.Spec.HTTPEndpointDestinationConfiguration
+			if got := aws.StringValue(dest.RoleARN); got != tt.expectRole {
+				t.Errorf("expected role ARN %q, got %q", tt.expectRole, got)
+			}
+			var bucket string
+			if dest.S3Configuration != nil {
+				bucket = aws.StringValue(dest.S3Configuration.BucketARN)
+			}
+			if bucket != tt.expectBucket {
+				t.Errorf("expected bucket ARN %q, got %q", tt.expectBucket, bucket)
+			}
+			var lambda string
+			for _, p := range httpEndpointProcessors(ko) {
+				if param := processorParameter(p, lambdaArnParameter); param != nil {
+					lambda = aws.StringValue(param.ParameterValue)
+				}
+			}
+			if lambda != tt.expectLambda {
+				t.Errorf("expected lambda ARN %q, got %q", tt.expectLambda, lambda)
+			}
+
+			cleared := rm.ClearResolvedReferences(res).(*resource)
+			for _, f := range valueFromFields(cleared.ko) {
+				if f.hasTarget {
+					t.Errorf("expected %s to be cleared", f.targetPath)
+				}
+			}
+		})
+	}
+}
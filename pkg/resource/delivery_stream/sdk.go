@@ -166,10 +166,25 @@ func (rm *resourceManager) sdkFind(
 
 	setDestinations(ko, resp)
 
-	ko.Spec.Tags, err = rm.getTags(ctx, *r.ko.Spec.DeliveryStreamName)
+	ko.Spec.Tags, err = rm.getTags(ctx, ko)
 	if err != nil {
 		return nil, err
 	}
+	// Delivery streams owned by another cluster or custom resource are
+	// neither adopted nor read into the status, unless ownership is
+	// explicitly taken over.
+	if err = checkReadOwnership(r, &resource{ko}); err != nil {
+		return nil, err
+	}
+
+	// DescribeDeliveryStream never returns the access key. Recording the hash
+	// of the value currently held by the referenced Secret lets the delta
+	// detect a rotation against the hash recorded when it was last sent. An
+	// unreadable Secret must not prevent the delivery stream from being read,
+	// for instance while it is being deleted, so the recorded hash is kept.
+	if err := rm.setAccessKeyHash(ctx, ko); err != nil {
+		rlog.Debug("unable to resolve access key", "error", err)
+	}
 	return &resource{ko}, nil
 }
 
@@ -209,6 +224,12 @@ func (rm *resourceManager) sdkCreate(
 	defer func() {
 		exit(err)
 	}()
+	if err = validateTags(desired); err != nil {
+		return nil, err
+	}
+	if err = rm.validateLogGroups(ctx, desired); err != nil {
+		return nil, err
+	}
 	input, err := rm.newCreateRequestPayload(ctx, desired)
 	if err != nil {
 		return nil, err
@@ -234,6 +255,14 @@ func (rm *resourceManager) sdkCreate(
 	}
 
 	rm.setStatusDefaults(ko)
+	setLastAppliedSpec(ko)
+	// The access key was resolved a moment ago to build the request, a failure
+	// here only means the key will be sent again on the next update.
+	if err := rm.setAccessKeyHash(ctx, ko); err != nil {
+		rlog.Debug("unable to record access key hash", "error", err)
+	}
+	setTagsHash(ko, ko.Spec.Tags)
+
 	return &resource{ko}, nil
 }
 
@@ -510,6 +539,22 @@ func (rm *resourceManager) sdkUpdate(
 		return desired, requeueWhileEncryptionDisabling
 	}
 
+	var request *resource
+	desired, request, delta, err = handleDrift(desired, latest, delta)
+	if err != nil {
+		return desired, err
+	}
+	if !delta.DifferentAt("Spec") {
+		return desired, nil
+	}
+
+	// Delivery streams owned by another cluster or custom resource are left
+	// untouched, unless ownership is explicitly taken over.
+	takeOver, err := checkOwnership(desired, latest)
+	if err != nil {
+		return desired, err
+	}
+
 	if delta.DifferentAt("Spec.DeliveryStreamEncryptionConfiguration") {
 		err = updateDeliveryStreamEncryptionConfiguration(ctx, desired, rm.sdkapi, rm.metrics)
 		if err != nil {
@@ -517,7 +562,7 @@ func (rm *resourceManager) sdkUpdate(
 		}
 	}
 
-	if delta.DifferentAt("Spec.Tags") {
+	if delta.DifferentAt("Spec.Tags") || takeOver {
 		err = rm.syncTags(ctx, desired, latest)
 		if err != nil {
 			return nil, err
@@ -527,10 +572,30 @@ func (rm *resourceManager) sdkUpdate(
 	if !delta.DifferentExcept("Spec.DeliveryStreamEncryptionConfiguration", "Spec.Tags") {
 		return desired, nil
 	}
+
+	if err = rm.validateLogGroups(ctx, desired); err != nil {
+		return desired, err
+	}
+
+	// UpdateDestination sends the access key currently held by the referenced
+	// Secret, record its hash so that the next rotation is detected.
+	err = rm.setAccessKeyHash(ctx, desired.ko)
+	if err != nil {
+		return nil, err
+	}
 	input, err := rm.newUpdateRequestPayload(ctx, desired, delta)
 	if err != nil {
 		return nil, err
 	}
+	// With the ReportOnly drift policy the drifted fields are sent with their
+	// live values, only the changes made to the custom resource are applied.
+	if request != desired {
+		input, err = rm.newUpdateRequestPayload(ctx, request, delta)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Set CurrentDeliveryStreamVersionId from latest to ensure most
 	// recent version ID is used in the update request.
 	if latest.ko.Status.VersionID != nil {
@@ -792,6 +857,13 @@ func (rm *resourceManager) sdkDelete(
 	defer func() {
 		exit(err)
 	}()
+	// A delivery stream owned by another cluster or custom resource, for
+	// instance one taken over since, is left in place: only the custom
+	// resource is released.
+	if owner := foreignOwner(r, r); owner != "" {
+		rlog.Info("not deleting delivery stream owned elsewhere", "owner", owner)
+		return nil, nil
+	}
 	input, err := rm.newDeleteRequestPayload(r)
 	if err != nil {
 		return nil, err
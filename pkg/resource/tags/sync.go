@@ -2,6 +2,13 @@ package tags
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
 
 	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
 	ackrtlog "github.com/aws-controllers-k8s/runtime/pkg/runtime/log"
@@ -10,6 +17,25 @@ import (
 	svcsdktypes "github.com/aws/aws-sdk-go-v2/service/firehose/types"
 )
 
+const (
+	// MaxTags is the maximum number of tags of a delivery stream, tags
+	// reserved for use by AWS aside. It is also the maximum number of tags,
+	// or tag keys, CreateDeliveryStream, TagDeliveryStream and
+	// UntagDeliveryStream accept in a single call.
+	MaxTags = 50
+	// maxTagKeyLength and maxTagValueLength are the maximum lengths, in
+	// Unicode characters, of tag keys and values.
+	maxTagKeyLength   = 128
+	maxTagValueLength = 256
+	// reservedTagKeyPrefix is the prefix of the tag keys reserved for use by
+	// AWS, which can be neither added nor removed.
+	reservedTagKeyPrefix = "aws:"
+)
+
+// ErrInvalidTags is returned when tags don't meet the Firehose tag
+// restrictions.
+var ErrInvalidTags = errors.New("invalid tags")
+
 type metricsRecorder interface {
 	RecordAPICall(opType string, opID string, err error)
 }
@@ -50,7 +76,12 @@ func GetResourceTags(
 }
 
 // SyncResourceTags uses TagDeliveryStream and UntagDeliveryStream API Calls to add, remove
-// and update resource tags.
+// and update resource tags. The desired tags are validated before any call
+// is made; an error wrapping ErrInvalidTags is returned if they are invalid.
+// As a delivery stream has at most MaxTags tags, each call fits within the
+// limit of the API. A failed untag call doesn't prevent the tag call from
+// being made; the returned error names the tags each failed call was
+// supposed to sync.
 func SyncResourceTags(
 	ctx context.Context,
 	client tagsClient,
@@ -66,80 +97,150 @@ func SyncResourceTags(
 		exit(err)
 	}()
 
+	if err = ValidateTags(desiredTags); err != nil {
+		return err
+	}
+
 	addedOrUpdated, removed := computeTagsDelta(desiredTags, latestTags)
+	var errs []error
 
 	if len(removed) > 0 {
-		_, err = client.UntagDeliveryStream(
+		_, callErr := client.UntagDeliveryStream(
 			ctx,
 			&svcsdk.UntagDeliveryStreamInput{
 				DeliveryStreamName: aws.String(deliveryStreamName),
 				TagKeys:            removed,
 			},
 		)
-		mr.RecordAPICall("UPDATE", "UntagDeliveryStream", err)
-		if err != nil {
-			return err
+		mr.RecordAPICall("UPDATE", "UntagDeliveryStream", callErr)
+		if callErr != nil {
+			errs = append(errs, fmt.Errorf("removing tags %s: %w", strings.Join(removed, ", "), callErr))
 		}
 	}
 
 	if len(addedOrUpdated) > 0 {
-		_, err = client.TagDeliveryStream(
+		_, callErr := client.TagDeliveryStream(
 			ctx,
 			&svcsdk.TagDeliveryStreamInput{
 				DeliveryStreamName: aws.String(deliveryStreamName),
 				Tags:               addedOrUpdated,
 			},
 		)
-		mr.RecordAPICall("UPDATE", "TagDeliveryStream", err)
-		if err != nil {
-			return err
+		mr.RecordAPICall("UPDATE", "TagDeliveryStream", callErr)
+		if callErr != nil {
+			keys := make([]string, 0, len(addedOrUpdated))
+			for _, tag := range addedOrUpdated {
+				keys = append(keys, *tag.Key)
+			}
+			errs = append(errs, fmt.Errorf("adding or updating tags %s: %w", strings.Join(keys, ", "), callErr))
 		}
 	}
+
+	err = errors.Join(errs...)
+	return err
+}
+
+// Hash returns a hash of the supplied tags that doesn't depend on their
+// order. Tags reserved for use by AWS are ignored, as they are never synced.
+func Hash(tags []*svcapitypes.Tag) string {
+	m := tagsMap(tags)
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		if !isReservedTagKey(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, key := range keys {
+		// Keys and values can't contain NUL characters, use them as
+		// separators.
+		fmt.Fprintf(h, "%s\x00%s\x00", key, m[key])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ValidateTags returns an error wrapping ErrInvalidTags if the supplied tags
+// don't meet the Firehose tag restrictions: there must be at most 50 tags, a
+// key must be between 1 and 128 characters long, must not start with the
+// reserved aws: prefix and must not be repeated, and a value must be at most
+// 256 characters long.
+func ValidateTags(tags []*svcapitypes.Tag) error {
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		if tag == nil {
+			continue
+		}
+		if len(seen) == MaxTags {
+			return fmt.Errorf("%w: a delivery stream can have at most %d tags", ErrInvalidTags, MaxTags)
+		}
+		key := aws.ToString(tag.Key)
+		switch {
+		case key == "":
+			return fmt.Errorf("%w: tag key must not be empty", ErrInvalidTags)
+		case utf8.RuneCountInString(key) > maxTagKeyLength:
+			return fmt.Errorf("%w: tag key %q is longer than %d characters", ErrInvalidTags, key, maxTagKeyLength)
+		case isReservedTagKey(key):
+			return fmt.Errorf("%w: tag key %q uses the reserved %s prefix", ErrInvalidTags, key, reservedTagKeyPrefix)
+		case seen[key]:
+			return fmt.Errorf("%w: tag key %q is repeated", ErrInvalidTags, key)
+		case utf8.RuneCountInString(aws.ToString(tag.Value)) > maxTagValueLength:
+			return fmt.Errorf("%w: value of tag %q is longer than %d characters", ErrInvalidTags, key, maxTagValueLength)
+		}
+		seen[key] = true
+	}
 	return nil
 }
 
+// isReservedTagKey returns true if the supplied tag key starts with the
+// prefix reserved for use by AWS.
+func isReservedTagKey(key string) bool {
+	return strings.HasPrefix(strings.ToLower(key), reservedTagKeyPrefix)
+}
+
+// tagsMap returns the supplied tags indexed by key. Tags without a key are
+// ignored and tags without a value have an empty value.
+func tagsMap(tags []*svcapitypes.Tag) map[string]string {
+	m := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		if tag == nil || tag.Key == nil {
+			continue
+		}
+		m[*tag.Key] = aws.ToString(tag.Value)
+	}
+	return m
+}
+
 // computeTagsDelta compares two Tag arrays and return two different list
 // containing the addedOrupdated and removed tags. The removed tags array
-// only contains the tags Keys.
+// only contains the tags Keys. Both lists are sorted by key. Tags reserved
+// for use by AWS are never removed.
 func computeTagsDelta(
 	a []*svcapitypes.Tag,
 	b []*svcapitypes.Tag,
 ) (addedOrUpdated []svcsdktypes.Tag, removed []string) {
+	aTags := tagsMap(a)
+	bTags := tagsMap(b)
 
-	// Find the keys in the Spec have either been added or updated.
 	addedOrUpdated = make([]svcsdktypes.Tag, 0)
-	for _, aTag := range a {
-		found := false
-		for _, bTag := range b {
-			if *aTag.Key == *bTag.Key {
-				if *aTag.Value == *bTag.Value {
-					found = true
-				}
-
-				break
-			}
-		}
-		if !found {
+	for key, value := range aTags {
+		if bValue, found := bTags[key]; !found || bValue != value {
 			addedOrUpdated = append(addedOrUpdated, svcsdktypes.Tag{
-				Key:   aTag.Key,
-				Value: aTag.Value,
+				Key:   aws.String(key),
+				Value: aws.String(value),
 			})
 		}
 	}
+	sort.Slice(addedOrUpdated, func(i, j int) bool {
+		return *addedOrUpdated[i].Key < *addedOrUpdated[j].Key
+	})
 
-	for _, bTag := range b {
-		found := false
-		for _, aTag := range a {
-			if *aTag.Key == *bTag.Key {
-				found = true
-				break
-			}
-		}
-
-		if !found {
-			removed = append(removed, *bTag.Key)
+	for key := range bTags {
+		if _, found := aTags[key]; !found && !isReservedTagKey(key) {
+			removed = append(removed, key)
 		}
 	}
+	sort.Strings(removed)
 
 	return addedOrUpdated, removed
 }
@@ -0,0 +1,294 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package tags
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	svcsdk "github.com/aws/aws-sdk-go-v2/service/firehose"
+	svcsdktypes "github.com/aws/aws-sdk-go-v2/service/firehose/types"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+)
+
+type fakeMetrics struct{}
+
+func (fakeMetrics) RecordAPICall(string, string, error) {}
+
+type fakeTagsClient struct {
+	tagged   [][]svcsdktypes.Tag
+	untagged [][]string
+	// failTag and failUntag make TagDeliveryStream and UntagDeliveryStream
+	// fail.
+	failTag   bool
+	failUntag bool
+}
+
+var errTagLimitExceeded = errors.New("LimitExceededException")
+
+func (c *fakeTagsClient) TagDeliveryStream(_ context.Context, in *svcsdk.TagDeliveryStreamInput, _ ...func(*svcsdk.Options)) (*svcsdk.TagDeliveryStreamOutput, error) {
+	c.tagged = append(c.tagged, in.Tags)
+	if len(in.Tags) > MaxTags || c.failTag {
+		return nil, errTagLimitExceeded
+	}
+	return &svcsdk.TagDeliveryStreamOutput{}, nil
+}
+
+func (c *fakeTagsClient) ListTagsForDeliveryStream(context.Context, *svcsdk.ListTagsForDeliveryStreamInput, ...func(*svcsdk.Options)) (*svcsdk.ListTagsForDeliveryStreamOutput, error) {
+	return &svcsdk.ListTagsForDeliveryStreamOutput{}, nil
+}
+
+func (c *fakeTagsClient) UntagDeliveryStream(_ context.Context, in *svcsdk.UntagDeliveryStreamInput, _ ...func(*svcsdk.Options)) (*svcsdk.UntagDeliveryStreamOutput, error) {
+	c.untagged = append(c.untagged, in.TagKeys)
+	if len(in.TagKeys) > MaxTags || c.failUntag {
+		return nil, errTagLimitExceeded
+	}
+	return &svcsdk.UntagDeliveryStreamOutput{}, nil
+}
+
+func tag(key string, value *string) *svcapitypes.Tag {
+	return &svcapitypes.Tag{Key: aws.String(key), Value: value}
+}
+
+func sdkTag(key, value string) svcsdktypes.Tag {
+	return svcsdktypes.Tag{Key: aws.String(key), Value: aws.String(value)}
+}
+
+func manyTags(n int) []*svcapitypes.Tag {
+	tags := make([]*svcapitypes.Tag, 0, n)
+	for i := 0; i < n; i++ {
+		tags = append(tags, tag(strings.Repeat("k", i+1), aws.String("v")))
+	}
+	return tags
+}
+
+func TestComputeTagsDelta(t *testing.T) {
+	tests := []struct {
+		name                 string
+		a, b                 []*svcapitypes.Tag
+		expectAddedOrUpdated []svcsdktypes.Tag
+		expectRemoved        []string
+	}{
+		{
+			name:                 "empty",
+			expectAddedOrUpdated: []svcsdktypes.Tag{},
+		},
+		{
+			name:                 "equal in a different order",
+			a:                    []*svcapitypes.Tag{tag("a", aws.String("1")), tag("b", aws.String("2"))},
+			b:                    []*svcapitypes.Tag{tag("b", aws.String("2")), tag("a", aws.String("1"))},
+			expectAddedOrUpdated: []svcsdktypes.Tag{},
+		},
+		{
+			name:                 "added, updated and removed",
+			a:                    []*svcapitypes.Tag{tag("c", aws.String("3")), tag("a", aws.String("new"))},
+			b:                    []*svcapitypes.Tag{tag("a", aws.String("old")), tag("b", aws.String("2"))},
+			expectAddedOrUpdated: []svcsdktypes.Tag{sdkTag("a", "new"), sdkTag("c", "3")},
+			expectRemoved:        []string{"b"},
+		},
+		{
+			name:                 "nil values are empty values",
+			a:                    []*svcapitypes.Tag{tag("a", nil), tag("b", nil)},
+			b:                    []*svcapitypes.Tag{tag("a", aws.String("")), tag("b", aws.String("2"))},
+			expectAddedOrUpdated: []svcsdktypes.Tag{sdkTag("b", "")},
+		},
+		{
+			name:                 "nil tags and keys are ignored",
+			a:                    []*svcapitypes.Tag{nil, {Value: aws.String("1")}},
+			b:                    []*svcapitypes.Tag{nil, {Value: aws.String("2")}},
+			expectAddedOrUpdated: []svcsdktypes.Tag{},
+		},
+		{
+			name:                 "reserved tags are not removed",
+			b:                    []*svcapitypes.Tag{tag("aws:cloudformation:stack-name", aws.String("stack"))},
+			expectAddedOrUpdated: []svcsdktypes.Tag{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addedOrUpdated, removed := computeTagsDelta(tt.a, tt.b)
+			if !reflect.DeepEqual(addedOrUpdated, tt.expectAddedOrUpdated) {
+				t.Errorf("expected added or updated tags %v, got %v", tt.expectAddedOrUpdated, addedOrUpdated)
+			}
+			if !reflect.DeepEqual(removed, tt.expectRemoved) {
+				t.Errorf("expected removed tags %v, got %v", tt.expectRemoved, removed)
+			}
+			if EqualTags(tt.a, tt.b) != (len(tt.expectAddedOrUpdated) == 0 && len(tt.expectRemoved) == 0) {
+				t.Errorf("unexpected EqualTags result")
+			}
+		})
+	}
+}
+
+func TestValidateTags(t *testing.T) {
+	tests := []struct {
+		name      string
+		tags      []*svcapitypes.Tag
+		expectErr bool
+	}{
+		{
+			name: "valid tags",
+			tags: []*svcapitypes.Tag{nil, tag("team", aws.String("data")), tag("empty", nil)},
+		},
+		{
+			name: "longest key and value",
+			tags: []*svcapitypes.Tag{tag(strings.Repeat("é", maxTagKeyLength), aws.String(strings.Repeat("é", maxTagValueLength)))},
+		},
+		{
+			name: "most tags",
+			tags: manyTags(MaxTags),
+		},
+		{
+			name:      "too many tags",
+			tags:      manyTags(MaxTags + 1),
+			expectErr: true,
+		},
+		{
+			name:      "empty key",
+			tags:      []*svcapitypes.Tag{{Value: aws.String("v")}},
+			expectErr: true,
+		},
+		{
+			name:      "key too long",
+			tags:      []*svcapitypes.Tag{tag(strings.Repeat("k", maxTagKeyLength+1), nil)},
+			expectErr: true,
+		},
+		{
+			name:      "value too long",
+			tags:      []*svcapitypes.Tag{tag("k", aws.String(strings.Repeat("v", maxTagValueLength+1)))},
+			expectErr: true,
+		},
+		{
+			name:      "reserved prefix",
+			tags:      []*svcapitypes.Tag{tag("AWS:owner", aws.String("v"))},
+			expectErr: true,
+		},
+		{
+			name:      "repeated key",
+			tags:      []*svcapitypes.Tag{tag("k", aws.String("1")), tag("k", aws.String("2"))},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTags(tt.tags)
+			if tt.expectErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.expectErr, err)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidTags) {
+				t.Errorf("expected error to wrap ErrInvalidTags, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSyncResourceTags(t *testing.T) {
+	tests := []struct {
+		name           string
+		desired        []*svcapitypes.Tag
+		latest         []*svcapitypes.Tag
+		failTag        bool
+		failUntag      bool
+		expectInvalid  bool
+		expectErr      string
+		expectTagged   int
+		expectUntagged int
+	}{
+		{
+			name:    "unchanged tags make no calls",
+			desired: []*svcapitypes.Tag{tag("a", aws.String("1"))},
+			latest:  []*svcapitypes.Tag{tag("a", aws.String("1"))},
+		},
+		{
+			name:           "added and removed tags",
+			desired:        []*svcapitypes.Tag{tag("a", aws.String("1"))},
+			latest:         []*svcapitypes.Tag{tag("b", aws.String("2"))},
+			expectTagged:   1,
+			expectUntagged: 1,
+		},
+		{
+			name:          "invalid tags make no calls",
+			desired:       []*svcapitypes.Tag{tag("aws:owner", aws.String("1"))},
+			latest:        []*svcapitypes.Tag{tag("b", aws.String("2"))},
+			expectInvalid: true,
+		},
+		{
+			name:           "the largest tag sets fit in a single call",
+			desired:        manyTags(MaxTags),
+			latest:         []*svcapitypes.Tag{tag("x", nil)},
+			expectTagged:   1,
+			expectUntagged: 1,
+		},
+		{
+			name:           "failed untag call is reported and the tag call is made",
+			desired:        []*svcapitypes.Tag{tag("a", aws.String("1"))},
+			latest:         []*svcapitypes.Tag{tag("b", aws.String("2"))},
+			failUntag:      true,
+			expectErr:      "removing tags b: LimitExceededException",
+			expectTagged:   1,
+			expectUntagged: 1,
+		},
+		{
+			name:         "failed tag call is reported",
+			desired:      []*svcapitypes.Tag{tag("a", aws.String("1")), tag("c", nil)},
+			failTag:      true,
+			expectErr:    "adding or updating tags a, c: LimitExceededException",
+			expectTagged: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeTagsClient{failTag: tt.failTag, failUntag: tt.failUntag}
+			err := SyncResourceTags(context.TODO(), client, fakeMetrics{}, "stream", tt.desired, tt.latest)
+			if tt.expectInvalid != errors.Is(err, ErrInvalidTags) {
+				t.Errorf("expected invalid tags error %v, got %v", tt.expectInvalid, err)
+			}
+			if tt.expectErr != "" {
+				if err == nil || err.Error() != tt.expectErr || !errors.Is(err, errTagLimitExceeded) {
+					t.Errorf("expected error %q wrapping the API error, got %v", tt.expectErr, err)
+				}
+			} else if err != nil && !tt.expectInvalid {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(client.tagged) != tt.expectTagged {
+				t.Errorf("expected %d TagDeliveryStream calls, got %d", tt.expectTagged, len(client.tagged))
+			}
+			if len(client.untagged) != tt.expectUntagged {
+				t.Errorf("expected %d UntagDeliveryStream calls, got %d", tt.expectUntagged, len(client.untagged))
+			}
+		})
+	}
+}
+
+func TestHash(t *testing.T) {
+	a := []*svcapitypes.Tag{tag("a", aws.String("1")), tag("b", nil)}
+	b := []*svcapitypes.Tag{tag("b", aws.String("")), tag("a", aws.String("1")), tag("aws:cloudformation:stack-name", aws.String("stack"))}
+	if Hash(a) != Hash(b) {
+		t.Errorf("expected equal tag sets to have the same hash")
+	}
+	if Hash(a) == Hash([]*svcapitypes.Tag{tag("a", aws.String("2")), tag("b", nil)}) {
+		t.Errorf("expected different tag sets to have different hashes")
+	}
+	if Hash([]*svcapitypes.Tag{tag("ab", aws.String(""))}) == Hash([]*svcapitypes.Tag{tag("a", aws.String("b"))}) {
+		t.Errorf("expected keys and values to be separated in the hash")
+	}
+}
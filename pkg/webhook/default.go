@@ -0,0 +1,95 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package webhook
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ctrlrt "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+)
+
+// Defaults documented in the Firehose API reference for the fields of an
+// HTTP endpoint destination.
+const (
+	defaultHTTPBufferingIntervalInSeconds = 300
+	defaultHTTPBufferingSizeInMBs         = 5
+	defaultHTTPRetryDurationInSeconds     = 300
+)
+
+// setupDeliveryStreamDefaulter registers the DeliveryStream defaulting
+// webhook with the manager's webhook server.
+func setupDeliveryStreamDefaulter(mgr ctrlrt.Manager) error {
+	return ctrlrt.NewWebhookManagedBy(mgr, &svcapitypes.DeliveryStream{}).
+		WithDefaulter(&deliveryStreamDefaulter{}).
+		Complete()
+}
+
+// deliveryStreamDefaulter sets the unset fields of a DeliveryStream spec to
+// the values Firehose uses when they are omitted, so that the stored spec
+// matches what DescribeDeliveryStream returns.
+type deliveryStreamDefaulter struct{}
+
+var _ admission.Defaulter[*svcapitypes.DeliveryStream] = &deliveryStreamDefaulter{}
+
+// Default sets the service defaults in the spec of the supplied
+// DeliveryStream. Fields that are already set are left untouched.
+func (d *deliveryStreamDefaulter) Default(
+	_ context.Context,
+	ko *svcapitypes.DeliveryStream,
+) error {
+	if ko.GetDeletionTimestamp() != nil {
+		return nil
+	}
+	setDeliveryStreamSpecDefaults(&ko.Spec)
+	return nil
+}
+
+func setDeliveryStreamSpecDefaults(spec *svcapitypes.DeliveryStreamSpec) {
+	if spec.DeliveryStreamType == nil {
+		spec.DeliveryStreamType = aws.String(string(svcapitypes.DeliveryStreamType_DirectPut))
+	}
+	if dest := spec.HTTPEndpointDestinationConfiguration; dest != nil {
+		setHTTPEndpointDestinationDefaults(dest)
+	}
+}
+
+func setHTTPEndpointDestinationDefaults(dest *svcapitypes.HTTPEndpointDestinationConfiguration) {
+	if dest.BufferingHints == nil {
+		dest.BufferingHints = &svcapitypes.HTTPEndpointBufferingHints{}
+	}
+	if dest.BufferingHints.IntervalInSeconds == nil {
+		dest.BufferingHints.IntervalInSeconds = aws.Int64(defaultHTTPBufferingIntervalInSeconds)
+	}
+	if dest.BufferingHints.SizeInMBs == nil {
+		dest.BufferingHints.SizeInMBs = aws.Int64(defaultHTTPBufferingSizeInMBs)
+	}
+	if dest.RetryOptions == nil {
+		dest.RetryOptions = &svcapitypes.HTTPEndpointRetryOptions{}
+	}
+	if dest.RetryOptions.DurationInSeconds == nil {
+		dest.RetryOptions.DurationInSeconds = aws.Int64(defaultHTTPRetryDurationInSeconds)
+	}
+	if dest.S3BackupMode == nil {
+		dest.S3BackupMode = aws.String(string(svcapitypes.HTTPEndpointS3BackupMode_FailedDataOnly))
+	}
+	if dest.CloudWatchLoggingOptions == nil {
+		dest.CloudWatchLoggingOptions = &svcapitypes.CloudWatchLoggingOptions{
+			Enabled: aws.Bool(false),
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"k8s.io/apimachinery/pkg/api/equality"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+)
+
+func TestDefault(t *testing.T) {
+	ko := newDeliveryStream(func(spec *svcapitypes.DeliveryStreamSpec) {
+		spec.DeliveryStreamType = nil
+		spec.HTTPEndpointDestinationConfiguration.BufferingHints = &svcapitypes.HTTPEndpointBufferingHints{
+			SizeInMBs: aws.Int64(1),
+		}
+	})
+	if err := (&deliveryStreamDefaulter{}).Default(context.TODO(), ko); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := newDeliveryStream(func(spec *svcapitypes.DeliveryStreamSpec) {
+		dest := spec.HTTPEndpointDestinationConfiguration
+		dest.BufferingHints = &svcapitypes.HTTPEndpointBufferingHints{
+			IntervalInSeconds: aws.Int64(300),
+			SizeInMBs:         aws.Int64(1),
+		}
+		dest.RetryOptions = &svcapitypes.HTTPEndpointRetryOptions{DurationInSeconds: aws.Int64(300)}
+		dest.S3BackupMode = aws.String("FailedDataOnly")
+		dest.CloudWatchLoggingOptions = &svcapitypes.CloudWatchLoggingOptions{Enabled: aws.Bool(false)}
+	})
+	if !equality.Semantic.DeepEqual(ko.Spec, expected.Spec) {
+		t.Errorf("expected spec %+v, got %+v", expected.Spec, ko.Spec)
+	}
+
+	// Defaulting is idempotent and leaves set fields untouched.
+	ko.Spec.HTTPEndpointDestinationConfiguration.S3BackupMode = aws.String("AllData")
+	ko.Spec.HTTPEndpointDestinationConfiguration.CloudWatchLoggingOptions = &svcapitypes.CloudWatchLoggingOptions{
+		LogGroupName: aws.String("group"),
+	}
+	expected = ko.DeepCopy()
+	if err := (&deliveryStreamDefaulter{}).Default(context.TODO(), ko); err != nil {
+		t.Fatal(err)
+	}
+	if !equality.Semantic.DeepEqual(ko.Spec, expected.Spec) {
+		t.Errorf("expected spec %+v, got %+v", expected.Spec, ko.Spec)
+	}
+
+	ko = newDeliveryStream(func(spec *svcapitypes.DeliveryStreamSpec) {
+		spec.DeliveryStreamType = nil
+		spec.HTTPEndpointDestinationConfiguration = nil
+	})
+	if err := (&deliveryStreamDefaulter{}).Default(context.TODO(), ko); err != nil {
+		t.Fatal(err)
+	}
+	if ko.Spec.DeliveryStreamType == nil || *ko.Spec.DeliveryStreamType != "DirectPut" ||
+		ko.Spec.HTTPEndpointDestinationConfiguration != nil {
+		t.Errorf("expected only the delivery stream type to be defaulted, got %+v", ko.Spec)
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package webhook
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+)
+
+// Limits documented in the Firehose API reference.
+const (
+	maxDeliveryStreamNameLength = 64
+	minBufferingSizeInMBs       = 1
+	maxHTTPBufferingSizeInMBs   = 64
+	maxS3BufferingSizeInMBs     = 128
+	maxBufferingIntervalSeconds = 900
+	maxRetryDurationInSeconds   = 7200
+	maxCommonAttributes         = 50
+	maxAttributeNameLength      = 256
+	httpsScheme                 = "https"
+)
+
+var deliveryStreamNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+var deliveryStreamTypes = []string{
+	string(svcapitypes.DeliveryStreamType_DirectPut),
+	string(svcapitypes.DeliveryStreamType_KinesisStreamAsSource),
+	string(svcapitypes.DeliveryStreamType_MSKAsSource),
+	string(svcapitypes.DeliveryStreamType_DatabaseAsSource),
+}
+
+// sourceConfigurations maps the delivery stream types that read from a
+// source to the source configuration Firehose requires for them, none of
+// which DeliveryStreamSpec exposes.
+var sourceConfigurations = map[string]string{
+	string(svcapitypes.DeliveryStreamType_KinesisStreamAsSource): "KinesisStreamSourceConfiguration",
+	string(svcapitypes.DeliveryStreamType_MSKAsSource):           "MSKSourceConfiguration",
+	string(svcapitypes.DeliveryStreamType_DatabaseAsSource):      "DatabaseSourceConfiguration",
+}
+
+var s3BackupModes = []string{
+	string(svcapitypes.HTTPEndpointS3BackupMode_FailedDataOnly),
+	string(svcapitypes.HTTPEndpointS3BackupMode_AllData),
+}
+
+// validateDeliveryStreamSpec returns the errors of the supplied spec that
+// Firehose would reject with an InvalidArgumentException. old is the spec
+// being updated, nil on create. The delivery stream type is only checked on
+// create or when it changes, so that delivery streams of a type that can't be
+// created with this API, adopted or created before the webhook was enabled,
+// can still be updated.
+func validateDeliveryStreamSpec(old, spec *svcapitypes.DeliveryStreamSpec) field.ErrorList {
+	var errs field.ErrorList
+	path := field.NewPath("spec")
+
+	if name := spec.DeliveryStreamName; name != nil {
+		namePath := path.Child("deliveryStreamName")
+		switch {
+		case len(*name) == 0 || len(*name) > maxDeliveryStreamNameLength:
+			errs = append(errs, field.Invalid(namePath, *name,
+				"must be between 1 and 64 characters long"))
+		case !deliveryStreamNameRegexp.MatchString(*name):
+			errs = append(errs, field.Invalid(namePath, *name,
+				"must only contain letters, digits, '_', '.' and '-'"))
+		}
+	}
+	if t := spec.DeliveryStreamType; t != nil && (old == nil || old.DeliveryStreamType == nil || *old.DeliveryStreamType != *t) {
+		typePath := path.Child("deliveryStreamType")
+		switch {
+		case !slices.Contains(deliveryStreamTypes, *t):
+			errs = append(errs, field.NotSupported(typePath, *t, deliveryStreamTypes))
+		case sourceConfigurations[*t] != "":
+			errs = append(errs, field.Invalid(typePath, *t,
+				"requires a "+sourceConfigurations[*t]+", which can't be specified"))
+		}
+	}
+
+	destinations := setDestinations(spec)
+	switch len(destinations) {
+	case 0:
+		errs = append(errs, field.Required(path.Child("httpEndpointDestinationConfiguration"),
+			"a destination configuration must be specified"))
+	case 1:
+	default:
+		for _, name := range destinations[1:] {
+			errs = append(errs, field.Forbidden(path.Child(name),
+				"only one destination configuration can be specified, found "+destinations[0]))
+		}
+	}
+	if dest := spec.HTTPEndpointDestinationConfiguration; dest != nil {
+		errs = append(errs, validateHTTPEndpointDestination(dest, path.Child("httpEndpointDestinationConfiguration"))...)
+	}
+	return errs
+}
+
+// setDestinations returns the JSON names of the destination configurations
+// set in the supplied spec.
+func setDestinations(spec *svcapitypes.DeliveryStreamSpec) []string {
+	var names []string
+	if spec.HTTPEndpointDestinationConfiguration != nil {
+		names = append(names, "httpEndpointDestinationConfiguration")
+	}
+	return names
+}
+
+func validateHTTPEndpointDestination(
+	dest *svcapitypes.HTTPEndpointDestinationConfiguration,
+	path *field.Path,
+) field.ErrorList {
+	var errs field.ErrorList
+
+	endpointPath := path.Child("endpointConfiguration")
+	if dest.EndpointConfiguration == nil {
+		errs = append(errs, field.Required(endpointPath, "the HTTP endpoint must be specified"))
+	} else {
+		errs = append(errs, validateEndpointURL(dest.EndpointConfiguration.URL, endpointPath.Child("url"))...)
+	}
+	if hints := dest.BufferingHints; hints != nil {
+		errs = append(errs, validateBufferingHints(
+			hints.SizeInMBs, hints.IntervalInSeconds, maxHTTPBufferingSizeInMBs, path.Child("bufferingHints"),
+		)...)
+	}
+	if retry := dest.RetryOptions; retry != nil && retry.DurationInSeconds != nil {
+		errs = append(errs, validateRange(
+			*retry.DurationInSeconds, 0, maxRetryDurationInSeconds, path.Child("retryOptions", "durationInSeconds"),
+		)...)
+	}
+	if req := dest.RequestConfiguration; req != nil {
+		errs = append(errs, validateCommonAttributes(
+			req.CommonAttributes, path.Child("requestConfiguration", "commonAttributes"),
+		)...)
+	}
+	if mode := dest.S3BackupMode; mode != nil && !slices.Contains(s3BackupModes, *mode) {
+		errs = append(errs, field.NotSupported(path.Child("s3BackupMode"), *mode, s3BackupModes))
+	}
+	s3Path := path.Child("s3Configuration")
+	if dest.S3Configuration == nil {
+		errs = append(errs, field.Required(s3Path, "the S3 backup configuration must be specified"))
+	} else if hints := dest.S3Configuration.BufferingHints; hints != nil {
+		errs = append(errs, validateBufferingHints(
+			hints.SizeInMBs, hints.IntervalInSeconds, maxS3BufferingSizeInMBs, s3Path.Child("bufferingHints"),
+		)...)
+	}
+	return errs
+}
+
+// validateCommonAttributes checks the number of common attributes, the
+// length of their names and that their names are unique, as the CRD does.
+func validateCommonAttributes(
+	attrs []*svcapitypes.HTTPEndpointCommonAttribute,
+	path *field.Path,
+) field.ErrorList {
+	var errs field.ErrorList
+	if len(attrs) > maxCommonAttributes {
+		errs = append(errs, field.TooMany(path, len(attrs), maxCommonAttributes))
+	}
+	names := make(map[string]bool, len(attrs))
+	for i, attr := range attrs {
+		if attr == nil || attr.AttributeName == nil {
+			continue
+		}
+		name := *attr.AttributeName
+		namePath := path.Index(i).Child("attributeName")
+		if len(name) > maxAttributeNameLength {
+			errs = append(errs, field.TooLong(namePath, name, maxAttributeNameLength))
+		}
+		if names[name] {
+			errs = append(errs, field.Duplicate(namePath, name))
+		}
+		names[name] = true
+	}
+	return errs
+}
+
+// validateEndpointURL checks that the HTTP endpoint URL is an absolute HTTPS
+// URL, the only kind Firehose delivers to.
+func validateEndpointURL(value *string, path *field.Path) field.ErrorList {
+	if value == nil || *value == "" {
+		return field.ErrorList{field.Required(path, "the URL of the HTTP endpoint must be specified")}
+	}
+	u, err := url.Parse(*value)
+	if err != nil {
+		return field.ErrorList{field.Invalid(path, *value, err.Error())}
+	}
+	if u.Scheme != httpsScheme || u.Host == "" {
+		return field.ErrorList{field.Invalid(path, *value, "must be an absolute https:// URL")}
+	}
+	return nil
+}
+
+// validateBufferingHints checks the ranges of the buffering hints, and that
+// both or neither of them are set.
+func validateBufferingHints(
+	sizeInMBs *int64,
+	intervalInSeconds *int64,
+	maxSizeInMBs int64,
+	path *field.Path,
+) field.ErrorList {
+	var errs field.ErrorList
+	switch {
+	case sizeInMBs == nil && intervalInSeconds != nil:
+		errs = append(errs, field.Required(path.Child("sizeInMBs"), "must be set when intervalInSeconds is set"))
+	case sizeInMBs != nil && intervalInSeconds == nil:
+		errs = append(errs, field.Required(path.Child("intervalInSeconds"), "must be set when sizeInMBs is set"))
+	}
+	if sizeInMBs != nil {
+		errs = append(errs, validateRange(*sizeInMBs, minBufferingSizeInMBs, maxSizeInMBs, path.Child("sizeInMBs"))...)
+	}
+	if intervalInSeconds != nil {
+		errs = append(errs, validateRange(*intervalInSeconds, 0, maxBufferingIntervalSeconds, path.Child("intervalInSeconds"))...)
+	}
+	return errs
+}
+
+func validateRange(value, lo, hi int64, path *field.Path) field.ErrorList {
+	if value < lo || value > hi {
+		return field.ErrorList{field.Invalid(path, value, fmt.Sprintf("must be between %d and %d", lo, hi))}
+	}
+	return nil
+}
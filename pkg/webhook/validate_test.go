@@ -0,0 +1,235 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+)
+
+func newDeliveryStream(mutate func(*svcapitypes.DeliveryStreamSpec)) *svcapitypes.DeliveryStream {
+	ko := &svcapitypes.DeliveryStream{
+		ObjectMeta: metav1.ObjectMeta{Name: "stream"},
+		Spec: svcapitypes.DeliveryStreamSpec{
+			DeliveryStreamName: aws.String("stream"),
+			DeliveryStreamType: aws.String("DirectPut"),
+			HTTPEndpointDestinationConfiguration: &svcapitypes.HTTPEndpointDestinationConfiguration{
+				BufferingHints: &svcapitypes.HTTPEndpointBufferingHints{
+					IntervalInSeconds: aws.Int64(60),
+					SizeInMBs:         aws.Int64(1),
+				},
+				EndpointConfiguration: &svcapitypes.HTTPEndpointConfiguration{
+					URL: aws.String("https://example.com/ingest"),
+				},
+				S3Configuration: &svcapitypes.S3DestinationConfiguration{
+					BucketARN: aws.String("arn:aws:s3:::bucket"),
+				},
+			},
+		},
+	}
+	if mutate != nil {
+		mutate(&ko.Spec)
+	}
+	return ko
+}
+
+func commonAttributes(names ...string) []*svcapitypes.HTTPEndpointCommonAttribute {
+	attrs := make([]*svcapitypes.HTTPEndpointCommonAttribute, 0, len(names))
+	for _, name := range names {
+		attrs = append(attrs, &svcapitypes.HTTPEndpointCommonAttribute{
+			AttributeName:  aws.String(name),
+			AttributeValue: aws.String("value"),
+		})
+	}
+	return attrs
+}
+
+func TestValidateCreate(t *testing.T) {
+	tests := []struct {
+		name         string
+		mutate       func(*svcapitypes.DeliveryStreamSpec)
+		expectFields []string
+	}{
+		{
+			name: "valid",
+		},
+		{
+			name: "no destination",
+			mutate: func(spec *svcapitypes.DeliveryStreamSpec) {
+				spec.HTTPEndpointDestinationConfiguration = nil
+			},
+			expectFields: []string{"spec.httpEndpointDestinationConfiguration"},
+		},
+		{
+			name: "kinesis stream as source",
+			mutate: func(spec *svcapitypes.DeliveryStreamSpec) {
+				spec.DeliveryStreamType = aws.String("KinesisStreamAsSource")
+			},
+			expectFields: []string{"spec.deliveryStreamType"},
+		},
+		{
+			name: "unknown type",
+			mutate: func(spec *svcapitypes.DeliveryStreamSpec) {
+				spec.DeliveryStreamType = aws.String("Unknown")
+			},
+			expectFields: []string{"spec.deliveryStreamType"},
+		},
+		{
+			name: "invalid name",
+			mutate: func(spec *svcapitypes.DeliveryStreamSpec) {
+				spec.DeliveryStreamName = aws.String("my stream")
+			},
+			expectFields: []string{"spec.deliveryStreamName"},
+		},
+		{
+			name: "non-HTTPS endpoint",
+			mutate: func(spec *svcapitypes.DeliveryStreamSpec) {
+				spec.HTTPEndpointDestinationConfiguration.EndpointConfiguration.URL = aws.String("http://example.com")
+			},
+			expectFields: []string{"spec.httpEndpointDestinationConfiguration.endpointConfiguration.url"},
+		},
+		{
+			name: "buffering hints out of range",
+			mutate: func(spec *svcapitypes.DeliveryStreamSpec) {
+				spec.HTTPEndpointDestinationConfiguration.BufferingHints.SizeInMBs = aws.Int64(65)
+				spec.HTTPEndpointDestinationConfiguration.BufferingHints.IntervalInSeconds = aws.Int64(901)
+			},
+			expectFields: []string{
+				"spec.httpEndpointDestinationConfiguration.bufferingHints.sizeInMBs",
+				"spec.httpEndpointDestinationConfiguration.bufferingHints.intervalInSeconds",
+			},
+		},
+		{
+			name: "incomplete S3 buffering hints",
+			mutate: func(spec *svcapitypes.DeliveryStreamSpec) {
+				spec.HTTPEndpointDestinationConfiguration.S3Configuration.BufferingHints = &svcapitypes.BufferingHints{
+					SizeInMBs: aws.Int64(128),
+				}
+			},
+			expectFields: []string{"spec.httpEndpointDestinationConfiguration.s3Configuration.bufferingHints.intervalInSeconds"},
+		},
+		{
+			name: "duplicate common attribute names",
+			mutate: func(spec *svcapitypes.DeliveryStreamSpec) {
+				spec.HTTPEndpointDestinationConfiguration.RequestConfiguration = &svcapitypes.HTTPEndpointRequestConfiguration{
+					CommonAttributes: commonAttributes("env", "team", "env"),
+				}
+			},
+			expectFields: []string{"spec.httpEndpointDestinationConfiguration.requestConfiguration.commonAttributes[2].attributeName"},
+		},
+		{
+			name: "too many common attributes",
+			mutate: func(spec *svcapitypes.DeliveryStreamSpec) {
+				names := make([]string, 51)
+				for i := range names {
+					names[i] = fmt.Sprintf("attribute-%d", i)
+				}
+				spec.HTTPEndpointDestinationConfiguration.RequestConfiguration = &svcapitypes.HTTPEndpointRequestConfiguration{
+					CommonAttributes: commonAttributes(names...),
+				}
+			},
+			expectFields: []string{"spec.httpEndpointDestinationConfiguration.requestConfiguration.commonAttributes"},
+		},
+		{
+			name: "common attribute name too long",
+			mutate: func(spec *svcapitypes.DeliveryStreamSpec) {
+				spec.HTTPEndpointDestinationConfiguration.RequestConfiguration = &svcapitypes.HTTPEndpointRequestConfiguration{
+					CommonAttributes: commonAttributes(strings.Repeat("a", 257)),
+				}
+			},
+			expectFields: []string{"spec.httpEndpointDestinationConfiguration.requestConfiguration.commonAttributes[0].attributeName"},
+		},
+		{
+			name: "retry duration and backup mode",
+			mutate: func(spec *svcapitypes.DeliveryStreamSpec) {
+				spec.HTTPEndpointDestinationConfiguration.RetryOptions = &svcapitypes.HTTPEndpointRetryOptions{
+					DurationInSeconds: aws.Int64(7201),
+				}
+				spec.HTTPEndpointDestinationConfiguration.S3BackupMode = aws.String("Never")
+			},
+			expectFields: []string{
+				"spec.httpEndpointDestinationConfiguration.retryOptions.durationInSeconds",
+				"spec.httpEndpointDestinationConfiguration.s3BackupMode",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := (&deliveryStreamValidator{}).ValidateCreate(context.TODO(), newDeliveryStream(tt.mutate))
+			if len(tt.expectFields) == 0 {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			if !apierrors.IsInvalid(err) {
+				t.Fatalf("expected an Invalid error, got %v", err)
+			}
+			for _, f := range tt.expectFields {
+				if !strings.Contains(err.Error(), f+":") {
+					t.Errorf("expected an error for %s, got %v", f, err)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateUpdate(t *testing.T) {
+	invalidSpec := func(spec *svcapitypes.DeliveryStreamSpec) {
+		spec.HTTPEndpointDestinationConfiguration.EndpointConfiguration.URL = aws.String("http://example.com")
+	}
+	v := &deliveryStreamValidator{}
+
+	old := newDeliveryStream(invalidSpec)
+	ko := newDeliveryStream(invalidSpec)
+	ko.Annotations = map[string]string{"example.com/note": "metadata only"}
+	if _, err := v.ValidateUpdate(context.TODO(), old, ko); err != nil {
+		t.Errorf("expected a metadata update to be allowed, got %v", err)
+	}
+
+	ko = newDeliveryStream(invalidSpec)
+	ko.Spec.DeliveryStreamName = aws.String("renamed")
+	if _, err := v.ValidateUpdate(context.TODO(), old, ko); !apierrors.IsInvalid(err) {
+		t.Errorf("expected a spec update to be validated, got %v", err)
+	}
+
+	kinesis := func(spec *svcapitypes.DeliveryStreamSpec) {
+		spec.DeliveryStreamType = aws.String("KinesisStreamAsSource")
+	}
+	old = newDeliveryStream(kinesis)
+	ko = newDeliveryStream(kinesis)
+	ko.Spec.HTTPEndpointDestinationConfiguration.BufferingHints.IntervalInSeconds = aws.Int64(120)
+	if _, err := v.ValidateUpdate(context.TODO(), old, ko); err != nil {
+		t.Errorf("expected an update keeping the delivery stream type to be allowed, got %v", err)
+	}
+
+	old = newDeliveryStream(nil)
+	if _, err := v.ValidateUpdate(context.TODO(), old, ko); !apierrors.IsInvalid(err) {
+		t.Errorf("expected a delivery stream type change to be validated, got %v", err)
+	}
+
+	now := metav1.Now()
+	ko.DeletionTimestamp = &now
+	if _, err := v.ValidateUpdate(context.TODO(), old, ko); err != nil {
+		t.Errorf("expected an update of a deleted resource to be allowed, got %v", err)
+	}
+}
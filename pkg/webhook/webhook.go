@@ -0,0 +1,117 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package webhook contains the admission webhooks of the Firehose service
+// controller. They register themselves with the ACK runtime's webhook
+// registry and are set up by the controller's main function when the webhook
+// server is enabled.
+package webhook
+
+import (
+	"context"
+
+	ackrtwebhook "github.com/aws-controllers-k8s/runtime/pkg/webhook"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrlrt "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+)
+
+// Types of the admission webhooks in the ACK runtime's webhook registry.
+const (
+	WebhookTypeValidating = "validating"
+	WebhookTypeDefaulting = "defaulting"
+)
+
+func init() {
+	for _, w := range []*ackrtwebhook.Webhook{
+		ackrtwebhook.New(
+			svcapitypes.GroupVersion.Version,
+			"DeliveryStream",
+			WebhookTypeValidating,
+			setupDeliveryStreamValidator,
+		),
+		ackrtwebhook.New(
+			svcapitypes.GroupVersion.Version,
+			"DeliveryStream",
+			WebhookTypeDefaulting,
+			setupDeliveryStreamDefaulter,
+		),
+	} {
+		if err := ackrtwebhook.RegisterWebhook(w); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// setupDeliveryStreamValidator registers the DeliveryStream validating
+// webhook with the manager's webhook server.
+func setupDeliveryStreamValidator(mgr ctrlrt.Manager) error {
+	return ctrlrt.NewWebhookManagedBy(mgr, &svcapitypes.DeliveryStream{}).
+		WithValidator(&deliveryStreamValidator{}).
+		Complete()
+}
+
+// deliveryStreamValidator rejects DeliveryStream specs that Firehose would
+// refuse, so that they are caught when the resource is applied rather than
+// when CreateDeliveryStream or UpdateDestination fails.
+type deliveryStreamValidator struct{}
+
+var _ admission.Validator[*svcapitypes.DeliveryStream] = &deliveryStreamValidator{}
+
+// ValidateCreate validates the spec of a new DeliveryStream.
+func (v *deliveryStreamValidator) ValidateCreate(
+	_ context.Context,
+	ko *svcapitypes.DeliveryStream,
+) (admission.Warnings, error) {
+	return nil, invalid(ko, validateDeliveryStreamSpec(nil, &ko.Spec))
+}
+
+// ValidateUpdate validates the spec of an updated DeliveryStream. Updates
+// that leave the spec unchanged, like the metadata updates the controller
+// makes, and updates of resources being deleted are always allowed, so that
+// resources created before the webhook was enabled can still be reconciled
+// and deleted.
+func (v *deliveryStreamValidator) ValidateUpdate(
+	_ context.Context,
+	old *svcapitypes.DeliveryStream,
+	ko *svcapitypes.DeliveryStream,
+) (admission.Warnings, error) {
+	if ko.GetDeletionTimestamp() != nil || equality.Semantic.DeepEqual(old.Spec, ko.Spec) {
+		return nil, nil
+	}
+	return nil, invalid(ko, validateDeliveryStreamSpec(&old.Spec, &ko.Spec))
+}
+
+// ValidateDelete allows every deletion.
+func (v *deliveryStreamValidator) ValidateDelete(
+	context.Context,
+	*svcapitypes.DeliveryStream,
+) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// invalid returns an Invalid API error listing errs, or nil if errs is
+// empty.
+func invalid(ko *svcapitypes.DeliveryStream, errs field.ErrorList) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(
+		svcapitypes.GroupVersion.WithKind("DeliveryStream").GroupKind(),
+		ko.GetName(), errs,
+	)
+}
@@ -1,5 +1,18 @@
+	// Defaults filled in by Firehose and the order of unordered lists are
+	// normalized on copies, the compared resources are left untouched.
+	a, b = normalizeForCompare(a, b)
     // When server-side encryption is disabled DescribeDeliveryStream will return an empty DeliveryStreamEncryptionConfiguration
     // object. 
     if deliveryStreamEncryptionDisabled(a) && deliveryStreamEncryptionDisabled(b) {
 		a.ko.Spec.DeliveryStreamEncryptionConfiguration = b.ko.Spec.DeliveryStreamEncryptionConfiguration
+	}
+	// Tags that changed neither in the spec nor on the delivery stream since
+	// they were last synced are not compared.
+	if tagsUnchanged(a, b) {
+		a.ko.Spec.Tags = b.ko.Spec.Tags
+	}
+	// DescribeDeliveryStream never returns the access key, a rotation of the
+	// referenced Secret is detected by comparing the recorded hashes.
+	if accessKeyHash(a) != accessKeyHash(b) {
+		delta.Add(accessKeyPath, accessKeyRef(a.ko), accessKeyRef(b.ko))
 	}
\ No newline at end of file
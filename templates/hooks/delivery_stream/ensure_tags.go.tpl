@@ -0,0 +1,20 @@
+	r := rm.concreteResource(res)
+	if r.ko == nil {
+		// Should never happen... if it does, it's buggy code.
+		panic("resource manager's EnsureTags method received resource with nil CR object")
+	}
+	defaultTags := ackrt.GetDefaultTags(&rm.cfg, r.ko, md)
+	var existingTags []*svcapitypes.Tag
+	existingTags = r.ko.Spec.Tags
+	resourceTags, keyOrder := convertToOrderedACKTags(existingTags)
+	// The tags the controller manages, the owner tag and the labels listed in
+	// the propagate-label-keys option, are set last so that neither the spec
+	// nor the default tags override them. See setControllerTags for the
+	// precedence rules.
+	ignoreControllerTags(resourceTags)
+	tags := acktags.Merge(resourceTags, defaultTags)
+	if err := setControllerTags(ctx, tags, r.ko); err != nil {
+		return err
+	}
+	r.ko.Spec.Tags = fromACKTags(tags, keyOrder)
+	return nil
@@ -0,0 +1,11 @@
+	r := rm.concreteResource(res)
+	if r == nil || r.ko == nil {
+		return
+	}
+	var existingTags []*svcapitypes.Tag
+	existingTags = r.ko.Spec.Tags
+	resourceTags, tagKeyOrder := convertToOrderedACKTags(existingTags)
+	ignoreSystemTags(resourceTags, systemTags)
+	// The tags the controller manages are not shown in the spec either.
+	ignoreControllerTags(resourceTags)
+	r.ko.Spec.Tags = fromACKTags(resourceTags, tagKeyOrder)
@@ -0,0 +1 @@
+	clearCustomResolvedReferences(ko)
@@ -0,0 +1,5 @@
+	if err == nil {
+		fieldHasReferences, resolveErr := rm.resolveCustomReferences(ctx, apiReader, ko)
+		resourceHasReferences = resourceHasReferences || fieldHasReferences
+		err = resolveErr
+	}
@@ -0,0 +1,13 @@
+	// The reference fields are validated before any of them is resolved, and
+	// the referenced resources read once, to report their state and to resolve
+	// the references from them.
+	if err := validateReferenceFields(ko); err != nil {
+		return &resource{ko}, hasAnyReferences(ko), err
+	}
+	if err := validateCustomReferenceFields(ko); err != nil {
+		return &resource{ko}, hasAnyReferences(ko), err
+	}
+	apiReader = newReferenceReader(apiReader)
+	if fieldHasReferences, err := rm.setReferenceStatuses(ctx, apiReader, ko); err != nil {
+		return &resource{ko}, fieldHasReferences, err
+	}
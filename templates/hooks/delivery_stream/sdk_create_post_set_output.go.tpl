@@ -0,0 +1,7 @@
+    setLastAppliedSpec(ko)
+	// The access key was resolved a moment ago to build the request, a failure
+	// here only means the key will be sent again on the next update.
+	if err := rm.setAccessKeyHash(ctx, ko); err != nil {
+		rlog.Debug("unable to record access key hash", "error", err)
+	}
+	setTagsHash(ko, ko.Spec.Tags)
\ No newline at end of file
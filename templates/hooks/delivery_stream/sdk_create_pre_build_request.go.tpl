@@ -0,0 +1,6 @@
+	if err = validateTags(desired); err != nil {
+		return nil, err
+	}
+	if err = rm.validateLogGroups(ctx, desired); err != nil {
+		return nil, err
+	}
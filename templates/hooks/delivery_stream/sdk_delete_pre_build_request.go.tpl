@@ -0,0 +1,7 @@
+	// A delivery stream owned by another cluster or custom resource, for
+	// instance one taken over since, is left in place: only the custom
+	// resource is released.
+	if owner := foreignOwner(r, r); owner != "" {
+		rlog.Info("not deleting delivery stream owned elsewhere", "owner", owner)
+		return nil, nil
+	}
@@ -22,7 +22,22 @@
 
 	setDestinations(ko, resp)
 
-	ko.Spec.Tags, err = rm.getTags(ctx, *r.ko.Spec.DeliveryStreamName)
+	ko.Spec.Tags, err = rm.getTags(ctx, ko)
 	if err != nil {
 		return nil, err
+	}
+	// Delivery streams owned by another cluster or custom resource are
+	// neither adopted nor read into the status, unless ownership is
+	// explicitly taken over.
+	if err = checkReadOwnership(r, &resource{ko}); err != nil {
+		return nil, err
+	}
+
+	// DescribeDeliveryStream never returns the access key. Recording the hash
+	// of the value currently held by the referenced Secret lets the delta
+	// detect a rotation against the hash recorded when it was last sent. An
+	// unreadable Secret must not prevent the delivery stream from being read,
+	// for instance while it is being deleted, so the recorded hash is kept.
+	if err := rm.setAccessKeyHash(ctx, ko); err != nil {
+		rlog.Debug("unable to resolve access key", "error", err)
 	}
\ No newline at end of file
@@ -1,3 +1,12 @@
+    // With the ReportOnly drift policy the drifted fields are sent with their
+    // live values, only the changes made to the custom resource are applied.
+    if request != desired {
+		input, err = rm.newUpdateRequestPayload(ctx, request, delta)
+		if err != nil {
+			return nil, err
+		}
+	}
+
     // Set CurrentDeliveryStreamVersionId from latest to ensure most
     // recent version ID is used in the update request.
     if latest.ko.Status.VersionID != nil {
@@ -10,6 +10,22 @@
 		return desired, requeueWhileEncryptionDisabling
 	}
 
+	var request *resource
+	desired, request, delta, err = handleDrift(desired, latest, delta)
+	if err != nil {
+		return desired, err
+	}
+	if !delta.DifferentAt("Spec") {
+		return desired, nil
+	}
+
+	// Delivery streams owned by another cluster or custom resource are left
+	// untouched, unless ownership is explicitly taken over.
+	takeOver, err := checkOwnership(desired, latest)
+	if err != nil {
+		return desired, err
+	}
+
 	if delta.DifferentAt("Spec.DeliveryStreamEncryptionConfiguration") {
 		err = updateDeliveryStreamEncryptionConfiguration(ctx, desired, rm.sdkapi, rm.metrics)
 		if err != nil {
@@ -17,7 +33,7 @@
 		}
 	}
 
-	if delta.DifferentAt("Spec.Tags") {
+	if delta.DifferentAt("Spec.Tags") || takeOver {
 		err = rm.syncTags(ctx, desired, latest)
 		if err != nil {
 			return nil, err
@@ -26,4 +42,15 @@
 
 	if !delta.DifferentExcept("Spec.DeliveryStreamEncryptionConfiguration", "Spec.Tags") {
 		return desired, nil
+	}
+
+	if err = rm.validateLogGroups(ctx, desired); err != nil {
+		return desired, err
+	}
+
+	// UpdateDestination sends the access key currently held by the referenced
+	// Secret, record its hash so that the next rotation is detected.
+	err = rm.setAccessKeyHash(ctx, desired.ko)
+	if err != nil {
+		return nil, err
 	}
\ No newline at end of file
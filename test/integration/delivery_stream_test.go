@@ -0,0 +1,459 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	iamapitypes "github.com/aws-controllers-k8s/iam-controller/apis/v1alpha1"
+	kmsapitypes "github.com/aws-controllers-k8s/kms-controller/apis/v1alpha1"
+	ackv1alpha1 "github.com/aws-controllers-k8s/runtime/apis/core/v1alpha1"
+	s3apitypes "github.com/aws-controllers-k8s/s3-controller/apis/v1alpha1"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	svcsdk "github.com/aws/aws-sdk-go-v2/service/firehose"
+	svcsdktypes "github.com/aws/aws-sdk-go-v2/service/firehose/types"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+	svcdeliverystream "github.com/aws-controllers-k8s/firehose-controller/pkg/resource/delivery_stream"
+)
+
+const (
+	roleARN   = "arn:aws:iam::111111111111:role/firehose"
+	bucketARN = "arn:aws:s3:::firehose-backup"
+	keyARN    = "arn:aws:kms:us-west-2:111111111111:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+)
+
+// newNamespace creates a namespace for the objects of a test.
+func newNamespace(t *testing.T) string {
+	t.Helper()
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: "firehose-"}}
+	if err := k8sClient.Create(context.Background(), ns); err != nil {
+		t.Fatalf("creating namespace: %v", err)
+	}
+	return ns.Name
+}
+
+// newDeliveryStream returns a DeliveryStream with an HTTP endpoint
+// destination whose ARNs are set, after applying mutate.
+func newDeliveryStream(namespace, name string, mutate func(*svcapitypes.DeliveryStream)) *svcapitypes.DeliveryStream {
+	ds := &svcapitypes.DeliveryStream{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: svcapitypes.DeliveryStreamSpec{
+			DeliveryStreamName: aws.String(namespace + "-" + name),
+			HTTPEndpointDestinationConfiguration: &svcapitypes.HTTPEndpointDestinationConfiguration{
+				EndpointConfiguration: &svcapitypes.HTTPEndpointConfiguration{
+					URL: aws.String("https://example.com"),
+				},
+				RoleARN: aws.String(roleARN),
+				S3Configuration: &svcapitypes.S3DestinationConfiguration{
+					BucketARN: aws.String(bucketARN),
+					RoleARN:   aws.String(roleARN),
+				},
+			},
+		},
+	}
+	if mutate != nil {
+		mutate(ds)
+	}
+	return ds
+}
+
+// get reads the current state of ds.
+func get(ds *svcapitypes.DeliveryStream) (*svcapitypes.DeliveryStream, error) {
+	latest := &svcapitypes.DeliveryStream{}
+	return latest, k8sClient.Get(context.Background(), client.ObjectKeyFromObject(ds), latest)
+}
+
+// conditionStatus returns the status of the condition of the supplied type.
+func conditionStatus(ds *svcapitypes.DeliveryStream, conditionType ackv1alpha1.ConditionType) corev1.ConditionStatus {
+	for _, c := range ds.Status.Conditions {
+		if c.Type == conditionType {
+			return c.Status
+		}
+	}
+	return corev1.ConditionUnknown
+}
+
+// expectCondition waits for the condition of ds to have the supplied status.
+func expectCondition(t *testing.T, ds *svcapitypes.DeliveryStream, conditionType ackv1alpha1.ConditionType, status corev1.ConditionStatus) *svcapitypes.DeliveryStream {
+	t.Helper()
+	var latest *svcapitypes.DeliveryStream
+	eventually(t, func() (err error) {
+		if latest, err = get(ds); err != nil {
+			return err
+		}
+		if got := conditionStatus(latest, conditionType); got != status {
+			return fmt.Errorf("%s is %s, want %s", conditionType, got, status)
+		}
+		return nil
+	})
+	return latest
+}
+
+// expectSynced waits for ds to be ACTIVE and synced.
+func expectSynced(t *testing.T, ds *svcapitypes.DeliveryStream) *svcapitypes.DeliveryStream {
+	t.Helper()
+	var latest *svcapitypes.DeliveryStream
+	eventually(t, func() (err error) {
+		if latest, err = get(ds); err != nil {
+			return err
+		}
+		if got := aws.ToString(latest.Status.DeliveryStreamStatus); got != "ACTIVE" {
+			return fmt.Errorf("DeliveryStreamStatus is %q, want ACTIVE", got)
+		}
+		if got := conditionStatus(latest, ackv1alpha1.ConditionTypeResourceSynced); got != corev1.ConditionTrue {
+			return fmt.Errorf("%s is %s, want True", ackv1alpha1.ConditionTypeResourceSynced, got)
+		}
+		return nil
+	})
+	return latest
+}
+
+// describe returns the description of a delivery stream in the fake
+// Firehose.
+func describe(name string) (*svcsdktypes.DeliveryStreamDescription, error) {
+	out, err := firehose.DescribeDeliveryStream(context.Background(), &svcsdk.DescribeDeliveryStreamInput{
+		DeliveryStreamName: aws.String(name),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.DeliveryStreamDescription, nil
+}
+
+// update applies mutate to the current state of ds and updates it.
+func update(t *testing.T, ds *svcapitypes.DeliveryStream, mutate func(*svcapitypes.DeliveryStream)) {
+	t.Helper()
+	eventually(t, func() error {
+		latest, err := get(ds)
+		if err != nil {
+			return err
+		}
+		mutate(latest)
+		return k8sClient.Update(context.Background(), latest)
+	})
+}
+
+// setSynced marks a referenced ACK resource synced with the supplied ARN,
+// as its controller would.
+func setSynced(t *testing.T, obj client.Object, status *ackv1alpha1.ResourceMetadata, conditions *[]*ackv1alpha1.Condition, arn string) {
+	t.Helper()
+	resourceARN := ackv1alpha1.AWSResourceName(arn)
+	status.ARN = &resourceARN
+	*conditions = []*ackv1alpha1.Condition{{
+		Type:   ackv1alpha1.ConditionTypeResourceSynced,
+		Status: corev1.ConditionTrue,
+	}}
+	if err := k8sClient.Status().Update(context.Background(), obj); err != nil {
+		t.Fatalf("updating the status of %s: %v", obj.GetName(), err)
+	}
+}
+
+func reference(name string) *ackv1alpha1.AWSResourceReferenceWrapper {
+	return &ackv1alpha1.AWSResourceReferenceWrapper{
+		From: &ackv1alpha1.AWSResourceReference{Name: aws.String(name)},
+	}
+}
+
+func TestReferenceResolution(t *testing.T) {
+	requireEnv(t)
+	ctx := context.Background()
+	ns := newNamespace(t)
+
+	ds := newDeliveryStream(ns, "references", func(ds *svcapitypes.DeliveryStream) {
+		dest := ds.Spec.HTTPEndpointDestinationConfiguration
+		dest.RoleARN, dest.RoleRef = nil, reference("firehose")
+		dest.S3Configuration.BucketARN, dest.S3Configuration.BucketRef = nil, reference("backup")
+		dest.S3Configuration.RoleARN, dest.S3Configuration.RoleRef = nil, reference("firehose")
+		ds.Spec.DeliveryStreamEncryptionConfiguration = &svcapitypes.DeliveryStreamEncryptionConfigurationInput{
+			KeyType: aws.String("CUSTOMER_MANAGED_CMK"),
+			KeyRef:  reference("key"),
+		}
+	})
+	if err := k8sClient.Create(ctx, ds); err != nil {
+		t.Fatalf("creating the DeliveryStream: %v", err)
+	}
+	// The referenced resources don't exist yet.
+	expectCondition(t, ds, ackv1alpha1.ConditionTypeReferencesResolved, corev1.ConditionFalse)
+	if _, err := describe(*ds.Spec.DeliveryStreamName); err == nil {
+		t.Fatal("the delivery stream was created before its references were resolved")
+	}
+
+	role := &iamapitypes.Role{
+		ObjectMeta: metav1.ObjectMeta{Name: "firehose", Namespace: ns},
+		Spec: iamapitypes.RoleSpec{
+			Name:                     aws.String("firehose"),
+			AssumeRolePolicyDocument: aws.String(`{"Version":"2012-10-17","Statement":[]}`),
+		},
+	}
+	bucket := &s3apitypes.Bucket{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: ns},
+		Spec:       s3apitypes.BucketSpec{Name: aws.String("firehose-backup")},
+	}
+	key := &kmsapitypes.Key{ObjectMeta: metav1.ObjectMeta{Name: "key", Namespace: ns}}
+	for _, obj := range []client.Object{role, bucket, key} {
+		if err := k8sClient.Create(ctx, obj); err != nil {
+			t.Fatalf("creating %s: %v", obj.GetName(), err)
+		}
+	}
+	role.Status.ACKResourceMetadata = &ackv1alpha1.ResourceMetadata{}
+	setSynced(t, role, role.Status.ACKResourceMetadata, &role.Status.Conditions, roleARN)
+	bucket.Status.ACKResourceMetadata = &ackv1alpha1.ResourceMetadata{}
+	setSynced(t, bucket, bucket.Status.ACKResourceMetadata, &bucket.Status.Conditions, bucketARN)
+	key.Status.ACKResourceMetadata = &ackv1alpha1.ResourceMetadata{}
+	setSynced(t, key, key.Status.ACKResourceMetadata, &key.Status.Conditions, keyARN)
+
+	expectCondition(t, ds, ackv1alpha1.ConditionTypeReferencesResolved, corev1.ConditionTrue)
+	expectSynced(t, ds)
+
+	description, err := describe(*ds.Spec.DeliveryStreamName)
+	if err != nil {
+		t.Fatalf("describing the delivery stream: %v", err)
+	}
+	dest := description.Destinations[0].HttpEndpointDestinationDescription
+	if got := aws.ToString(dest.RoleARN); got != roleARN {
+		t.Errorf("RoleARN = %q, want %q", got, roleARN)
+	}
+	if got := aws.ToString(dest.S3DestinationDescription.BucketARN); got != bucketARN {
+		t.Errorf("S3 BucketARN = %q, want %q", got, bucketARN)
+	}
+	if got := aws.ToString(description.DeliveryStreamEncryptionConfiguration.KeyARN); got != keyARN {
+		t.Errorf("KeyARN = %q, want %q", got, keyARN)
+	}
+}
+
+func TestConditionTransitions(t *testing.T) {
+	requireEnv(t)
+	ns := newNamespace(t)
+	ds := newDeliveryStream(ns, "conditions", nil)
+	if err := k8sClient.Create(context.Background(), ds); err != nil {
+		t.Fatalf("creating the DeliveryStream: %v", err)
+	}
+
+	// The controller requeues while the delivery stream is CREATING.
+	eventually(t, func() error {
+		latest, err := get(ds)
+		if err != nil {
+			return err
+		}
+		if got := aws.ToString(latest.Status.DeliveryStreamStatus); got != "CREATING" && got != "ACTIVE" {
+			return fmt.Errorf("DeliveryStreamStatus is %q, want CREATING or ACTIVE", got)
+		}
+		if aws.ToString(latest.Status.DeliveryStreamStatus) == "CREATING" &&
+			conditionStatus(latest, ackv1alpha1.ConditionTypeResourceSynced) == corev1.ConditionTrue {
+			return fmt.Errorf("a CREATING delivery stream is reported synced")
+		}
+		return nil
+	})
+	latest := expectSynced(t, ds)
+	if got := aws.ToString(latest.Status.VersionID); got != "1" {
+		t.Errorf("VersionID = %q, want 1", got)
+	}
+
+	// An update is applied and synced.
+	update(t, ds, func(ds *svcapitypes.DeliveryStream) {
+		ds.Spec.HTTPEndpointDestinationConfiguration.BufferingHints = &svcapitypes.HTTPEndpointBufferingHints{
+			IntervalInSeconds: aws.Int64(60),
+			SizeInMBs:         aws.Int64(1),
+		}
+	})
+	eventually(t, func() error {
+		description, err := describe(*ds.Spec.DeliveryStreamName)
+		if err != nil {
+			return err
+		}
+		if got := aws.ToString(description.VersionId); got != "2" {
+			return fmt.Errorf("VersionId is %q, want 2", got)
+		}
+		return nil
+	})
+	expectSynced(t, ds)
+
+	// A rejected update is terminal until the spec changes again.
+	firehose.FailNext("UpdateDestination", &svcsdktypes.InvalidArgumentException{Message: aws.String("rejected")})
+	update(t, ds, func(ds *svcapitypes.DeliveryStream) {
+		ds.Spec.HTTPEndpointDestinationConfiguration.RetryOptions = &svcapitypes.HTTPEndpointRetryOptions{
+			DurationInSeconds: aws.Int64(60),
+		}
+	})
+	expectCondition(t, ds, ackv1alpha1.ConditionTypeTerminal, corev1.ConditionTrue)
+	update(t, ds, func(ds *svcapitypes.DeliveryStream) {
+		ds.Spec.HTTPEndpointDestinationConfiguration.RetryOptions.DurationInSeconds = aws.Int64(120)
+	})
+	expectCondition(t, ds, ackv1alpha1.ConditionTypeTerminal, corev1.ConditionFalse)
+	expectSynced(t, ds)
+}
+
+func TestDeletion(t *testing.T) {
+	requireEnv(t)
+	ctx := context.Background()
+	ns := newNamespace(t)
+
+	deleted := newDeliveryStream(ns, "deleted", nil)
+	retained := newDeliveryStream(ns, "retained", func(ds *svcapitypes.DeliveryStream) {
+		ds.Annotations = map[string]string{ackv1alpha1.AnnotationDeletionPolicy: string(ackv1alpha1.DeletionPolicyRetain)}
+	})
+	for _, ds := range []*svcapitypes.DeliveryStream{deleted, retained} {
+		if err := k8sClient.Create(ctx, ds); err != nil {
+			t.Fatalf("creating the DeliveryStream: %v", err)
+		}
+		latest := expectSynced(t, ds)
+		if !containsFinalizer(latest, svcdeliverystream.FinalizerString) {
+			t.Fatalf("finalizers = %v, want %s", latest.Finalizers, svcdeliverystream.FinalizerString)
+		}
+		if err := k8sClient.Delete(ctx, latest); err != nil {
+			t.Fatalf("deleting the DeliveryStream: %v", err)
+		}
+		// The finalizer is removed once the delivery stream is deleted, or
+		// retained.
+		eventually(t, func() error {
+			if _, err := get(ds); !apierrors.IsNotFound(err) {
+				return fmt.Errorf("the DeliveryStream still exists: %v", err)
+			}
+			return nil
+		})
+	}
+
+	eventually(t, func() error {
+		description, err := describe(*deleted.Spec.DeliveryStreamName)
+		if err == nil && description.DeliveryStreamStatus != svcsdktypes.DeliveryStreamStatusDeleting {
+			return fmt.Errorf("the deleted delivery stream is %s", description.DeliveryStreamStatus)
+		}
+		return nil
+	})
+	description, err := describe(*retained.Spec.DeliveryStreamName)
+	if err != nil {
+		t.Fatalf("describing the retained delivery stream: %v", err)
+	}
+	if description.DeliveryStreamStatus != svcsdktypes.DeliveryStreamStatusActive {
+		t.Errorf("the retained delivery stream is %s, want ACTIVE", description.DeliveryStreamStatus)
+	}
+}
+
+func TestAdoption(t *testing.T) {
+	requireEnv(t)
+	ctx := context.Background()
+	ns := newNamespace(t)
+
+	ds := newDeliveryStream(ns, "adopted", func(ds *svcapitypes.DeliveryStream) {
+		ds.Annotations = map[string]string{ackv1alpha1.AnnotationAdoptionPolicy: "adopt-or-create"}
+	})
+	out, err := firehose.CreateDeliveryStream(ctx, &svcsdk.CreateDeliveryStreamInput{
+		DeliveryStreamName: ds.Spec.DeliveryStreamName,
+		HttpEndpointDestinationConfiguration: &svcsdktypes.HttpEndpointDestinationConfiguration{
+			EndpointConfiguration: &svcsdktypes.HttpEndpointConfiguration{Url: aws.String("https://example.com")},
+			RoleARN:               aws.String(roleARN),
+			S3Configuration: &svcsdktypes.S3DestinationConfiguration{
+				BucketARN: aws.String(bucketARN),
+				RoleARN:   aws.String(roleARN),
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("creating the delivery stream: %v", err)
+	}
+
+	if err := k8sClient.Create(ctx, ds); err != nil {
+		t.Fatalf("creating the DeliveryStream: %v", err)
+	}
+	latest := expectSynced(t, ds)
+	if got := latest.Annotations[ackv1alpha1.AnnotationAdopted]; got != "true" {
+		t.Errorf("%s annotation = %q, want true", ackv1alpha1.AnnotationAdopted, got)
+	}
+	if got := string(*latest.Status.ACKResourceMetadata.ARN); got != aws.ToString(out.DeliveryStreamARN) {
+		t.Errorf("ARN = %q, want %q", got, aws.ToString(out.DeliveryStreamARN))
+	}
+	if !containsFinalizer(latest, svcdeliverystream.FinalizerString) {
+		t.Errorf("finalizers = %v, want %s", latest.Finalizers, svcdeliverystream.FinalizerString)
+	}
+}
+
+func containsFinalizer(obj client.Object, finalizer string) bool {
+	for _, f := range obj.GetFinalizers() {
+		if f == finalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// TestValidationRules checks that the API server enforces the validation
+// rules patched onto the generated CRD.
+func TestValidationRules(t *testing.T) {
+	requireEnv(t)
+	ns := newNamespace(t)
+	attribute := func(name string) *svcapitypes.HTTPEndpointCommonAttribute {
+		return &svcapitypes.HTTPEndpointCommonAttribute{
+			AttributeName:  aws.String(name),
+			AttributeValue: aws.String("value"),
+		}
+	}
+	tests := []struct {
+		name    string
+		mutate  func(*svcapitypes.DeliveryStream)
+		message string
+	}{
+		{
+			name: "no destination",
+			mutate: func(ds *svcapitypes.DeliveryStream) {
+				ds.Spec.HTTPEndpointDestinationConfiguration = nil
+			},
+			message: "exactly one destination configuration must be set",
+		},
+		{
+			name: "role ARN and reference",
+			mutate: func(ds *svcapitypes.DeliveryStream) {
+				ds.Spec.HTTPEndpointDestinationConfiguration.RoleRef = reference("firehose")
+			},
+			message: "only one of roleARN, roleRef and roleValueFrom can be set",
+		},
+		{
+			name: "duplicate common attributes",
+			mutate: func(ds *svcapitypes.DeliveryStream) {
+				ds.Spec.HTTPEndpointDestinationConfiguration.RequestConfiguration = &svcapitypes.HTTPEndpointRequestConfiguration{
+					CommonAttributes: []*svcapitypes.HTTPEndpointCommonAttribute{attribute("env"), attribute("env")},
+				}
+			},
+			message: "attributeName must be unique within commonAttributes",
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := newDeliveryStream(ns, fmt.Sprintf("invalid-%d", i), tt.mutate)
+			err := k8sClient.Create(context.Background(), ds)
+			if !apierrors.IsInvalid(err) || !strings.Contains(err.Error(), tt.message) {
+				t.Fatalf("Create() error = %v, want an invalid error with %q", err, tt.message)
+			}
+		})
+	}
+
+	t.Run("immutable name", func(t *testing.T) {
+		ds := newDeliveryStream(ns, "immutable", nil)
+		if err := k8sClient.Create(context.Background(), ds); err != nil {
+			t.Fatalf("creating %s: %v", ds.Name, err)
+		}
+		ds.Spec.DeliveryStreamName = aws.String("renamed")
+		err := k8sClient.Update(context.Background(), ds)
+		if !apierrors.IsInvalid(err) || !strings.Contains(err.Error(), "Value is immutable once set") {
+			t.Fatalf("Update() error = %v, want an invalid error", err)
+		}
+	})
+}
@@ -0,0 +1,265 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package integration runs the service controller built from
+// cmd/controller against the API server of controller-runtime's envtest and
+// the in-memory Firehose of the fakefirehose package, and exercises full
+// reconcile loops.
+//
+// The tests need the etcd and kube-apiserver binaries of envtest.
+// `make test-integration` installs them with setup-envtest and runs the
+// suite with ENVTEST_REQUIRED set, which fails the suite instead of skipping
+// it when the binaries are missing. The CRDs are installed from helm/crds,
+// which include the validation rules patched onto the generated CRD.
+package integration
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	iamapitypes "github.com/aws-controllers-k8s/iam-controller/apis/v1alpha1"
+	kmsapitypes "github.com/aws-controllers-k8s/kms-controller/apis/v1alpha1"
+	ackv1alpha1 "github.com/aws-controllers-k8s/runtime/apis/core/v1alpha1"
+	s3apitypes "github.com/aws-controllers-k8s/s3-controller/apis/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+
+	svcapitypes "github.com/aws-controllers-k8s/firehose-controller/apis/v1alpha1"
+	"github.com/aws-controllers-k8s/firehose-controller/pkg/fakefirehose"
+)
+
+const (
+	// accountID is the AWS account of the fake Firehose.
+	accountID = "111111111111"
+	// timeout bounds the wait for the controller to reconcile a change. A
+	// delivery stream transition takes up to the requeue delay of the
+	// controller, 5 seconds.
+	timeout = 60 * time.Second
+)
+
+var (
+	// skipReason is set when the suite can't run.
+	skipReason string
+	// k8sClient is a client of the envtest API server.
+	k8sClient client.Client
+	// firehose is the Firehose the controller manages delivery streams in.
+	firehose *fakefirehose.Firehose
+)
+
+// referencedControllers are the modules of the controllers whose resources
+// the DeliveryStream references, and whose CRDs are installed.
+var referencedControllers = []string{
+	"github.com/aws-controllers-k8s/iam-controller",
+	"github.com/aws-controllers-k8s/kms-controller",
+	"github.com/aws-controllers-k8s/s3-controller",
+}
+
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+func run(m *testing.M) int {
+	if !envtestAvailable() {
+		if os.Getenv("ENVTEST_REQUIRED") != "" {
+			return fatal(fmt.Errorf("envtest binaries not found, set KUBEBUILDER_ASSETS to the directory of etcd and kube-apiserver"))
+		}
+		skipReason = "envtest binaries not found, run make test-integration or set KUBEBUILDER_ASSETS to the directory of etcd and kube-apiserver"
+		return m.Run()
+	}
+
+	root, err := filepath.Abs(filepath.Join("..", ".."))
+	if err != nil {
+		return fatal(err)
+	}
+	crdPaths := []string{
+		filepath.Join(root, "helm", "crds"),
+	}
+	for _, module := range referencedControllers {
+		dir, err := moduleDir(root, module)
+		if err != nil {
+			return fatal(err)
+		}
+		crdPaths = append(crdPaths, filepath.Join(dir, "config", "crd", "bases"))
+	}
+
+	testEnv := &envtest.Environment{
+		CRDDirectoryPaths:     crdPaths,
+		ErrorIfCRDPathMissing: true,
+	}
+	cfg, err := testEnv.Start()
+	if err != nil {
+		return fatal(fmt.Errorf("starting envtest: %w", err))
+	}
+	defer testEnv.Stop()
+
+	scheme := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{
+		clientgoscheme.AddToScheme,
+		svcapitypes.AddToScheme,
+		ackv1alpha1.AddToScheme,
+		iamapitypes.AddToScheme,
+		kmsapitypes.AddToScheme,
+		s3apitypes.AddToScheme,
+	} {
+		if err := add(scheme); err != nil {
+			return fatal(err)
+		}
+	}
+	k8sClient, err = client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return fatal(err)
+	}
+
+	firehose = fakefirehose.New(fakefirehose.Options{
+		AccountID:       accountID,
+		TransitionDelay: time.Second,
+	})
+	server := httptest.NewServer(fakefirehose.Handler(firehose))
+	defer server.Close()
+
+	tmp, err := os.MkdirTemp("", "firehose-integration-")
+	if err != nil {
+		return fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	user, err := testEnv.AddUser(envtest.User{Name: "ack-firehose-controller", Groups: []string{"system:masters"}}, nil)
+	if err != nil {
+		return fatal(err)
+	}
+	kubeconfig, err := user.KubeConfig()
+	if err != nil {
+		return fatal(err)
+	}
+	kubeconfigPath := filepath.Join(tmp, "kubeconfig")
+	if err := os.WriteFile(kubeconfigPath, kubeconfig, 0o600); err != nil {
+		return fatal(err)
+	}
+
+	stop, logs, err := startController(root, tmp, kubeconfigPath, server.URL)
+	if err != nil {
+		return fatal(err)
+	}
+	code := m.Run()
+	stop()
+	if code != 0 {
+		fmt.Fprintf(os.Stderr, "controller logs:\n%s\n", logs)
+	}
+	return code
+}
+
+// startController builds cmd/controller and starts it against the envtest
+// API server and the fake Firehose. It returns a function stopping it and
+// the buffer receiving its logs.
+func startController(root, tmp, kubeconfig, endpoint string) (func(), *bytes.Buffer, error) {
+	bin := filepath.Join(tmp, "controller")
+	build := exec.Command("go", "build", "-o", bin, "./cmd/controller")
+	build.Dir = root
+	if out, err := build.CombinedOutput(); err != nil {
+		return nil, nil, fmt.Errorf("building the controller: %w\n%s", err, out)
+	}
+
+	logs := &bytes.Buffer{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd := exec.CommandContext(ctx, bin,
+		"--aws-region", "us-west-2",
+		"--aws-endpoint-url", endpoint,
+		"--aws-identity-endpoint-url", endpoint,
+		"--allow-unsafe-aws-endpoint-urls",
+		"--cluster-id", "envtest",
+		"--metrics-addr", "0",
+		"--healthz-addr", "0",
+		"--enable-development-logging",
+	)
+	cmd.Env = append(os.Environ(),
+		"KUBECONFIG="+kubeconfig,
+		"AWS_ACCESS_KEY_ID=fake",
+		"AWS_SECRET_ACCESS_KEY=fake",
+		"AWS_EC2_METADATA_DISABLED=true",
+	)
+	cmd.Stdout = logs
+	cmd.Stderr = logs
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	return func() {
+		cancel()
+		cmd.Wait()
+	}, logs, nil
+}
+
+// envtestAvailable returns whether the envtest binaries can be found.
+func envtestAvailable() bool {
+	dir := os.Getenv("KUBEBUILDER_ASSETS")
+	if dir == "" {
+		dir = filepath.Join("/usr", "local", "kubebuilder", "bin")
+	}
+	for _, bin := range []string{"etcd", "kube-apiserver"} {
+		if _, err := os.Stat(filepath.Join(dir, bin)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// moduleDir returns the directory of a module the controller depends on.
+func moduleDir(root, module string) (string, error) {
+	cmd := exec.Command("go", "list", "-m", "-f", "{{.Dir}}", module)
+	cmd.Dir = root
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("locating %s: %w", module, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func fatal(err error) int {
+	fmt.Fprintln(os.Stderr, err)
+	return 1
+}
+
+// requireEnv skips the test when the suite can't run.
+func requireEnv(t *testing.T) {
+	t.Helper()
+	if skipReason != "" {
+		t.Skip(skipReason)
+	}
+}
+
+// eventually calls check until it returns nil, failing the test with its
+// last error after timeout.
+func eventually(t *testing.T, check func() error) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for {
+		err := check()
+		if err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out after %s: %v", timeout, err)
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+}